@@ -16,7 +16,11 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
@@ -58,12 +62,23 @@ type BareMetalMachineStatus struct {
 	IPv6        string `json:"ipv6,omitempty"`
 	ServerID    int    `json:"server_number,omitempty"`
 	ServerName  string `json:"server_name,omitempty"`
+	Datacenter  string `json:"datacenter,omitempty"`
 	Ready       bool   `json:"ready,omitempty"`
 	ServerState string `json:"serverState,omitempty"`
 	Cancelled   bool   `json:"cancelled,omitempty"`
 	Reset       bool   `json:"reset,omitempty"`
 	Rescue      bool   `json:"rescue,omitempty"`
 
+	// KubeletVersion is the kubelet version reported by the node of the
+	// machine.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
+	// Remediation records the remediation attempts of the server by a
+	// HcloudRemediation.
+	// +optional
+	Remediation *BareMetalRemediationStatus `json:"remediation,omitempty"`
+
 	// FailureReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -101,18 +116,127 @@ type BareMetalMachineStatus struct {
 	// controller's output.
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the BareMetalMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// BareMetalRemediationAction is an action taken to remediate a bare metal
+// server
+type BareMetalRemediationAction string
+
+const (
+	// BareMetalRemediationSoftReset sends CTRL+ALT+DEL to the server
+	BareMetalRemediationSoftReset = BareMetalRemediationAction("SoftReset")
+	// BareMetalRemediationHardReset resets the hardware of the server
+	BareMetalRemediationHardReset = BareMetalRemediationAction("HardReset")
+	// BareMetalRemediationReinstall fails the machine, so the server is
+	// reinstalled when it is provisioned for the replacement machine
+	BareMetalRemediationReinstall = BareMetalRemediationAction("Reinstall")
+)
+
+// BareMetalRemediationOutcome is the outcome of a remediation action
+type BareMetalRemediationOutcome string
+
+const (
+	BareMetalRemediationSucceeded = BareMetalRemediationOutcome("Succeeded")
+	BareMetalRemediationFailed    = BareMetalRemediationOutcome("Failed")
+)
+
+// BareMetalRemediationStatus records the remediation attempts of a bare
+// metal server
+type BareMetalRemediationStatus struct {
+	// Attempts is the number of remediation actions taken on the server
+	Attempts int `json:"attempts"`
+
+	// LastAction is the last remediation action taken
+	// +optional
+	LastAction BareMetalRemediationAction `json:"lastAction,omitempty"`
+
+	// LastOutcome is the outcome of the last remediation action
+	// +optional
+	LastOutcome BareMetalRemediationOutcome `json:"lastOutcome,omitempty"`
+
+	// LastMessage explains a failed remediation action
+	// +optional
+	LastMessage string `json:"lastMessage,omitempty"`
+
+	// LastAttempted is the time of the last remediation action
+	// +optional
+	LastAttempted *metav1.Time `json:"lastAttempted,omitempty"`
 }
 
 func (h *BareMetalMachine) BareMetalMachineSpec() *BareMetalMachineSpec {
 	return h.Spec.DeepCopy()
 }
 
+// NodeName returns the name of the node, which is the hostname set during
+// provisioning
+func (h *BareMetalMachine) NodeName() string {
+	if h.Status.ServerName != "" {
+		return h.Status.ServerName
+	}
+	return h.Name
+}
+
+// NodeIPAddresses returns the IP addresses of the server
+func (h *BareMetalMachine) NodeIPAddresses() []string {
+	var addresses []string
+	for _, address := range []string{h.Status.IPv4, h.Status.IPv6} {
+		if address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// NodeProviderID returns the provider ID of the server, which is empty until
+// the server is provisioned
+func (h *BareMetalMachine) NodeProviderID() string {
+	if h.Spec.ProviderID == nil {
+		return ""
+	}
+	return *h.Spec.ProviderID
+}
+
+// NodeTopology returns the region and zone of the node. The datacenter of a
+// server is reported as e.g. FSN1-DC14, which is the zone fsn1-dc14 of the
+// region fsn1.
+func (h *BareMetalMachine) NodeTopology() (region, zone string) {
+	zone = strings.ToLower(h.Status.Datacenter)
+	region = strings.SplitN(zone, "-", 2)[0]
+	return region, zone
+}
+
+// NodeInstanceType returns the server type
+func (h *BareMetalMachine) NodeInstanceType() string {
+	if h.Spec.ServerType == nil {
+		return ""
+	}
+	return *h.Spec.ServerType
+}
+
+// NodeAddresses returns the IP addresses of the server as external addresses
+func (h *BareMetalMachine) NodeAddresses() []corev1.NodeAddress {
+	var addresses []corev1.NodeAddress
+	for _, address := range h.NodeIPAddresses() {
+		addresses = append(addresses, corev1.NodeAddress{
+			Type:    corev1.NodeExternalIP,
+			Address: address,
+		})
+	}
+	return addresses
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=baremetalmachines,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.serverState",description="Server state"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Machine ready status"
+// +kubebuilder:printcolumn:name="NodeReady",type="string",JSONPath=".status.conditions[?(@.type==\"NodeReady\")].status",description="Ready status of the node"
+// +kubebuilder:printcolumn:name="Kubelet",type="string",JSONPath=".status.kubeletVersion",description="Kubelet version of the node"
 // +kubebuilder:printcolumn:name="InstanceID",type="string",JSONPath=".spec.providerID",description="Hcloud instance ID"
 // +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"Machine\")].name",description="Machine object which owns with this BareMetalMachine"
 
@@ -125,6 +249,14 @@ type BareMetalMachine struct {
 	Status BareMetalMachineStatus `json:"status,omitempty"`
 }
 
+func (h *BareMetalMachine) GetConditions() clusterv1.Conditions {
+	return h.Status.Conditions
+}
+
+func (h *BareMetalMachine) SetConditions(conditions clusterv1.Conditions) {
+	h.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // BareMetalMachineList contains a list of BareMetalMachine
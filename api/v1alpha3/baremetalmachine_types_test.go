@@ -0,0 +1,43 @@
+package v1alpha3
+
+import (
+	"testing"
+)
+
+func TestBareMetalMachine_NodeTopology(t *testing.T) {
+	tests := []struct {
+		name       string
+		datacenter string
+		wantRegion string
+		wantZone   string
+	}{
+		{
+			name:       "datacenter of a location",
+			datacenter: "FSN1-DC14",
+			wantRegion: "fsn1",
+			wantZone:   "fsn1-dc14",
+		},
+		{
+			name:       "datacenter without number",
+			datacenter: "HEL1",
+			wantRegion: "hel1",
+			wantZone:   "hel1",
+		},
+		{
+			name: "unknown datacenter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &BareMetalMachine{
+				Status: BareMetalMachineStatus{
+					Datacenter: tt.datacenter,
+				},
+			}
+			region, zone := machine.NodeTopology()
+			if region != tt.wantRegion || zone != tt.wantZone {
+				t.Errorf("NodeTopology() = %s, %s, want %s, %s", region, zone, tt.wantRegion, tt.wantZone)
+			}
+		})
+	}
+}
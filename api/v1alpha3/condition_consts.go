@@ -0,0 +1,97 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+const (
+	// CNIAddonReadyCondition reports on the rollout of the CNI addon
+	CNIAddonReadyCondition clusterv1.ConditionType = "CNIAddonReady"
+
+	// CCMAddonReadyCondition reports on the rollout of the CCM addon
+	CCMAddonReadyCondition clusterv1.ConditionType = "CCMAddonReady"
+
+	// CSIAddonReadyCondition reports on the rollout of the CSI addon
+	CSIAddonReadyCondition clusterv1.ConditionType = "CSIAddonReady"
+
+	// RobotAddonReadyCondition reports on the rollout of the robot addon
+	RobotAddonReadyCondition clusterv1.ConditionType = "RobotAddonReady"
+
+	// AddonNotAppliedReason (Severity=Info) documents an addon which has not
+	// been applied successfully to the workload cluster yet
+	AddonNotAppliedReason = "NotApplied"
+
+	// AddonRolloutInProgressReason (Severity=Info) documents an addon waiting
+	// for its Deployments and DaemonSets to be rolled out
+	AddonRolloutInProgressReason = "RolloutInProgress"
+
+	// AddonDegradedReason (Severity=Warning) documents an addon which has
+	// been ready before, but whose Deployments or DaemonSets are no longer
+	// available
+	AddonDegradedReason = "Degraded"
+)
+
+const (
+	// RemoteClusterConnectedCondition reports on the connection of the
+	// controller to the API server of the workload cluster
+	RemoteClusterConnectedCondition clusterv1.ConditionType = "RemoteClusterConnected"
+
+	// RemoteClusterConnectingReason (Severity=Info) documents a connection
+	// to the workload cluster, which has not been checked yet
+	RemoteClusterConnectingReason = "Connecting"
+
+	// RemoteClusterUnreachableReason (Severity=Warning) documents a failed
+	// health check of the workload cluster API server
+	RemoteClusterUnreachableReason = "Unreachable"
+
+	// RemoteClusterManagerFailedReason (Severity=Warning) documents a failure
+	// to set up the controllers of the workload cluster
+	RemoteClusterManagerFailedReason = "ManagerFailed"
+)
+
+const (
+	// NodeRegisteredCondition reports on the registration of the node of a
+	// machine in the workload cluster
+	NodeRegisteredCondition clusterv1.ConditionType = "NodeRegistered"
+
+	// NodeReadyCondition mirrors the Ready condition of the node of a
+	// machine
+	NodeReadyCondition clusterv1.ConditionType = "NodeReady"
+
+	// NodeNotFoundReason (Severity=Warning) documents a node, which has been
+	// removed from the workload cluster
+	NodeNotFoundReason = "NodeNotFound"
+
+	// NodeNotReadyReason (Severity=Warning) documents a node, which is not
+	// ready or whose kubelet stopped posting its status
+	NodeNotReadyReason = "NodeNotReady"
+)
+
+// ReadyCondition returns the condition reporting the readiness of the addon
+// type
+func (t HcloudClusterAddonType) ReadyCondition() clusterv1.ConditionType {
+	switch t {
+	case HcloudClusterAddonTypeCNI:
+		return CNIAddonReadyCondition
+	case HcloudClusterAddonTypeCCM:
+		return CCMAddonReadyCondition
+	case HcloudClusterAddonTypeCSI:
+		return CSIAddonReadyCondition
+	case HcloudClusterAddonTypeRobot:
+		return RobotAddonReadyCondition
+	}
+	return clusterv1.ConditionType(string(t) + "AddonReady")
+}
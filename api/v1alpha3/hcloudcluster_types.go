@@ -66,6 +66,171 @@ type HcloudClusterSpec struct {
 	// If no token is provided then it is assumed that the bare metal controller is unused
 	// +optional
 	HrobotTokenRef *hrobotTokenRef `json:"hrobotTokenRef"`
+
+	// WorkloadCredentials references the credentials passed to the manifests
+	// and addons, e.g. for the CCM and CSI driver. Defaults to the
+	// credentials of the controller.
+	// +optional
+	WorkloadCredentials *HcloudClusterWorkloadCredentials `json:"workloadCredentials,omitempty"`
+
+	// Manifests customizes the manifests applied to the workload cluster
+	// +optional
+	Manifests *HcloudClusterManifestsSpec `json:"manifests,omitempty"`
+
+	// Addons selects bundles from the addon catalog shipped with the
+	// controller. Every addon is applied independently of the manifests.
+	// +optional
+	Addons *HcloudClusterAddonsSpec `json:"addons,omitempty"`
+
+	// ForceDeletionTimeout is the time the deletion of the cluster waits for
+	// its machines to be deleted. Afterwards the remaining servers of the
+	// cluster are deleted directly, before the load balancer and network are
+	// deleted. Without a timeout the deletion waits for the machines forever.
+	// +optional
+	ForceDeletionTimeout *metav1.Duration `json:"forceDeletionTimeout,omitempty"`
+}
+
+type HcloudClusterAddonType string
+
+const (
+	HcloudClusterAddonTypeCNI   = HcloudClusterAddonType("cni")
+	HcloudClusterAddonTypeCCM   = HcloudClusterAddonType("ccm")
+	HcloudClusterAddonTypeCSI   = HcloudClusterAddonType("csi")
+	HcloudClusterAddonTypeRobot = HcloudClusterAddonType("robot")
+)
+
+// HcloudClusterAddonTypes lists all addon types in the order they are applied
+var HcloudClusterAddonTypes = []HcloudClusterAddonType{
+	HcloudClusterAddonTypeCNI,
+	HcloudClusterAddonTypeCCM,
+	HcloudClusterAddonTypeCSI,
+	HcloudClusterAddonTypeRobot,
+}
+
+// HcloudClusterAddonsSpec selects a bundle of the addon catalog per addon type
+type HcloudClusterAddonsSpec struct {
+	// CNI selects the container network interface, e.g. cilium or calico
+	// +optional
+	CNI *HcloudClusterAddon `json:"cni,omitempty"`
+
+	// CCM selects the hcloud cloud controller manager
+	// +optional
+	CCM *HcloudClusterAddon `json:"ccm,omitempty"`
+
+	// CSI selects the hcloud CSI driver
+	// +optional
+	CSI *HcloudClusterAddon `json:"csi,omitempty"`
+
+	// Robot selects the support for bare metal servers managed through the
+	// Hetzner robot
+	// +optional
+	Robot *HcloudClusterAddon `json:"robot,omitempty"`
+}
+
+// Get returns the addon selected for a type or nil
+func (s *HcloudClusterAddonsSpec) Get(t HcloudClusterAddonType) *HcloudClusterAddon {
+	if s == nil {
+		return nil
+	}
+	switch t {
+	case HcloudClusterAddonTypeCNI:
+		return s.CNI
+	case HcloudClusterAddonTypeCCM:
+		return s.CCM
+	case HcloudClusterAddonTypeCSI:
+		return s.CSI
+	case HcloudClusterAddonTypeRobot:
+		return s.Robot
+	}
+	return nil
+}
+
+// HcloudClusterAddon selects a bundle of the addon catalog
+type HcloudClusterAddon struct {
+	// Name of the bundle in the addon catalog
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	Name string `json:"name"`
+
+	// Version of the bundle, defaults to the latest version in the catalog
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// HcloudClusterAddonStatus describes an addon applied to the workload cluster
+type HcloudClusterAddonStatus struct {
+	Type HcloudClusterAddonType `json:"type"`
+	Name string                 `json:"name"`
+	// +optional
+	Version string `json:"version,omitempty"`
+	// +optional
+	AppliedHash *string `json:"appliedHash,omitempty"`
+	// +optional
+	AppliedObjects []HcloudClusterManifestObject `json:"appliedObjects,omitempty"`
+}
+
+// HcloudClusterManifestsMode selects how the manifests and addons are
+// delivered to the workload cluster
+type HcloudClusterManifestsMode string
+
+const (
+	// HcloudClusterManifestsModeApply server-side applies the manifests with
+	// the kubeconfig of the workload cluster
+	HcloudClusterManifestsModeApply = HcloudClusterManifestsMode("Apply")
+
+	// HcloudClusterManifestsModeClusterResourceSet writes the manifests into
+	// ConfigMaps and Secrets of a ClusterResourceSet, which are applied by
+	// Cluster API
+	HcloudClusterManifestsModeClusterResourceSet = HcloudClusterManifestsMode("ClusterResourceSet")
+)
+
+// HcloudClusterManifestsSpec customizes the evaluation of the manifests
+// config for a single cluster
+type HcloudClusterManifestsSpec struct {
+	// Mode selects how the manifests and addons are delivered to the
+	// workload cluster, defaults to Apply
+	// +kubebuilder:validation:Enum=Apply;ClusterResourceSet
+	// +optional
+	Mode HcloudClusterManifestsMode `json:"mode,omitempty"`
+
+	// Snippet is a jsonnet snippet merged into the object rendered by the
+	// manifests config, e.g. `{ secrets+: { hcloudSecret+: { ... } } }`. The
+	// same ext-vars are available to the snippet.
+	// +optional
+	Snippet *string `json:"snippet,omitempty"`
+
+	// SnippetConfigMapRef selects a key of a ConfigMap in the namespace of the
+	// HcloudCluster, which holds the jsonnet snippet. Mutually exclusive with
+	// Snippet.
+	// +optional
+	SnippetConfigMapRef *corev1.ConfigMapKeySelector `json:"snippetConfigMapRef,omitempty"`
+
+	// ExtVarsConfigMapRef references a ConfigMap in the namespace of the
+	// HcloudCluster. All its keys are passed as additional ext-vars, built-in
+	// ext-vars can not be overridden.
+	// +optional
+	ExtVarsConfigMapRef *corev1.LocalObjectReference `json:"extVarsConfigMapRef,omitempty"`
+}
+
+// GetMode returns the manifests mode, Apply if not set
+func (s *HcloudClusterManifestsSpec) GetMode() HcloudClusterManifestsMode {
+	if s == nil || s.Mode == "" {
+		return HcloudClusterManifestsModeApply
+	}
+	return s.Mode
+}
+
+// HcloudClusterWorkloadCredentials references secrets in the namespace of the
+// HcloudCluster, which hold least-privilege credentials for the workload
+// cluster
+type HcloudClusterWorkloadCredentials struct {
+	// HcloudTokenRef selects the Hcloud token used by the CCM and CSI driver
+	// +optional
+	HcloudTokenRef *corev1.SecretKeySelector `json:"hcloudTokenRef,omitempty"`
+
+	// HrobotTokenRef selects the Robot credentials used by the CCM
+	// +optional
+	HrobotTokenRef *hrobotTokenRef `json:"hrobotTokenRef,omitempty"`
 }
 
 type hrobotTokenRef struct {
@@ -133,9 +298,57 @@ type HcloudLoadBalancerStatus struct {
 	Targets    []int                           `json:"-"`
 }
 
+// HcloudClusterManifestObject references an object applied from the manifests
+type HcloudClusterManifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
 type HcloudClusterStatusManifests struct {
 	Initialized *bool   `json:"initialized,omitempty"`
 	AppliedHash *string `json:"appliedHash,omitempty"`
+
+	// AppliedObjects lists the objects applied to the workload cluster. Objects
+	// no longer rendered by the manifests are pruned.
+	// +optional
+	AppliedObjects []HcloudClusterManifestObject `json:"appliedObjects,omitempty"`
+
+	// Addons lists the addons applied to the workload cluster
+	// +optional
+	Addons []HcloudClusterAddonStatus `json:"addons,omitempty"`
+}
+
+// Addon returns the status of the addon of a type or nil
+func (s *HcloudClusterStatusManifests) Addon(t HcloudClusterAddonType) *HcloudClusterAddonStatus {
+	for pos := range s.Addons {
+		if s.Addons[pos].Type == t {
+			return &s.Addons[pos]
+		}
+	}
+	return nil
+}
+
+// SetAddon adds or replaces the status of an addon
+func (s *HcloudClusterStatusManifests) SetAddon(addon HcloudClusterAddonStatus) {
+	if current := s.Addon(addon.Type); current != nil {
+		*current = addon
+		return
+	}
+	s.Addons = append(s.Addons, addon)
+}
+
+// RemoveAddon removes the status of the addon of a type
+func (s *HcloudClusterStatusManifests) RemoveAddon(t HcloudClusterAddonType) {
+	addons := s.Addons[:0]
+	for _, a := range s.Addons {
+		if a.Type != t {
+			addons = append(addons, a)
+		}
+	}
+	s.Addons = addons
 }
 
 // HcloudClusterStatus defines the observed state of HcloudCluster
@@ -199,6 +412,10 @@ type HcloudClusterStatus struct {
 	// controller's output.
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the HcloudCluster.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -218,6 +435,14 @@ type HcloudCluster struct {
 	Status HcloudClusterStatus `json:"status,omitempty"`
 }
 
+func (r *HcloudCluster) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+func (r *HcloudCluster) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // HcloudClusterList contains a list of HcloudCluster
@@ -28,7 +28,7 @@ func (r *HcloudClusterList) SetupWebhookWithManager(mgr ctrl.Manager) error {
 var _ webhook.Validator = &HcloudCluster{}
 
 func (r *HcloudCluster) ValidateCreate() error {
-	return nil
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, r.validateSpec())
 }
 
 func (r *HcloudCluster) ValidateDelete() error {
@@ -58,5 +58,25 @@ func (r *HcloudCluster) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	allErrs = append(allErrs, r.validateSpec()...)
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
+
+func (r *HcloudCluster) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if m := r.Spec.Manifests; m != nil && m.Snippet != nil && m.SnippetConfigMapRef != nil {
+		allErrs = append(allErrs,
+			field.Forbidden(field.NewPath("spec", "manifests", "snippetConfigMapRef"), "snippet and snippetConfigMapRef are mutually exclusive"),
+		)
+	}
+
+	if t := r.Spec.ForceDeletionTimeout; t != nil && t.Duration < 0 {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "forceDeletionTimeout"), t.Duration.String(), "timeout cannot be negative"),
+		)
+	}
+
+	return allErrs
+}
@@ -15,6 +15,10 @@ package v1alpha3
 
 import (
 	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestHcloudCluster_ValidateUpdate(t *testing.T) {
@@ -60,3 +64,58 @@ func TestHcloudCluster_ValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestHcloudCluster_ValidateCreate(t *testing.T) {
+	snippet := "{}"
+	tests := []struct {
+		name    string
+		cluster *HcloudCluster
+		wantErr bool
+	}{
+		{
+			name:    "no manifests",
+			cluster: &HcloudCluster{},
+		},
+		{
+			name: "inline snippet",
+			cluster: &HcloudCluster{
+				Spec: HcloudClusterSpec{
+					Manifests: &HcloudClusterManifestsSpec{
+						Snippet: &snippet,
+					},
+				},
+			},
+		},
+		{
+			name: "snippet and snippet configmap are mutually exclusive",
+			cluster: &HcloudCluster{
+				Spec: HcloudClusterSpec{
+					Manifests: &HcloudClusterManifestsSpec{
+						Snippet: &snippet,
+						SnippetConfigMapRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "overrides"},
+							Key:                  "snippet.jsonnet",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "force deletion timeout cannot be negative",
+			cluster: &HcloudCluster{
+				Spec: HcloudClusterSpec{
+					ForceDeletionTimeout: &metav1.Duration{Duration: -time.Minute},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cluster.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package v1alpha3
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
@@ -39,11 +40,31 @@ type HcloudMachineSpec struct {
 	// +optional
 	Volumes []HcloudMachineVolume `json:"volumes"`
 
+	// ImageName is the name of the image to create the server from, it is
+	// not used for adopted servers.
 	ImageName string `json:"image"`
 
 	// ProviderID is the unique identifier as specified by the cloud provider.
 	// +optional
 	ProviderID *string `json:"providerID"`
+
+	// Adopt references an existing server, which is managed by the machine
+	// instead of creating a new server.
+	// +optional
+	Adopt *HcloudMachineAdoption `json:"adopt,omitempty"`
+}
+
+// HcloudMachineAdoption references an existing server to adopt
+type HcloudMachineAdoption struct {
+	// ServerID is the ID of the server to adopt. Its location and type have
+	// to match the machine.
+	ServerID int `json:"serverID"`
+
+	// TransferOwnership allows the server to be deleted together with the
+	// machine. Otherwise the server is only released, its labels are removed
+	// and it is detached from the load balancer.
+	// +optional
+	TransferOwnership bool `json:"transferOwnership,omitempty"`
 }
 
 type HcloudMachineTypeSpec string
@@ -70,6 +91,16 @@ type HcloudMachineStatus struct {
 	NetworkZone HcloudNetworkZone `json:"networkZone,omitempty"`
 	ImageID     *HcloudImageID    `json:"imageID,omitempty"`
 
+	// ServerID is the ID of the server of this machine, by which the server
+	// is looked up. It is kept, when the server is deleted outside of the
+	// provider, to detect its deletion.
+	// +optional
+	ServerID *int `json:"serverID,omitempty"`
+
+	// Datacenter is the datacenter within the location the server runs in.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
 	// ServerState is the state of the server for this machine.
 	// +optional
 	ServerState HcloudServerState `json:"serverState,omitempty"`
@@ -86,6 +117,11 @@ type HcloudMachineStatus struct {
 	// Addresses contains the server's associated addresses.
 	Addresses []v1.NodeAddress `json:"addresses,omitempty"`
 
+	// KubeletVersion is the kubelet version reported by the node of the
+	// machine.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
 	// FailureReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -123,18 +159,66 @@ type HcloudMachineStatus struct {
 	// controller's output.
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the HcloudMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 func (h *HcloudMachine) HcloudMachineSpec() *HcloudMachineSpec {
 	return h.Spec.DeepCopy()
 }
 
+// NodeName returns the name of the node, which is the name of the server
+func (h *HcloudMachine) NodeName() string {
+	return h.Name
+}
+
+// NodeIPAddresses returns the internal and external IP addresses of the server
+func (h *HcloudMachine) NodeIPAddresses() []string {
+	var addresses []string
+	for _, address := range h.Status.Addresses {
+		switch address.Type {
+		case v1.NodeInternalIP, v1.NodeExternalIP:
+			addresses = append(addresses, address.Address)
+		}
+	}
+	return addresses
+}
+
+// NodeProviderID returns the provider ID of the server, which is empty until
+// the server is running
+func (h *HcloudMachine) NodeProviderID() string {
+	if h.Spec.ProviderID == nil {
+		return ""
+	}
+	return *h.Spec.ProviderID
+}
+
+// NodeTopology returns the region and zone of the node, which are the
+// location and datacenter of the server
+func (h *HcloudMachine) NodeTopology() (region, zone string) {
+	return string(h.Status.Location), h.Status.Datacenter
+}
+
+// NodeInstanceType returns the server type
+func (h *HcloudMachine) NodeInstanceType() string {
+	return string(h.Spec.Type)
+}
+
+// NodeAddresses returns the addresses of the server
+func (h *HcloudMachine) NodeAddresses() []v1.NodeAddress {
+	return h.Status.Addresses
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=hcloudmachines,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.serverState",description="Server state"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Machine ready status"
+// +kubebuilder:printcolumn:name="NodeReady",type="string",JSONPath=".status.conditions[?(@.type==\"NodeReady\")].status",description="Ready status of the node"
+// +kubebuilder:printcolumn:name="Kubelet",type="string",JSONPath=".status.kubeletVersion",description="Kubelet version of the node"
 // +kubebuilder:printcolumn:name="InstanceID",type="string",JSONPath=".spec.providerID",description="Hcloud instance ID"
 // +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"Machine\")].name",description="Machine object which owns with this HcloudMachine"
 
@@ -147,6 +231,14 @@ type HcloudMachine struct {
 	Status HcloudMachineStatus `json:"status,omitempty"`
 }
 
+func (h *HcloudMachine) GetConditions() clusterv1.Conditions {
+	return h.Status.Conditions
+}
+
+func (h *HcloudMachine) SetConditions(conditions clusterv1.Conditions) {
+	h.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // HcloudMachineList contains a list of HcloudMachine
@@ -46,6 +46,12 @@ func validateHcloudMachineSpec(r hcloudMachineSpecer) error {
 		)
 	}
 
+	if adopt := r.HcloudMachineSpec().Adopt; adopt != nil && adopt.ServerID <= 0 {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "adopt", "serverID"), adopt.ServerID, "field has to be a positive server ID"),
+		)
+	}
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.GetName(), allErrs)
 }
 
@@ -64,6 +70,14 @@ func (r *HcloudMachine) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	// the ownership of an adopted server can be transferred later on
+	if (r.Spec.Adopt == nil) != (oldM.Spec.Adopt == nil) ||
+		(r.Spec.Adopt != nil && r.Spec.Adopt.ServerID != oldM.Spec.Adopt.ServerID) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "adopt", "serverID"), r.Spec.Adopt, "field is immutable"),
+		)
+	}
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
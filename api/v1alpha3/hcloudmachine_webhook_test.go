@@ -25,6 +25,25 @@ func TestHcloudMachine_ValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "adopted server needs an ID",
+			machine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "adopted server with an ID is valid",
+			machine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{ServerID: 42},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -69,6 +88,37 @@ func TestHcloudMachine_ValidateUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "adopted server is immutable",
+			oldMachine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{ServerID: 42},
+				},
+			},
+			newMachine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{ServerID: 43},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ownership of adopted server can be transferred",
+			oldMachine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{ServerID: 42},
+				},
+			},
+			newMachine: &HcloudMachine{
+				Spec: HcloudMachineSpec{
+					Type:  "x",
+					Adopt: &HcloudMachineAdoption{ServerID: 42, TransferOwnership: true},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
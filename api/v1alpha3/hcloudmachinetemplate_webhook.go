@@ -0,0 +1,52 @@
+package v1alpha3
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *HcloudMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+func (r *HcloudMachineTemplateList) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-api-provider-hcloud-capihc-com-v1alpha3-hcloudmachinetemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudmachinetemplates,versions=v1alpha3,name=validation.hcloudmachinetemplate.cluster-api-provider-hcloud.capihc.com
+
+var _ webhook.Validator = &HcloudMachineTemplate{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *HcloudMachineTemplate) ValidateCreate() error {
+	return r.validateTemplateSpec()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *HcloudMachineTemplate) ValidateUpdate(old runtime.Object) error {
+	return r.validateTemplateSpec()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *HcloudMachineTemplate) ValidateDelete() error {
+	return nil
+}
+
+// validateTemplateSpec rejects the adoption of a server, as every machine
+// created from the template would adopt the same server
+func (r *HcloudMachineTemplate) validateTemplateSpec() error {
+	var allErrs field.ErrorList
+
+	if r.Spec.Template.Spec.Adopt != nil {
+		allErrs = append(allErrs,
+			field.Forbidden(field.NewPath("spec", "template", "spec", "adopt"), "servers cannot be adopted by a template"),
+		)
+	}
+
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
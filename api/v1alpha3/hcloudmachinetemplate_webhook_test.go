@@ -0,0 +1,50 @@
+package v1alpha3
+
+import (
+	"testing"
+)
+
+func TestHcloudMachineTemplate_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template *HcloudMachineTemplate
+		wantErr  bool
+	}{
+		{
+			name: "template without adoption is valid",
+			template: &HcloudMachineTemplate{
+				Spec: HcloudMachineTemplateSpec{
+					Template: HcloudMachineTemplateResource{
+						Spec: HcloudMachineSpec{
+							Type: "x",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "template cannot adopt a server",
+			template: &HcloudMachineTemplate{
+				Spec: HcloudMachineTemplateSpec{
+					Template: HcloudMachineTemplateResource{
+						Spec: HcloudMachineSpec{
+							Type:  "x",
+							Adopt: &HcloudMachineAdoption{ServerID: 42},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.template.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := tt.template.ValidateUpdate(tt.template.DeepCopy()); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
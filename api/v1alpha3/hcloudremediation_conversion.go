@@ -0,0 +1,7 @@
+package v1alpha3
+
+// Hub marks HcloudRemediation as a conversion hub.
+func (*HcloudRemediation) Hub() {}
+
+// Hub marks HcloudRemediationList as a conversion hub.
+func (*HcloudRemediationList) Hub() {}
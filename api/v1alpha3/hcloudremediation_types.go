@@ -0,0 +1,147 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultRemediationRetryLimit is the number of reboots before the
+	// machine is deleted, a soft reboot followed by a reset
+	DefaultRemediationRetryLimit = 2
+
+	// DefaultRemediationTimeout is the time to wait for the node to become
+	// healthy after a reboot
+	DefaultRemediationTimeout = 5 * time.Minute
+)
+
+// RemediationPhase is the phase of a remediation
+type RemediationPhase string
+
+const (
+	// RemediationPhaseRunning is the phase of waiting for the node after a
+	// reboot
+	RemediationPhaseRunning RemediationPhase = "Running"
+
+	// RemediationPhaseDeleting is the phase after the retry limit has been
+	// exceeded and the machine has been handed back to its owner for deletion
+	RemediationPhaseDeleting RemediationPhase = "Deleting"
+)
+
+// HcloudRemediationSpec defines the desired state of HcloudRemediation
+type HcloudRemediationSpec struct {
+	// Strategy configures the reboots of the server
+	// +optional
+	Strategy *RemediationStrategy `json:"strategy,omitempty"`
+}
+
+// RemediationStrategy configures how often a server is rebooted, before its
+// machine is deleted. The first reboot is a soft reboot, every further
+// reboot resets the server.
+type RemediationStrategy struct {
+	// RetryLimit is the number of reboots, before the machine is deleted.
+	// Defaults to 2.
+	// +optional
+	RetryLimit *int `json:"retryLimit,omitempty"`
+
+	// Timeout is the time to wait for the node to become healthy after a
+	// reboot. Defaults to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Reinstall fails a BareMetalMachine, which is still unhealthy after the
+	// retry limit has been exceeded, so its server is released and
+	// reinstalled with fresh bootstrap data once it is provisioned for a
+	// replacement machine. It is ignored for HcloudMachines.
+	// +optional
+	Reinstall bool `json:"reinstall,omitempty"`
+}
+
+// HcloudRemediationStatus defines the observed state of HcloudRemediation
+type HcloudRemediationStatus struct {
+	// Phase is the phase of the remediation
+	// +optional
+	Phase RemediationPhase `json:"phase,omitempty"`
+
+	// RetryCount is the number of reboots issued
+	// +optional
+	RetryCount int `json:"retryCount,omitempty"`
+
+	// LastRemediated is the time of the last reboot
+	// +optional
+	LastRemediated *metav1.Time `json:"lastRemediated,omitempty"`
+
+	// Reinstalled is true once the BareMetalMachine has been failed for the
+	// reinstallation of its server
+	// +optional
+	Reinstalled bool `json:"reinstalled,omitempty"`
+}
+
+// GetRetryLimit returns the retry limit of the strategy or its default
+func (s *HcloudRemediationSpec) GetRetryLimit() int {
+	if s.Strategy == nil || s.Strategy.RetryLimit == nil {
+		return DefaultRemediationRetryLimit
+	}
+	return *s.Strategy.RetryLimit
+}
+
+// GetTimeout returns the timeout of the strategy or its default
+func (s *HcloudRemediationSpec) GetTimeout() metav1.Duration {
+	if s.Strategy == nil || s.Strategy.Timeout == nil {
+		return metav1.Duration{Duration: DefaultRemediationTimeout}
+	}
+	return *s.Strategy.Timeout
+}
+
+// GetReinstall returns whether the server is reinstalled after the retry
+// limit has been exceeded
+func (s *HcloudRemediationSpec) GetReinstall() bool {
+	return s.Strategy != nil && s.Strategy.Reinstall
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hcloudremediations,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Phase of the remediation"
+// +kubebuilder:printcolumn:name="Retries",type="integer",JSONPath=".status.retryCount",description="Number of reboots issued"
+// +kubebuilder:printcolumn:name="Last Remediated",type="date",JSONPath=".status.lastRemediated",description="Time of the last reboot"
+
+// HcloudRemediation is the Schema for the hcloudremediations API, it is
+// created by a MachineHealthCheck for an unhealthy machine
+type HcloudRemediation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HcloudRemediationSpec   `json:"spec,omitempty"`
+	Status HcloudRemediationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HcloudRemediationList contains a list of HcloudRemediation
+type HcloudRemediationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HcloudRemediation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HcloudRemediation{}, &HcloudRemediationList{})
+}
@@ -0,0 +1,7 @@
+package v1alpha3
+
+// Hub marks HcloudRemediationTemplate as a conversion hub.
+func (*HcloudRemediationTemplate) Hub() {}
+
+// Hub marks HcloudRemediationTemplateList as a conversion hub.
+func (*HcloudRemediationTemplateList) Hub() {}
@@ -0,0 +1,56 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HcloudRemediationTemplateSpec defines the desired state of HcloudRemediationTemplate
+type HcloudRemediationTemplateSpec struct {
+	Template HcloudRemediationTemplateResource `json:"template"`
+}
+
+// HcloudRemediationTemplateResource describes the data needed to create a HcloudRemediation from a template
+type HcloudRemediationTemplateResource struct {
+	// Spec is the specification of the desired behavior of the remediation.
+	Spec HcloudRemediationSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hcloudremediationtemplates,scope=Namespaced,categories=cluster-api
+
+// HcloudRemediationTemplate is the Schema for the hcloudremediationtemplates
+// API, it is referenced by the remediationTemplate of a MachineHealthCheck
+type HcloudRemediationTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HcloudRemediationTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HcloudRemediationTemplateList contains a list of HcloudRemediationTemplate
+type HcloudRemediationTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HcloudRemediationTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HcloudRemediationTemplate{}, &HcloudRemediationTemplateList{})
+}
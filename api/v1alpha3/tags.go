@@ -50,11 +50,36 @@ const (
 	// uses NameKubernetesClusterPrefix
 	NameHcloudProviderOwned = "cluster." + NameHcloudProviderPrefix
 
+	// ClusterNamespaceTagKey labels resources with the namespace of the
+	// HcloudCluster owning them
+	ClusterNamespaceTagKey = "cluster-identity." + NameHcloudProviderPrefix + "namespace"
+
+	// ClusterUIDTagKey labels resources with the UID of the HcloudCluster
+	// owning them. The HcloudCluster keeps this UID in an annotation of the
+	// same key, so it survives moving the cluster.
+	ClusterUIDTagKey = "cluster-identity." + NameHcloudProviderPrefix + "uid"
+
 	// MachineNameTag tags related MachineNameTag
 	MachineNameTagKey = "machine." + NameHcloudProviderPrefix + "name"
 
 	// MachineTempalteHashTag tags server resources
 	MachineTemplateHashTagKey = "machine." + NameHcloudProviderPrefix + "template"
+
+	// VolumeNameTagKey labels volumes with the name of their HcloudVolume
+	VolumeNameTagKey = "volume." + NameHcloudProviderPrefix + "name"
+
+	// ManifestsOwnedLabelKey labels objects in the workload cluster which have
+	// been applied from the manifests
+	ManifestsOwnedLabelKey = "manifests." + NameHcloudProviderPrefix + "owned"
+
+	// ManifestsPruneAnnotationKey set to "false" keeps an object in the
+	// workload cluster after it has been removed from the manifests
+	ManifestsPruneAnnotationKey = "manifests." + NameHcloudProviderPrefix + "prune"
+
+	// ManifestsClusterResourceSetLabelKey labels the Cluster selected by the
+	// ClusterResourceSet of the manifests and the ConfigMaps and Secrets
+	// holding the manifests with the name of the HcloudCluster
+	ManifestsClusterResourceSetLabelKey = "manifests." + NameHcloudProviderPrefix + "cluster-resource-set"
 )
 
 // ClusterTagKey generates the key for resources associated with a cluster.
@@ -66,3 +91,47 @@ func ClusterTagKey(name string) string {
 func ClusterHcloudCloudProviderTagKey(name string) string {
 	return fmt.Sprintf("%s%s", NameKubernetesHcloudCloudProviderPrefix, name)
 }
+
+// ResourceUID returns the UID the resources of the cluster are labelled with.
+// It is taken from the annotation, if set, as the UID of the HcloudCluster
+// changes when it is moved to another management cluster.
+func (r *HcloudCluster) ResourceUID() string {
+	if uid := r.Annotations[ClusterUIDTagKey]; uid != "" {
+		return uid
+	}
+	return string(r.UID)
+}
+
+// ResourceLabels returns the labels of the resources owned by the cluster.
+// Besides the name, they contain the namespace and the UID of the cluster, so
+// clusters of the same name never share their resources.
+func (r *HcloudCluster) ResourceLabels() map[string]string {
+	return map[string]string{
+		ClusterTagKey(r.Name):  string(ResourceLifecycleOwned),
+		ClusterNamespaceTagKey: r.Namespace,
+		ClusterUIDTagKey:       r.ResourceUID(),
+	}
+}
+
+// LegacyResourceLabelSelector selects the resources, which are only labelled
+// with the name of the cluster, as they have been created before the labels
+// contained its namespace and UID. These labels do not tell clusters of the
+// same name in different namespaces apart.
+func (r *HcloudCluster) LegacyResourceLabelSelector() string {
+	return fmt.Sprintf("%s==%s,!%s", ClusterTagKey(r.Name), ResourceLifecycleOwned, ClusterUIDTagKey)
+}
+
+// ResourceName returns the name of a resource of the cluster. The name
+// contains a prefix of the UID, so it does not collide with the resources of
+// other clusters of the same name in the project.
+func (r *HcloudCluster) ResourceName(suffix string) string {
+	uid := r.ResourceUID()
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	name := fmt.Sprintf("%s-%s", r.Name, uid)
+	if suffix != "" {
+		name = fmt.Sprintf("%s-%s", name, suffix)
+	}
+	return name
+}
@@ -0,0 +1,64 @@
+package v1alpha3
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHcloudCluster_ResourceName(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		suffix      string
+		want        string
+	}{
+		{
+			name:   "uid of the object",
+			suffix: "kube-apiserver",
+			want:   "cluster-1b4e28ba-kube-apiserver",
+		},
+		{
+			name:        "uid of the annotation",
+			annotations: map[string]string{ClusterUIDTagKey: "6ecd8c99-4036-403d-bf84-cf8400f67836"},
+			want:        "cluster-6ecd8c99",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &HcloudCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster",
+					Namespace:   "default",
+					UID:         "1b4e28ba-2fa1-11d2-883f-0016d3cca427",
+					Annotations: tt.annotations,
+				},
+			}
+			if got := c.ResourceName(tt.suffix); got != tt.want {
+				t.Errorf("ResourceName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHcloudCluster_ResourceLabels(t *testing.T) {
+	a := &HcloudCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "a", UID: "1"}}
+	b := &HcloudCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "b", UID: "2"}}
+
+	labelsA, labelsB := a.ResourceLabels(), b.ResourceLabels()
+	if labelsA[ClusterNamespaceTagKey] == labelsB[ClusterNamespaceTagKey] || labelsA[ClusterUIDTagKey] == labelsB[ClusterUIDTagKey] {
+		t.Errorf("ResourceLabels() of clusters in different namespaces are equal: %v", labelsA)
+	}
+	if labelsA[ClusterTagKey("cluster")] != string(ResourceLifecycleOwned) {
+		t.Errorf("ResourceLabels() = %v, missing the owned label", labelsA)
+	}
+}
+
+func TestHcloudCluster_LegacyResourceLabelSelector(t *testing.T) {
+	c := &HcloudCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default", UID: "1"}}
+
+	want := ClusterTagKey("cluster") + "==owned,!" + ClusterUIDTagKey
+	if got := c.LegacyResourceLabelSelector(); got != want {
+		t.Errorf("LegacyResourceLabelSelector() = %s, want %s", got, want)
+	}
+}
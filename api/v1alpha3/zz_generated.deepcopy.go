@@ -21,6 +21,7 @@ package v1alpha3
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
@@ -124,6 +125,11 @@ func (in *BareMetalMachineSpec) DeepCopy() *BareMetalMachineSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalMachineStatus) DeepCopyInto(out *BareMetalMachineStatus) {
 	*out = *in
+	if in.Remediation != nil {
+		in, out := &in.Remediation, &out.Remediation
+		*out = new(BareMetalRemediationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.FailureReason != nil {
 		in, out := &in.FailureReason, &out.FailureReason
 		*out = new(errors.MachineStatusError)
@@ -134,6 +140,13 @@ func (in *BareMetalMachineStatus) DeepCopyInto(out *BareMetalMachineStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalMachineStatus.
@@ -236,6 +249,25 @@ func (in *BareMetalMachineTemplateSpec) DeepCopy() *BareMetalMachineTemplateSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalRemediationStatus) DeepCopyInto(out *BareMetalRemediationStatus) {
+	*out = *in
+	if in.LastAttempted != nil {
+		in, out := &in.LastAttempted, &out.LastAttempted
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalRemediationStatus.
+func (in *BareMetalRemediationStatus) DeepCopy() *BareMetalRemediationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalRemediationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudCluster) DeepCopyInto(out *HcloudCluster) {
 	*out = *in
@@ -263,6 +295,81 @@ func (in *HcloudCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterAddon) DeepCopyInto(out *HcloudClusterAddon) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterAddon.
+func (in *HcloudClusterAddon) DeepCopy() *HcloudClusterAddon {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterAddon)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterAddonStatus) DeepCopyInto(out *HcloudClusterAddonStatus) {
+	*out = *in
+	if in.AppliedHash != nil {
+		in, out := &in.AppliedHash, &out.AppliedHash
+		*out = new(string)
+		**out = **in
+	}
+	if in.AppliedObjects != nil {
+		in, out := &in.AppliedObjects, &out.AppliedObjects
+		*out = make([]HcloudClusterManifestObject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterAddonStatus.
+func (in *HcloudClusterAddonStatus) DeepCopy() *HcloudClusterAddonStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterAddonStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterAddonsSpec) DeepCopyInto(out *HcloudClusterAddonsSpec) {
+	*out = *in
+	if in.CNI != nil {
+		in, out := &in.CNI, &out.CNI
+		*out = new(HcloudClusterAddon)
+		**out = **in
+	}
+	if in.CCM != nil {
+		in, out := &in.CCM, &out.CCM
+		*out = new(HcloudClusterAddon)
+		**out = **in
+	}
+	if in.CSI != nil {
+		in, out := &in.CSI, &out.CSI
+		*out = new(HcloudClusterAddon)
+		**out = **in
+	}
+	if in.Robot != nil {
+		in, out := &in.Robot, &out.Robot
+		*out = new(HcloudClusterAddon)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterAddonsSpec.
+func (in *HcloudClusterAddonsSpec) DeepCopy() *HcloudClusterAddonsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterAddonsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudClusterList) DeepCopyInto(out *HcloudClusterList) {
 	*out = *in
@@ -295,6 +402,51 @@ func (in *HcloudClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterManifestObject) DeepCopyInto(out *HcloudClusterManifestObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterManifestObject.
+func (in *HcloudClusterManifestObject) DeepCopy() *HcloudClusterManifestObject {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterManifestObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterManifestsSpec) DeepCopyInto(out *HcloudClusterManifestsSpec) {
+	*out = *in
+	if in.Snippet != nil {
+		in, out := &in.Snippet, &out.Snippet
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnippetConfigMapRef != nil {
+		in, out := &in.SnippetConfigMapRef, &out.SnippetConfigMapRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtVarsConfigMapRef != nil {
+		in, out := &in.ExtVarsConfigMapRef, &out.ExtVarsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterManifestsSpec.
+func (in *HcloudClusterManifestsSpec) DeepCopy() *HcloudClusterManifestsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterManifestsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudClusterSpec) DeepCopyInto(out *HcloudClusterSpec) {
 	*out = *in
@@ -331,6 +483,26 @@ func (in *HcloudClusterSpec) DeepCopyInto(out *HcloudClusterSpec) {
 		*out = new(hrobotTokenRef)
 		**out = **in
 	}
+	if in.WorkloadCredentials != nil {
+		in, out := &in.WorkloadCredentials, &out.WorkloadCredentials
+		*out = new(HcloudClusterWorkloadCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = new(HcloudClusterManifestsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Addons != nil {
+		in, out := &in.Addons, &out.Addons
+		*out = new(HcloudClusterAddonsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForceDeletionTimeout != nil {
+		in, out := &in.ForceDeletionTimeout, &out.ForceDeletionTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterSpec.
@@ -379,6 +551,13 @@ func (in *HcloudClusterStatus) DeepCopyInto(out *HcloudClusterStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterStatus.
@@ -404,6 +583,18 @@ func (in *HcloudClusterStatusManifests) DeepCopyInto(out *HcloudClusterStatusMan
 		*out = new(string)
 		**out = **in
 	}
+	if in.AppliedObjects != nil {
+		in, out := &in.AppliedObjects, &out.AppliedObjects
+		*out = make([]HcloudClusterManifestObject, len(*in))
+		copy(*out, *in)
+	}
+	if in.Addons != nil {
+		in, out := &in.Addons, &out.Addons
+		*out = make([]HcloudClusterAddonStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterStatusManifests.
@@ -416,6 +607,31 @@ func (in *HcloudClusterStatusManifests) DeepCopy() *HcloudClusterStatusManifests
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudClusterWorkloadCredentials) DeepCopyInto(out *HcloudClusterWorkloadCredentials) {
+	*out = *in
+	if in.HcloudTokenRef != nil {
+		in, out := &in.HcloudTokenRef, &out.HcloudTokenRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HrobotTokenRef != nil {
+		in, out := &in.HrobotTokenRef, &out.HrobotTokenRef
+		*out = new(hrobotTokenRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudClusterWorkloadCredentials.
+func (in *HcloudClusterWorkloadCredentials) DeepCopy() *HcloudClusterWorkloadCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudClusterWorkloadCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudLoadBalancerSpec) DeepCopyInto(out *HcloudLoadBalancerSpec) {
 	*out = *in
@@ -495,6 +711,21 @@ func (in *HcloudMachine) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudMachineAdoption) DeepCopyInto(out *HcloudMachineAdoption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudMachineAdoption.
+func (in *HcloudMachineAdoption) DeepCopy() *HcloudMachineAdoption {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudMachineAdoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudMachineList) DeepCopyInto(out *HcloudMachineList) {
 	*out = *in
@@ -547,6 +778,11 @@ func (in *HcloudMachineSpec) DeepCopyInto(out *HcloudMachineSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Adopt != nil {
+		in, out := &in.Adopt, &out.Adopt
+		*out = new(HcloudMachineAdoption)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudMachineSpec.
@@ -567,6 +803,11 @@ func (in *HcloudMachineStatus) DeepCopyInto(out *HcloudMachineStatus) {
 		*out = new(HcloudImageID)
 		**out = **in
 	}
+	if in.ServerID != nil {
+		in, out := &in.ServerID, &out.ServerID
+		*out = new(int)
+		**out = **in
+	}
 	if in.Addresses != nil {
 		in, out := &in.Addresses, &out.Addresses
 		*out = make([]v1.NodeAddress, len(*in))
@@ -582,6 +823,13 @@ func (in *HcloudMachineStatus) DeepCopyInto(out *HcloudMachineStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1alpha3.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudMachineStatus.
@@ -774,6 +1022,194 @@ func (in *HcloudNetworkSubnetSpec) DeepCopy() *HcloudNetworkSubnetSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediation) DeepCopyInto(out *HcloudRemediation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediation.
+func (in *HcloudRemediation) DeepCopy() *HcloudRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HcloudRemediation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationList) DeepCopyInto(out *HcloudRemediationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HcloudRemediation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationList.
+func (in *HcloudRemediationList) DeepCopy() *HcloudRemediationList {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HcloudRemediationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationSpec) DeepCopyInto(out *HcloudRemediationSpec) {
+	*out = *in
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(RemediationStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationSpec.
+func (in *HcloudRemediationSpec) DeepCopy() *HcloudRemediationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationStatus) DeepCopyInto(out *HcloudRemediationStatus) {
+	*out = *in
+	if in.LastRemediated != nil {
+		in, out := &in.LastRemediated, &out.LastRemediated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationStatus.
+func (in *HcloudRemediationStatus) DeepCopy() *HcloudRemediationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationTemplate) DeepCopyInto(out *HcloudRemediationTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationTemplate.
+func (in *HcloudRemediationTemplate) DeepCopy() *HcloudRemediationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HcloudRemediationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationTemplateList) DeepCopyInto(out *HcloudRemediationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HcloudRemediationTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationTemplateList.
+func (in *HcloudRemediationTemplateList) DeepCopy() *HcloudRemediationTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HcloudRemediationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationTemplateResource) DeepCopyInto(out *HcloudRemediationTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationTemplateResource.
+func (in *HcloudRemediationTemplateResource) DeepCopy() *HcloudRemediationTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HcloudRemediationTemplateSpec) DeepCopyInto(out *HcloudRemediationTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HcloudRemediationTemplateSpec.
+func (in *HcloudRemediationTemplateSpec) DeepCopy() *HcloudRemediationTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HcloudRemediationTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HcloudSSHKeySpec) DeepCopyInto(out *HcloudSSHKeySpec) {
 	*out = *in
@@ -917,3 +1353,28 @@ func (in *LoadBalancerServiceSpec) DeepCopy() *LoadBalancerServiceSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
+	*out = *in
+	if in.RetryLimit != nil {
+		in, out := &in.RetryLimit, &out.RetryLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationStrategy.
+func (in *RemediationStrategy) DeepCopy() *RemediationStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -9,6 +10,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -18,6 +20,7 @@ import (
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/controllers"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -31,8 +34,22 @@ var rootFlags = struct {
 	EnableLeaderElection bool
 	Verbose              bool
 	ManifestsConfigPath  string
+	AddonsCatalogPath    string
 	PackerConfigPath     string
 	WebhookPort          int
+
+	RequireSeparateWorkloadCredentials bool
+	CSRDNSSuffixes                     []string
+	CSRReportOnly                      bool
+
+	OrphanGCInterval    time.Duration
+	OrphanGCGracePeriod time.Duration
+	OrphanGCDryRun      bool
+
+	HcloudRateLimit      float64
+	HcloudRateLimitBurst int
+	HcloudMaxRetries     int
+	HcloudPollInterval   time.Duration
 }{}
 
 func init() {
@@ -41,13 +58,25 @@ func init() {
 	_ = infrav1alpha3.AddToScheme(scheme)
 	_ = clusterv1.AddToScheme(scheme)
 	_ = bootstrapv1.AddToScheme(scheme)
+	_ = addonsv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.Verbose, "verbose", "v", false, "Enable verbose logging")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.MetricsAddr, "metrics-addr", ":8484", "The address the metrics endpoint binds to.")
 	rootCmd.PersistentFlags().BoolVar(&rootFlags.EnableLeaderElection, "enable-leader-election", false, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	rootCmd.PersistentFlags().StringVarP(&rootFlags.ManifestsConfigPath, "manifests-config-path", "m", "", "Path to the manifests config. Disable manifest deployment if not set")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.AddonsCatalogPath, "addons-catalog-path", "", "Path to the addons catalog. Disable addon deployment if not set")
 	rootCmd.PersistentFlags().StringVarP(&rootFlags.PackerConfigPath, "packer-config-path", "p", "", "Path to the packer config. Disable image building if not set")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.RequireSeparateWorkloadCredentials, "require-separate-workload-credentials", false, "Refuse to pass the credentials of the controller to the manifests, HcloudClusters have to reference separate workload credentials")
+	rootCmd.PersistentFlags().StringSliceVar(&rootFlags.CSRDNSSuffixes, "csr-dns-suffixes", nil, "DNS suffixes, which are allowed to be appended to the node name in kubelet serving certificates, e.g. for nodes with FQDN hostnames")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.CSRReportOnly, "csr-report-only", false, "Only record the decisions about kubelet serving certificates as events and metrics without approving or denying them")
+	rootCmd.PersistentFlags().DurationVar(&rootFlags.OrphanGCInterval, "orphan-gc-interval", 10*time.Minute, "Interval between the garbage collections of orphaned resources in the projects of the clusters, 0 disables the garbage collection")
+	rootCmd.PersistentFlags().DurationVar(&rootFlags.OrphanGCGracePeriod, "orphan-gc-grace-period", time.Hour, "Time a resource has to be orphaned for, before it is deleted by the garbage collection")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.OrphanGCDryRun, "orphan-gc-dry-run", true, "Only report orphaned resources as events and metrics without deleting them")
+	rootCmd.PersistentFlags().Float64Var(&rootFlags.HcloudRateLimit, "hcloud-rate-limit", scope.DefaultHcloudClientCacheOptions.RateLimit, "Requests per second sent to the hcloud API of a project, 0 disables the rate limiting")
+	rootCmd.PersistentFlags().IntVar(&rootFlags.HcloudRateLimitBurst, "hcloud-rate-limit-burst", scope.DefaultHcloudClientCacheOptions.Burst, "Requests sent to the hcloud API of a project at once, before the rate limit applies, at least 1 with a rate limit")
+	rootCmd.PersistentFlags().IntVar(&rootFlags.HcloudMaxRetries, "hcloud-max-retries", scope.DefaultHcloudClientCacheOptions.MaxRetries, "Retries of hcloud API requests, which have been rate limited or failed with a server error")
+	rootCmd.PersistentFlags().DurationVar(&rootFlags.HcloudPollInterval, "hcloud-poll-interval", time.Minute, "Interval between the lists of the servers, load balancers, networks and volumes of a project, all but the servers are answered from memory, 0 disables the caching")
 	rootCmd.PersistentFlags().IntVar(&rootFlags.WebhookPort, "webhook-port", 0, "Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
 }
 
@@ -74,8 +103,25 @@ var rootCmd = &cobra.Command{
 		if rootFlags.WebhookPort == 0 {
 			// run in controller mode
 
+			// share the hcloud API clients of a project between the controllers
+			hcloudClientOptions := scope.DefaultHcloudClientCacheOptions
+			hcloudClientOptions.RateLimit = rootFlags.HcloudRateLimit
+			hcloudClientOptions.Burst = rootFlags.HcloudRateLimitBurst
+			hcloudClientOptions.MaxRetries = rootFlags.HcloudMaxRetries
+			hcloudClientOptions.PollInterval = rootFlags.HcloudPollInterval
+			hcloudClientOptions.Logger = ctrl.Log.WithName("module").WithName("hcloud")
+			if err := hcloudClientOptions.Validate(); err != nil {
+				setupLog.Error(err, "invalid hcloud API client flags")
+				os.Exit(1)
+			}
+			scope.DefaultHcloudClientCache = scope.NewHcloudClientCache(hcloudClientOptions)
+			if err := mgr.Add(scope.DefaultHcloudClientCache); err != nil {
+				setupLog.Error(err, "unable to add hcloud client cache")
+				os.Exit(1)
+			}
+
 			// Initialise manifests generator
-			manifestsMgr := manifests.New(ctrl.Log.WithName("module").WithName("manifests"), rootFlags.ManifestsConfigPath)
+			manifestsMgr := manifests.New(ctrl.Log.WithName("module").WithName("manifests"), rootFlags.ManifestsConfigPath, rootFlags.AddonsCatalogPath)
 			if err := manifestsMgr.Initialize(); err != nil {
 				setupLog.Error(err, "unable to initialise manifests manager")
 				os.Exit(1)
@@ -90,6 +136,10 @@ var rootCmd = &cobra.Command{
 				Scheme:    mgr.GetScheme(),
 				Packer:    packerMgr,
 				Manifests: manifestsMgr,
+
+				RequireSeparateWorkloadCredentials: rootFlags.RequireSeparateWorkloadCredentials,
+				CSRDNSSuffixes:                     rootFlags.CSRDNSSuffixes,
+				CSRReportOnly:                      rootFlags.CSRReportOnly,
 			}).SetupWithManager(mgr, controller.Options{}); err != nil {
 				setupLog.Error(err, "unable to create controller", "controller", "HcloudCluster")
 				os.Exit(1)
@@ -127,6 +177,33 @@ var rootCmd = &cobra.Command{
 				setupLog.Error(err, "unable to create controller", "controller", "HcloudVolume")
 				os.Exit(1)
 			}
+			if err = (&controllers.HcloudRemediationReconciler{
+				Client:    mgr.GetClient(),
+				Log:       ctrl.Log.WithName("controllers").WithName("HcloudRemediation"),
+				Recorder:  mgr.GetEventRecorderFor("hcloudremediation-controller"),
+				Scheme:    mgr.GetScheme(),
+				Packer:    packerMgr,
+				Manifests: manifestsMgr,
+			}).SetupWithManager(mgr, controller.Options{}); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "HcloudRemediation")
+				os.Exit(1)
+			}
+			if rootFlags.OrphanGCInterval > 0 {
+				if err = (&controllers.HcloudGarbageCollector{
+					Client:      mgr.GetClient(),
+					Log:         ctrl.Log.WithName("controllers").WithName("HcloudGarbageCollector"),
+					Recorder:    mgr.GetEventRecorderFor("hcloudgarbagecollector-controller"),
+					Scheme:      mgr.GetScheme(),
+					Packer:      packerMgr,
+					Manifests:   manifestsMgr,
+					Interval:    rootFlags.OrphanGCInterval,
+					GracePeriod: rootFlags.OrphanGCGracePeriod,
+					DryRun:      rootFlags.OrphanGCDryRun,
+				}).SetupWithManager(mgr, controller.Options{}); err != nil {
+					setupLog.Error(err, "unable to create controller", "controller", "HcloudGarbageCollector")
+					os.Exit(1)
+				}
+			}
 			// +kubebuilder:scaffold:builder
 		} else {
 			// run in webhook mode
@@ -140,6 +217,8 @@ var rootCmd = &cobra.Command{
 				&infrav1alpha3.HcloudClusterList{},
 				&infrav1alpha3.HcloudMachine{},
 				&infrav1alpha3.HcloudMachineList{},
+				&infrav1alpha3.HcloudMachineTemplate{},
+				&infrav1alpha3.HcloudMachineTemplateList{},
 			} {
 				if err = t.SetupWebhookWithManager(mgr); err != nil {
 					setupLog.Error(err, "unable to create webhook", "webhook", "HcloudCluster")
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/cluster-api/util"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests"
+	manifestsapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+var manifestsFlags = struct {
+	Kubeconfig         string
+	Namespace          string
+	WorkloadKubeconfig string
+}{}
+
+func init() {
+	manifestsCmd.PersistentFlags().StringVar(&manifestsFlags.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig of the management cluster. Defaults to the in-cluster config or $KUBECONFIG")
+	manifestsCmd.PersistentFlags().StringVarP(&manifestsFlags.Namespace, "namespace", "n", "default", "Namespace of the HcloudCluster")
+	manifestsDiffCmd.Flags().StringVar(&manifestsFlags.WorkloadKubeconfig, "workload-kubeconfig", "", "Path to the kubeconfig of the workload cluster. Defaults to the kubeconfig secret of the cluster")
+
+	manifestsCmd.AddCommand(manifestsRenderCmd, manifestsDiffCmd)
+	rootCmd.AddCommand(manifestsCmd)
+}
+
+var manifestsCmd = &cobra.Command{
+	Use:          "manifests",
+	Short:        "Render the manifests and addons of a HcloudCluster",
+	SilenceUsage: true,
+}
+
+var manifestsRenderCmd = &cobra.Command{
+	Use:   "render HCLOUDCLUSTER",
+	Short: "Render the manifests and addons of a HcloudCluster with redacted secrets",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterScope, _, err := newManifestsClusterScope(args[0])
+		if err != nil {
+			return err
+		}
+
+		sets, err := renderManifestSets(clusterScope)
+		if err != nil {
+			return err
+		}
+
+		for _, set := range sets {
+			if err := manifests.RedactSecrets(set.objects); err != nil {
+				return err
+			}
+			if err := writeObjects(os.Stdout, set.name, set.objects); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var manifestsDiffCmd = &cobra.Command{
+	Use:   "diff HCLOUDCLUSTER",
+	Short: "Diff the manifests and addons of a HcloudCluster against the live workload cluster",
+	Long:  "Diff the manifests and addons of a HcloudCluster against the live workload cluster. Only fields set by the manifests are compared and the values of secrets are redacted. Exits with an error if there are differences.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterScope, manifestsMgr, err := newManifestsClusterScope(args[0])
+		if err != nil {
+			return err
+		}
+
+		var clientConfig clientcmd.ClientConfig
+		if manifestsFlags.WorkloadKubeconfig != "" {
+			clientConfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				&clientcmd.ClientConfigLoadingRules{ExplicitPath: manifestsFlags.WorkloadKubeconfig},
+				&clientcmd.ConfigOverrides{},
+			)
+		} else if clientConfig, err = clusterScope.ClientConfig(); err != nil {
+			return err
+		}
+
+		sets, err := renderManifestSets(clusterScope)
+		if err != nil {
+			return err
+		}
+
+		var differences int
+		for _, set := range sets {
+			results, err := manifestsMgr.Diff(clusterScope.Ctx, clientConfig, set.objects)
+			if err != nil {
+				return errors.Wrapf(err, "error comparing %s", set.name)
+			}
+			for _, result := range results {
+				if result.Missing {
+					fmt.Printf("%s: %s is missing\n", set.name, result.Object)
+					differences++
+				} else if result.Diff != "" {
+					fmt.Printf("%s: %s differs (-live +rendered):\n%s\n", set.name, result.Object, result.Diff)
+					differences++
+				}
+			}
+		}
+
+		if differences > 0 {
+			return errors.Errorf("%d object(s) differ from the workload cluster", differences)
+		}
+		return nil
+	},
+}
+
+// manifestSet is the rendered manifests or a single addon
+type manifestSet struct {
+	name    string
+	objects []*unstructured.Unstructured
+}
+
+// newManifestsClusterScope creates the scope of a HcloudCluster from the
+// management cluster, so the manifests are rendered with the same parameters
+// as by the controller
+func newManifestsClusterScope(name string) (*scope.ClusterScope, *manifests.Manifests, error) {
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = rootFlags.Verbose
+	}))
+	ctx := context.Background()
+
+	restConfig, err := ctrl.GetConfig()
+	if manifestsFlags.Kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", manifestsFlags.Kubeconfig)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error loading management cluster kubeconfig")
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating management cluster client")
+	}
+
+	hcloudCluster := &infrav1.HcloudCluster{}
+	key := types.NamespacedName{Namespace: manifestsFlags.Namespace, Name: name}
+	if err := c.Get(ctx, key, hcloudCluster); err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting HcloudCluster %s", key)
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, c, hcloudCluster.ObjectMeta)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting owner cluster of HcloudCluster %s", key)
+	}
+	if cluster == nil {
+		return nil, nil, errors.Errorf("HcloudCluster %s has no owner cluster", key)
+	}
+
+	manifestsMgr := manifests.New(ctrl.Log.WithName("module").WithName("manifests"), rootFlags.ManifestsConfigPath, rootFlags.AddonsCatalogPath)
+	clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+		Ctx:           ctx,
+		Client:        c,
+		Logger:        ctrl.Log.WithName("manifests"),
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        packer.New(ctrl.Log.WithName("module").WithName("packer")),
+		Manifests:     manifestsMgr,
+
+		RequireSeparateWorkloadCredentials: rootFlags.RequireSeparateWorkloadCredentials,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating cluster scope")
+	}
+	return clusterScope, manifestsMgr, nil
+}
+
+// renderManifestSets renders the manifests, if configured, and every addon
+// selected in the spec
+func renderManifestSets(clusterScope *scope.ClusterScope) ([]manifestSet, error) {
+	var sets []manifestSet
+
+	if rootFlags.ManifestsConfigPath != "" {
+		objects, err := clusterScope.RenderManifests()
+		if err != nil {
+			return nil, errors.Wrap(err, "error rendering manifests")
+		}
+		sets = append(sets, manifestSet{name: "manifests", objects: objects})
+	}
+
+	for _, addonType := range infrav1.HcloudClusterAddonTypes {
+		addon := clusterScope.HcloudCluster.Spec.Addons.Get(addonType)
+		if addon == nil {
+			continue
+		}
+		ref := manifestsapi.Addon{Name: addon.Name, Version: addon.Version}
+		objects, err := clusterScope.RenderAddon(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error rendering %s addon %s", addonType, ref)
+		}
+		sets = append(sets, manifestSet{name: fmt.Sprintf("%s addon %s", addonType, ref), objects: objects})
+	}
+
+	return sets, nil
+}
+
+func writeObjects(w io.Writer, name string, objects []*unstructured.Unstructured) error {
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return errors.Wrapf(err, "error marshalling %s", obj.GetName())
+		}
+		if _, err := fmt.Fprintf(w, "---\n# %s\n%s", name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
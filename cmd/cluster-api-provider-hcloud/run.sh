@@ -14,4 +14,5 @@ export PATH=$(pwd)/usr/local/bin:$PATH
 exec $CAPH \
   --verbose \
   --manifests-config-path "./manifests-config/config-extvar.jsonnet" \
+  --addons-catalog-path "./manifests-config/addons" \
   "$@"
@@ -0,0 +1,209 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/rollout"
+)
+
+// addonReadinessResyncPeriod rechecks the addons, as changes of the applied
+// addons in the HcloudCluster status are not watched
+const addonReadinessResyncPeriod = time.Minute
+
+// GuestAddonReconciler watches the rollout of the Deployments and DaemonSets
+// applied by the addons to the workload cluster and reports the readiness of
+// every addon as condition of the HcloudCluster. A rollout of a new applied
+// hash or generation is reported as in progress, an addon is only reported as
+// degraded, once its rolled out workloads lose availability.
+type GuestAddonReconciler struct {
+	controllerclient.Client
+	Log      logr.Logger
+	mCluster ManagementCluster
+
+	// rolledOutHashes are the applied hashes of the addons, which have been
+	// rolled out completely
+	rolledOutHashes map[infrav1.HcloudClusterAddonType]string
+}
+
+func (r *GuestAddonReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.TODO()
+	log := r.Log.WithValues("hcloudCluster", req.NamespacedName)
+
+	hcloudCluster := &infrav1.HcloudCluster{}
+	if err := r.mCluster.Get(ctx, req.NamespacedName, hcloudCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(hcloudCluster, r.mCluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if r.rolledOutHashes == nil {
+		r.rolledOutHashes = make(map[infrav1.HcloudClusterAddonType]string)
+	}
+
+	ownedConditions := make([]clusterv1.ConditionType, 0, len(infrav1.HcloudClusterAddonTypes))
+	for _, addonType := range infrav1.HcloudClusterAddonTypes {
+		condition := addonType.ReadyCondition()
+		ownedConditions = append(ownedConditions, condition)
+
+		var addon *infrav1.HcloudClusterAddonStatus
+		if hcloudCluster.Status.Manifests != nil {
+			addon = hcloudCluster.Status.Manifests.Addon(addonType)
+		}
+		if addon == nil {
+			conditions.Delete(hcloudCluster, condition)
+			continue
+		}
+
+		if addon.AppliedHash == nil {
+			conditions.MarkFalse(hcloudCluster, condition, infrav1.AddonNotAppliedReason, clusterv1.ConditionSeverityInfo, "")
+			continue
+		}
+
+		msg, unavailable, err := r.addonRolloutStatus(ctx, addon)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		if msg == "" {
+			conditions.MarkTrue(hcloudCluster, condition)
+			r.rolledOutHashes[addonType] = *addon.AppliedHash
+			continue
+		}
+
+		// the rolled out hash is not known after a restart, then an addon
+		// which has been ready or degraded before is considered rolled out
+		rolledOut := conditions.IsTrue(hcloudCluster, condition) || conditions.GetReason(hcloudCluster, condition) == infrav1.AddonDegradedReason
+		if hash, ok := r.rolledOutHashes[addonType]; ok {
+			rolledOut = hash == *addon.AppliedHash
+		}
+		if !rolledOut || !unavailable {
+			conditions.MarkFalse(hcloudCluster, condition, infrav1.AddonRolloutInProgressReason, clusterv1.ConditionSeverityInfo, msg)
+			continue
+		}
+
+		if conditions.GetReason(hcloudCluster, condition) != infrav1.AddonDegradedReason {
+			r.mCluster.Eventf(
+				corev1.EventTypeWarning,
+				"AddonDegraded",
+				"The %s addon %s is degraded: %s",
+				addonType,
+				addon.Name,
+				msg,
+			)
+		}
+		conditions.MarkFalse(hcloudCluster, condition, infrav1.AddonDegradedReason, clusterv1.ConditionSeverityWarning, msg)
+	}
+
+	if err := patchHelper.Patch(ctx, hcloudCluster, patch.WithOwnedConditions{Conditions: ownedConditions}); err != nil {
+		log.Error(err, "failed to patch addon conditions")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: addonReadinessResyncPeriod}, nil
+}
+
+// addonRolloutStatus returns an empty message if all Deployments and
+// DaemonSets of the addon have been rolled out. It reports whether a workload
+// is missing or has lost availability without rolling out a new generation.
+func (r *GuestAddonReconciler) addonRolloutStatus(ctx context.Context, addon *infrav1.HcloudClusterAddonStatus) (string, bool, error) {
+	var msgs []string
+	var unavailable bool
+	for _, o := range addon.AppliedObjects {
+		if o.APIVersion != appsv1.SchemeGroupVersion.String() {
+			continue
+		}
+		key := types.NamespacedName{Namespace: o.Namespace, Name: o.Name}
+		if key.Namespace == "" {
+			key.Namespace = corev1.NamespaceDefault
+		}
+
+		switch o.Kind {
+		case "Deployment":
+			var d appsv1.Deployment
+			if err := r.Get(ctx, key, &d); apierrors.IsNotFound(err) {
+				msgs = append(msgs, "deployment "+key.String()+" not found")
+				unavailable = true
+			} else if err != nil {
+				return "", false, err
+			} else if msg := rollout.DeploymentStatus(&d); msg != "" {
+				msgs = append(msgs, msg)
+				unavailable = unavailable || rollout.DeploymentUnavailable(&d)
+			}
+		case "DaemonSet":
+			var ds appsv1.DaemonSet
+			if err := r.Get(ctx, key, &ds); apierrors.IsNotFound(err) {
+				msgs = append(msgs, "daemon set "+key.String()+" not found")
+				unavailable = true
+			} else if err != nil {
+				return "", false, err
+			} else if msg := rollout.DaemonSetStatus(&ds); msg != "" {
+				msgs = append(msgs, msg)
+				unavailable = unavailable || rollout.DaemonSetUnavailable(&ds)
+			}
+		}
+	}
+	return strings.Join(msgs, ", "), unavailable, nil
+}
+
+func (r *GuestAddonReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	options.Reconciler = r
+	c, err := controller.New("guest-addon-controller", mgr, options)
+	if err != nil {
+		return err
+	}
+
+	// every change of a workload is mapped to the single HcloudCluster of the
+	// workload cluster
+	toHcloudCluster := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(_ handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{
+				Namespace: r.mCluster.Namespace(),
+				Name:      r.mCluster.Name(),
+			}}}
+		}),
+	}
+
+	if err := c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, toHcloudCluster); err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &appsv1.DaemonSet{}}, toHcloudCluster)
+}
@@ -0,0 +1,173 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+func TestGuestAddonReconciler(t *testing.T) {
+	ctx := context.TODO()
+	key := types.NamespacedName{Namespace: "default", Name: "test"}
+	deploymentKey := types.NamespacedName{Namespace: "kube-system", Name: "cilium-operator"}
+	replicas := int32(2)
+
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: deploymentKey.Namespace, Name: deploymentKey.Name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	mClient := newTestClient(hcloudCluster)
+	wClient := newTestClient(deployment)
+	recorder := record.NewFakeRecorder(10)
+	r := &GuestAddonReconciler{
+		Client: wClient,
+		Log:    klogr.New(),
+		mCluster: &managementCluster{
+			Client:        mClient,
+			hcloudCluster: hcloudCluster,
+			recorder:      recorder,
+		},
+	}
+
+	for _, step := range []struct {
+		name       string
+		hash       string
+		generation int64
+		status     appsv1.DeploymentStatus
+		reason     string
+		event      string
+	}{
+		{
+			name:       "first rollout",
+			hash:       "a",
+			generation: 1,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			reason:     infrav1.AddonRolloutInProgressReason,
+		},
+		{
+			name:       "rolled out",
+			hash:       "a",
+			generation: 1,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:       "new generation",
+			hash:       "b",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			reason:     infrav1.AddonRolloutInProgressReason,
+		},
+		{
+			name:       "new replicas becoming available",
+			hash:       "b",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			reason:     infrav1.AddonRolloutInProgressReason,
+		},
+		{
+			name:       "new generation rolled out",
+			hash:       "b",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:       "lost availability",
+			hash:       "b",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			reason:     infrav1.AddonDegradedReason,
+			event:      "Warning AddonDegraded The cni addon cilium is degraded: deployment kube-system/cilium-operator: 1 of 2 updated replicas are available",
+		},
+		{
+			name:       "still degraded",
+			hash:       "b",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			reason:     infrav1.AddonDegradedReason,
+		},
+	} {
+		var c infrav1.HcloudCluster
+		if err := mClient.Get(ctx, key, &c); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		hash := step.hash
+		c.Status.Manifests = &infrav1.HcloudClusterStatusManifests{
+			Addons: []infrav1.HcloudClusterAddonStatus{{
+				Type:        infrav1.HcloudClusterAddonTypeCNI,
+				Name:        "cilium",
+				AppliedHash: &hash,
+				AppliedObjects: []infrav1.HcloudClusterManifestObject{{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Namespace:  deploymentKey.Namespace,
+					Name:       deploymentKey.Name,
+				}},
+			}},
+		}
+		if err := mClient.Update(ctx, &c); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		var d appsv1.Deployment
+		if err := wClient.Get(ctx, deploymentKey, &d); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		d.Generation = step.generation
+		d.Status = step.status
+		if err := wClient.Update(ctx, &d); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		if err := mClient.Get(ctx, key, &c); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		condition := conditions.Get(&c, infrav1.CNIAddonReadyCondition)
+		if condition == nil {
+			t.Fatalf("%s: condition not set", step.name)
+		}
+		if ready := conditions.IsTrue(&c, infrav1.CNIAddonReadyCondition); ready != (step.reason == "") {
+			t.Errorf("%s: expected addon to be ready %v, got %v", step.name, step.reason == "", ready)
+		} else if !ready && condition.Reason != step.reason {
+			t.Errorf("%s: expected reason %q, got %q", step.name, step.reason, condition.Reason)
+		}
+
+		var event string
+		if len(recorder.Events) > 0 {
+			event = <-recorder.Events
+		}
+		if event != step.event {
+			t.Errorf("%s: expected event %q, got %q", step.name, step.event, event)
+		}
+	}
+}
@@ -18,24 +18,31 @@ package controllers
 import (
 	"context"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
-	certificatesv1 "k8s.io/api/certificates/v1beta1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	ctrl "sigs.k8s.io/controller-runtime"
 	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/csr"
 )
 
@@ -43,97 +50,265 @@ type ManagementCluster interface {
 	controllerclient.Client
 	Eventf(eventtype, reason, message string, args ...interface{})
 	Event(eventtype, reason, message string)
+	ObjectEventf(object runtime.Object, eventtype, reason, message string, args ...interface{})
 	Namespace() string
+	Name() string
+}
+
+// CSRs are served by certificates.k8s.io/v1 since Kubernetes v1.19, older
+// clusters only serve v1beta1
+var (
+	csrGroupVersionV1      = schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1"}
+	csrGroupVersionV1beta1 = schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1beta1"}
+)
+
+// csrGroupVersion returns the most recent version of the CSR API served by
+// the cluster
+func csrGroupVersion(discoveryClient discovery.DiscoveryInterface) (schema.GroupVersion, error) {
+	if _, err := discoveryClient.ServerResourcesForGroupVersion(csrGroupVersionV1.String()); err == nil {
+		return csrGroupVersionV1, nil
+	} else if !apierrors.IsNotFound(err) {
+		return schema.GroupVersion{}, err
+	}
+	return csrGroupVersionV1beta1, nil
+}
+
+// outcomes of kubelet serving CSRs
+const (
+	csrOutcomeApproved = "approved"
+	csrOutcomeDenied   = "denied"
+	csrOutcomeInvalid  = "invalid"
+)
+
+// csrDecisionsTotal counts the kubelet serving CSRs handled per cluster, in
+// report only mode the outcome is the decision, which would have been made
+var csrDecisionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "capi_hcloud_csr_decisions_total",
+		Help: "Number of kubelet serving CertificateSigningRequests decided by outcome and cluster",
+	},
+	[]string{"cluster", "outcome", "report_only"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(csrDecisionsTotal)
 }
 
 type GuestCSRReconciler struct {
 	controllerclient.Client
-	Log       logr.Logger
-	mCluster  ManagementCluster
-	clientSet *kubernetes.Clientset
+	Log           logr.Logger
+	mCluster      ManagementCluster
+	dynamicClient dynamic.Interface
+	groupVersion  schema.GroupVersion
+
+	// DNSSuffixes are allowed to be appended to the node name in the DNS
+	// names of serving certificates
+	DNSSuffixes []string
+
+	// ReportOnly records the decisions without approving or denying the
+	// CSRs
+	ReportOnly bool
+
+	// recorded holds the UIDs of the pending CSRs by name, whose decision has
+	// been recorded, so it is only recorded once per CSR
+	recorded     map[string]types.UID
+	recordedLock sync.Mutex
+}
+
+func (r *GuestCSRReconciler) newCSR() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(r.groupVersion.WithKind("CertificateSigningRequest"))
+	return u
 }
 
 func (r *GuestCSRReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.TODO()
 	log := r.Log.WithValues("csr", req.Name)
 
-	// Fetch the HcloudCluster instance
-	certificateSigningRequest := &certificatesv1.CertificateSigningRequest{}
+	// Fetch the CertificateSigningRequest instance
+	certificateSigningRequest := r.newCSR()
 	err := r.Get(ctx, req.NamespacedName, certificateSigningRequest)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			r.forgetRecorded(req.Name)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
 
 	// skip CSR that have already been decided
-	if len(certificateSigningRequest.Status.Conditions) > 0 {
+	conditions, _, err := unstructured.NestedSlice(certificateSigningRequest.Object, "status", "conditions")
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(conditions) > 0 {
+		r.forgetRecorded(req.Name)
 		return reconcile.Result{}, nil
 	}
-	nodePrefix := "system:node:"
-	// skip CSR from non-nodes
-	if !strings.HasPrefix(certificateSigningRequest.Spec.Username, nodePrefix) {
+
+	// the decision of a pending CSR does not change, as its request is
+	// immutable
+	if r.isRecorded(req.Name, certificateSigningRequest.GetUID()) {
 		return reconcile.Result{}, nil
 	}
 
-	// find matching HcloudMachine object
-	var hcloudMachine infrav1.HcloudMachine
-	if err := r.mCluster.Get(ctx, types.NamespacedName{
-		Namespace: r.mCluster.Namespace(),
-		Name:      strings.TrimPrefix(certificateSigningRequest.Spec.Username, nodePrefix),
-	}, &hcloudMachine); err != nil {
-		return reconcile.Result{}, err
+	username, _, _ := unstructured.NestedString(certificateSigningRequest.Object, "spec", "username")
+	signerName, _, _ := unstructured.NestedString(certificateSigningRequest.Object, "spec", "signerName")
+	usages, _, _ := unstructured.NestedStringSlice(certificateSigningRequest.Object, "spec", "usages")
+
+	// skip CSR which are not kubelet serving certificates
+	if !csr.IsKubeletServingRequest(username, signerName, usages) {
+		return reconcile.Result{}, nil
 	}
 
-	csrBlock, _ := pem.Decode(certificateSigningRequest.Spec.Request)
+	// find matching machine object
+	nodeName := strings.TrimPrefix(username, csr.NodesPrefix)
+	machine, err := findInfraMachine(ctx, r.mCluster, nodeName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if machine == nil {
+		return reconcile.Result{}, errors.Errorf("no HcloudMachine or BareMetalMachine found for node %s", nodeName)
+	}
 
-	csrRequest, err := x509.ParseCertificateRequest(csrBlock.Bytes)
+	request, _, _ := unstructured.NestedString(certificateSigningRequest.Object, "spec", "request")
+	csrRequest, err := parseCertificateRequest(request)
 	if err != nil {
-		r.mCluster.Eventf(
+		r.countDecision(csrOutcomeInvalid)
+		r.mCluster.ObjectEventf(
+			machine,
 			corev1.EventTypeWarning,
 			"CSRParsingError",
 			"Error parsing CertificateSigningRequest %s: %s",
 			req.Name,
 			err,
 		)
-		return reconcile.Result{}, err
+		// the request will not become valid, so it is not retried
+		r.setRecorded(req.Name, certificateSigningRequest.GetUID())
+		return reconcile.Result{}, nil
 	}
 
-	var condition = certificatesv1.CertificateSigningRequestCondition{
-		LastUpdateTime: metav1.Time{Time: time.Now()},
+	var condition = map[string]interface{}{
+		"status":         string(corev1.ConditionTrue),
+		"lastUpdateTime": time.Now().UTC().Format(time.RFC3339),
+	}
+	validationErr := csr.ValidateKubeletServingUsages(usages)
+	if validationErr == nil {
+		validationErr = csr.ValidateKubeletCSR(csrRequest, machine, r.DNSSuffixes)
 	}
-	if err := csr.ValidateKubeletCSR(csrRequest, &hcloudMachine); err != nil {
-		condition.Type = certificatesv1.CertificateDenied
-		condition.Reason = "CSRValidationFailed"
-		condition.Message = fmt.Sprintf("Validation by cluster-api-provider-hcloud failed: %s", err)
+	if validationErr != nil {
+		condition["type"] = "Denied"
+		condition["reason"] = "CSRValidationFailed"
+		condition["message"] = fmt.Sprintf("Validation by cluster-api-provider-hcloud failed: %s", validationErr)
 	} else {
-		condition.Type = certificatesv1.CertificateApproved
-		condition.Reason = "CSRValidationSucceed"
-		condition.Message = "Validation by cluster-api-provider-hcloud was successful"
+		condition["type"] = "Approved"
+		condition["reason"] = "CSRValidationSucceed"
+		condition["message"] = "Validation by cluster-api-provider-hcloud was successful"
 	}
 
-	certificateSigningRequest.Status.Conditions = append(
-		certificateSigningRequest.Status.Conditions,
-		condition,
-	)
+	if r.ReportOnly {
+		log.Info("report only, not updating approval of csr", "username", username, "decision", condition["type"], "message", condition["message"])
+		r.recordDecision(req.Name, machine, validationErr)
+		r.setRecorded(req.Name, certificateSigningRequest.GetUID())
+		return reconcile.Result{}, nil
+	}
+
+	if err := unstructured.SetNestedSlice(certificateSigningRequest.Object, []interface{}{condition}, "status", "conditions"); err != nil {
+		return reconcile.Result{}, err
+	}
 
-	if _, err := r.clientSet.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(certificateSigningRequest); err != nil {
-		log.Error(err, "updating approval of csr failed", "username", certificateSigningRequest.Spec.Username, "csr")
+	resource := r.dynamicClient.Resource(r.groupVersion.WithResource("certificatesigningrequests"))
+	if _, err := resource.Update(certificateSigningRequest, metav1.UpdateOptions{}, "approval"); err != nil {
+		log.Error(err, "updating approval of csr failed", "username", username)
+		return reconcile.Result{}, err
 	}
+	log.Info("updated approval of csr", "username", username, "decision", condition["type"], "message", condition["message"])
+	r.recordDecision(req.Name, machine, validationErr)
 
 	return reconcile.Result{}, nil
 }
 
-func (r *GuestCSRReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	var (
-		controlledType = &certificatesv1.CertificateSigningRequest{}
-		// controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
-		// controlledTypeGVK  = certificatesv1.GroupVersion.WithKind(controlledTypeName)
+// recordDecision emits an event on the machine of the node and counts the
+// outcome of a CSR
+func (r *GuestCSRReconciler) recordDecision(name string, machine infraMachine, validationErr error) {
+	var prefix string
+	if r.ReportOnly {
+		prefix = "[report only] "
+	}
+
+	if validationErr != nil {
+		r.countDecision(csrOutcomeDenied)
+		r.mCluster.ObjectEventf(
+			machine,
+			corev1.EventTypeWarning,
+			"CSRDenied",
+			"%sDenied CertificateSigningRequest %s of node %s: %s",
+			prefix,
+			name,
+			machine.NodeName(),
+			validationErr,
+		)
+		return
+	}
+
+	r.countDecision(csrOutcomeApproved)
+	r.mCluster.ObjectEventf(
+		machine,
+		corev1.EventTypeNormal,
+		"CSRApproved",
+		"%sApproved CertificateSigningRequest %s of node %s",
+		prefix,
+		name,
+		machine.NodeName(),
 	)
+}
 
+func (r *GuestCSRReconciler) isRecorded(name string, uid types.UID) bool {
+	r.recordedLock.Lock()
+	defer r.recordedLock.Unlock()
+	recordedUID, ok := r.recorded[name]
+	return ok && recordedUID == uid
+}
+
+func (r *GuestCSRReconciler) setRecorded(name string, uid types.UID) {
+	r.recordedLock.Lock()
+	defer r.recordedLock.Unlock()
+	if r.recorded == nil {
+		r.recorded = make(map[string]types.UID)
+	}
+	r.recorded[name] = uid
+}
+
+func (r *GuestCSRReconciler) forgetRecorded(name string) {
+	r.recordedLock.Lock()
+	defer r.recordedLock.Unlock()
+	delete(r.recorded, name)
+}
+
+func (r *GuestCSRReconciler) countDecision(outcome string) {
+	csrDecisionsTotal.WithLabelValues(
+		fmt.Sprintf("%s/%s", r.mCluster.Namespace(), r.mCluster.Name()),
+		outcome,
+		fmt.Sprintf("%t", r.ReportOnly),
+	).Inc()
+}
+
+// parseCertificateRequest decodes the base64 encoded PEM of a CSR
+func parseCertificateRequest(request string) (*x509.CertificateRequest, error) {
+	data, err := base64.StdEncoding.DecodeString(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid request encoding")
+	}
+	csrBlock, _ := pem.Decode(data)
+	if csrBlock == nil {
+		return nil, errors.New("no PEM block found in request")
+	}
+	return x509.ParseCertificateRequest(csrBlock.Bytes)
+}
+
+func (r *GuestCSRReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
-		For(controlledType).
+		For(r.newCSR()).
 		Complete(r)
 }
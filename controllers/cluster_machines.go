@@ -0,0 +1,72 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/csr"
+)
+
+// infraMachine is the HcloudMachine or BareMetalMachine of a node in the
+// workload cluster
+type infraMachine interface {
+	csr.Machine
+	conditions.Setter
+
+	NodeProviderID() string
+	NodeTopology() (region, zone string)
+	NodeInstanceType() string
+	NodeAddresses() []corev1.NodeAddress
+}
+
+var _ infraMachine = &infrav1.HcloudMachine{}
+var _ infraMachine = &infrav1.BareMetalMachine{}
+
+// findInfraMachine returns the HcloudMachine or BareMetalMachine of a node,
+// nil if the node has no machine in the namespace of the cluster
+func findInfraMachine(ctx context.Context, mCluster ManagementCluster, nodeName string) (infraMachine, error) {
+	var hcloudMachine infrav1.HcloudMachine
+	err := mCluster.Get(ctx, types.NamespacedName{
+		Namespace: mCluster.Namespace(),
+		Name:      nodeName,
+	}, &hcloudMachine)
+	if err == nil {
+		return &hcloudMachine, nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// bare metal nodes are named after the server
+	var bareMetalMachines infrav1.BareMetalMachineList
+	if err := mCluster.List(ctx, &bareMetalMachines, controllerclient.InNamespace(mCluster.Namespace())); err != nil {
+		return nil, err
+	}
+	for pos := range bareMetalMachines.Items {
+		if m := &bareMetalMachines.Items[pos]; m.NodeName() == nodeName {
+			return m, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,242 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+// taintExternalCloudProvider is set by the kubelet running with an external
+// cloud provider, until the node has been initialized
+const taintExternalCloudProvider = "node.cloudprovider.kubernetes.io/uninitialized"
+
+const (
+	// nodeProviderIDRequeuePeriod rechecks nodes, whose machines have no
+	// provider ID yet, as changes of the machines are not watched
+	nodeProviderIDRequeuePeriod = 30 * time.Second
+
+	// nodeInitializationGracePeriod leaves the initialization of new nodes
+	// to a cloud controller manager, if one is running in the cluster
+	nodeInitializationGracePeriod = 2 * time.Minute
+)
+
+// GuestNodeReconciler reports the state of the nodes of the workload cluster
+// on their HcloudMachine or BareMetalMachine and initializes the nodes for
+// clusters running without the hcloud cloud controller manager. It sets the
+// provider ID and missing topology and instance type labels. Nodes which are
+// still uninitialized after a grace period get their addresses and the
+// uninitialized taint is removed.
+type GuestNodeReconciler struct {
+	controllerclient.Client
+	Log      logr.Logger
+	mCluster ManagementCluster
+}
+
+func (r *GuestNodeReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.TODO()
+	log := r.Log.WithValues("node", req.Name)
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		node = nil
+	}
+
+	machine, err := findInfraMachine(ctx, r.mCluster, req.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if machine == nil {
+		log.V(1).Info("no HcloudMachine or BareMetalMachine found for node")
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.reconcileMachineStatus(ctx, machine, node); err != nil {
+		log.Error(err, "failed to patch node status of machine", "machine", machine.GetName())
+		return reconcile.Result{}, err
+	}
+	if node == nil {
+		return reconcile.Result{}, nil
+	}
+
+	providerID := machine.NodeProviderID()
+	if providerID == "" {
+		log.V(1).Info("machine of node has no provider ID yet", "machine", machine.GetName())
+		return reconcile.Result{RequeueAfter: nodeProviderIDRequeuePeriod}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(node, r.Client)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if node.Spec.ProviderID == "" {
+		node.Spec.ProviderID = providerID
+	} else if node.Spec.ProviderID != providerID {
+		r.mCluster.ObjectEventf(
+			machine,
+			corev1.EventTypeWarning,
+			"NodeProviderIDMismatch",
+			"Node %s has the provider ID %s, expected %s",
+			node.Name,
+			node.Spec.ProviderID,
+			providerID,
+		)
+		return reconcile.Result{}, nil
+	}
+
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	region, zone := machine.NodeTopology()
+	for key, value := range map[string]string{
+		corev1.LabelZoneRegionStable:        region,
+		corev1.LabelZoneFailureDomainStable: zone,
+		corev1.LabelInstanceTypeStable:      machine.NodeInstanceType(),
+	} {
+		if _, ok := node.Labels[key]; !ok && value != "" {
+			node.Labels[key] = value
+		}
+	}
+
+	// the addresses are reported by the cloud controller manager, if one is
+	// running, so they are only set for nodes which remain uninitialized
+	var result reconcile.Result
+	initialize := false
+	if hasTaint(node, taintExternalCloudProvider) {
+		if wait := nodeInitializationGracePeriod - time.Since(node.CreationTimestamp.Time); wait > 0 {
+			result.RequeueAfter = wait
+		} else {
+			initialize = true
+		}
+	}
+	if initialize {
+		taints := make([]corev1.Taint, 0, len(node.Spec.Taints))
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != taintExternalCloudProvider {
+				taints = append(taints, taint)
+			}
+		}
+		node.Spec.Taints = taints
+
+		addresses := make([]corev1.NodeAddress, 0, len(machine.NodeAddresses())+1)
+		addresses = append(addresses, machine.NodeAddresses()...)
+		node.Status.Addresses = append(addresses, corev1.NodeAddress{Type: corev1.NodeHostName, Address: node.Name})
+	}
+
+	if err := patchHelper.Patch(ctx, node); err != nil {
+		log.Error(err, "failed to patch node")
+		return reconcile.Result{}, err
+	}
+
+	if initialize {
+		r.mCluster.ObjectEventf(
+			machine,
+			corev1.EventTypeNormal,
+			"NodeInitialized",
+			"Initialized node %s with provider ID %s",
+			node.Name,
+			providerID,
+		)
+	}
+
+	return result, nil
+}
+
+// reconcileMachineStatus reports the registration and readiness of the node
+// as well as its kubelet version on the machine
+func (r *GuestNodeReconciler) reconcileMachineStatus(ctx context.Context, machine infraMachine, node *corev1.Node) error {
+	patchHelper, err := patch.NewHelper(machine, r.mCluster)
+	if err != nil {
+		return err
+	}
+
+	if node == nil {
+		if conditions.Has(machine, infrav1.NodeRegisteredCondition) {
+			conditions.MarkFalse(machine, infrav1.NodeRegisteredCondition, infrav1.NodeNotFoundReason, clusterv1.ConditionSeverityWarning, "")
+			conditions.MarkFalse(machine, infrav1.NodeReadyCondition, infrav1.NodeNotFoundReason, clusterv1.ConditionSeverityWarning, "")
+		}
+	} else {
+		conditions.MarkTrue(machine, infrav1.NodeRegisteredCondition)
+
+		ready := nodeCondition(node, corev1.NodeReady)
+		switch {
+		case ready != nil && ready.Status == corev1.ConditionTrue:
+			conditions.MarkTrue(machine, infrav1.NodeReadyCondition)
+		case ready != nil:
+			conditions.MarkFalse(machine, infrav1.NodeReadyCondition, infrav1.NodeNotReadyReason, clusterv1.ConditionSeverityWarning, "%s: %s", ready.Reason, ready.Message)
+		default:
+			conditions.MarkFalse(machine, infrav1.NodeReadyCondition, infrav1.NodeNotReadyReason, clusterv1.ConditionSeverityInfo, "node has not reported readiness yet")
+		}
+
+		setKubeletVersion(machine, node.Status.NodeInfo.KubeletVersion)
+	}
+
+	return patchHelper.Patch(ctx, machine, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+		infrav1.NodeRegisteredCondition,
+		infrav1.NodeReadyCondition,
+	}})
+}
+
+func setKubeletVersion(machine infraMachine, version string) {
+	switch m := machine.(type) {
+	case *infrav1.HcloudMachine:
+		m.Status.KubeletVersion = version
+	case *infrav1.BareMetalMachine:
+		m.Status.KubeletVersion = version
+	}
+}
+
+func nodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for pos := range node.Status.Conditions {
+		if node.Status.Conditions[pos].Type == conditionType {
+			return &node.Status.Conditions[pos]
+		}
+	}
+	return nil
+}
+
+func hasTaint(node *corev1.Node, key string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *GuestNodeReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&corev1.Node{}).
+		Complete(r)
+}
@@ -0,0 +1,182 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+func TestReconcileMachineStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		newMachine func() infraMachine
+	}{
+		{name: "HcloudMachine", newMachine: func() infraMachine { return &infrav1.HcloudMachine{} }},
+		{name: "BareMetalMachine", newMachine: func() infraMachine { return &infrav1.BareMetalMachine{} }},
+	} {
+		machine := tc.newMachine()
+		machine.SetNamespace("default")
+		machine.SetName("worker-1")
+		machine.SetResourceVersion("1")
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			hcloudCluster := &infrav1.HcloudCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			}
+			mClient := newTestClient(hcloudCluster, machine)
+			r := &GuestNodeReconciler{
+				Log: klogr.New(),
+				mCluster: &managementCluster{
+					Client:        mClient,
+					hcloudCluster: hcloudCluster,
+					recorder:      record.NewFakeRecorder(10),
+				},
+			}
+
+			for _, step := range []struct {
+				name           string
+				node           *corev1.Node
+				registered     corev1.ConditionStatus
+				ready          corev1.ConditionStatus
+				reason         string
+				severity       clusterv1.ConditionSeverity
+				kubeletVersion string
+			}{
+				{
+					name: "node not registered yet",
+				},
+				{
+					name:           "node without readiness",
+					node:           newTestNode("v1.19.4"),
+					registered:     corev1.ConditionTrue,
+					ready:          corev1.ConditionFalse,
+					reason:         infrav1.NodeNotReadyReason,
+					severity:       clusterv1.ConditionSeverityInfo,
+					kubeletVersion: "v1.19.4",
+				},
+				{
+					name:           "node not ready",
+					node:           newTestNode("v1.19.4", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Reason: "KubeletNotReady", Message: "cni not initialized"}),
+					registered:     corev1.ConditionTrue,
+					ready:          corev1.ConditionFalse,
+					reason:         infrav1.NodeNotReadyReason,
+					severity:       clusterv1.ConditionSeverityWarning,
+					kubeletVersion: "v1.19.4",
+				},
+				{
+					name:           "node ready",
+					node:           newTestNode("v1.19.4", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}),
+					registered:     corev1.ConditionTrue,
+					ready:          corev1.ConditionTrue,
+					kubeletVersion: "v1.19.4",
+				},
+				{
+					name:           "kubelet upgraded",
+					node:           newTestNode("v1.20.1", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}),
+					registered:     corev1.ConditionTrue,
+					ready:          corev1.ConditionTrue,
+					kubeletVersion: "v1.20.1",
+				},
+				{
+					name:           "node deleted",
+					registered:     corev1.ConditionFalse,
+					ready:          corev1.ConditionFalse,
+					reason:         infrav1.NodeNotFoundReason,
+					severity:       clusterv1.ConditionSeverityWarning,
+					kubeletVersion: "v1.20.1",
+				},
+			} {
+				if err := r.reconcileMachineStatus(ctx, machine, step.node); err != nil {
+					t.Fatalf("%s: unexpected error: %v", step.name, err)
+				}
+
+				// the status is persisted on the machine
+				key, _ := controllerclient.ObjectKeyFromObject(machine)
+				persisted := tc.newMachine()
+				if err := mClient.Get(ctx, key, persisted); err != nil {
+					t.Fatalf("%s: unexpected error: %v", step.name, err)
+				}
+
+				assertCondition(t, step.name, persisted, infrav1.NodeRegisteredCondition, step.registered, step.reason, step.severity)
+				assertCondition(t, step.name, persisted, infrav1.NodeReadyCondition, step.ready, step.reason, step.severity)
+				if version := kubeletVersion(persisted); version != step.kubeletVersion {
+					t.Errorf("%s: expected kubelet version %q, got %q", step.name, step.kubeletVersion, version)
+				}
+			}
+		})
+	}
+}
+
+func newTestNode(kubeletVersion string, nodeConditions ...corev1.NodeCondition) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Status: corev1.NodeStatus{
+			Conditions: nodeConditions,
+			NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: kubeletVersion},
+		},
+	}
+}
+
+// assertCondition checks the condition of the machine, an empty status
+// expects the condition to be unset and the reason and severity are only
+// checked for false conditions
+func assertCondition(t *testing.T, step string, machine infraMachine, conditionType clusterv1.ConditionType, status corev1.ConditionStatus, reason string, severity clusterv1.ConditionSeverity) {
+	t.Helper()
+	condition := conditions.Get(machine, conditionType)
+	if status == "" {
+		if condition != nil {
+			t.Errorf("%s: expected condition %s to be unset, got %v", step, conditionType, condition)
+		}
+		return
+	}
+	if condition == nil {
+		t.Errorf("%s: expected condition %s to be set", step, conditionType)
+		return
+	}
+	if condition.Status != status {
+		t.Errorf("%s: expected condition %s to be %s, got %s", step, conditionType, status, condition.Status)
+	}
+	if status != corev1.ConditionFalse {
+		return
+	}
+	if condition.Reason != reason {
+		t.Errorf("%s: expected condition %s with reason %s, got %s", step, conditionType, reason, condition.Reason)
+	}
+	if condition.Severity != severity {
+		t.Errorf("%s: expected condition %s with severity %s, got %s", step, conditionType, severity, condition.Severity)
+	}
+}
+
+func kubeletVersion(machine infraMachine) string {
+	switch m := machine.(type) {
+	case *infrav1.HcloudMachine:
+		return m.Status.KubeletVersion
+	case *infrav1.BareMetalMachine:
+		return m.Status.KubeletVersion
+	}
+	return ""
+}
@@ -0,0 +1,492 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util"
+	ctrl "sigs.k8s.io/controller-runtime"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// orphanedResources reports the orphaned resources found by the last garbage
+// collection in the project of a cluster
+var orphanedResources = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "capi_hcloud_orphaned_resources",
+		Help: "Number of orphaned Hetzner Cloud resources found by the last garbage collection by cluster and resource",
+	},
+	[]string{"cluster", "resource"},
+)
+
+// orphanedResourcesDeletedTotal counts the orphaned resources deleted after
+// their grace period
+var orphanedResourcesDeletedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "capi_hcloud_orphaned_resources_deleted_total",
+		Help: "Number of orphaned Hetzner Cloud resources deleted by cluster and resource",
+	},
+	[]string{"cluster", "resource"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedResources, orphanedResourcesDeletedTotal)
+}
+
+const (
+	orphanServer       = "server"
+	orphanLoadBalancer = "load_balancer"
+	orphanNetwork      = "network"
+	orphanVolume       = "volume"
+)
+
+// HcloudGarbageCollector periodically deletes the resources in the projects
+// of the HcloudClusters, which are labelled as owned by a cluster, but are not
+// referenced by any HcloudCluster, HcloudMachine or HcloudVolume anymore. An
+// orphan is only deleted once it has been orphaned for the grace period, in
+// dry run mode orphans are only reported.
+type HcloudGarbageCollector struct {
+	controllerclient.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Packer    *packer.Packer
+	Manifests *manifests.Manifests
+	Recorder  record.EventRecorder
+
+	// Interval between the garbage collections of a project
+	Interval time.Duration
+	// GracePeriod a resource has to be orphaned for, before it is deleted
+	GracePeriod time.Duration
+	// DryRun only reports orphans without deleting them
+	DryRun bool
+
+	lock sync.Mutex
+	// lastCollected is the time of the last garbage collection by project
+	lastCollected map[string]time.Time
+	// orphanedSince is the time an orphan has been found first by project
+	// and resource
+	orphanedSince map[string]time.Time
+}
+
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudvolumes,verbs=get;list;watch
+
+func (r *HcloudGarbageCollector) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.TODO()
+	log := r.Log.WithValues("namespace", req.Namespace, "hcloudCluster", req.Name)
+
+	hcloudCluster := &infrav1.HcloudCluster{}
+	if err := r.Get(ctx, req.NamespacedName, hcloudCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !hcloudCluster.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, hcloudCluster.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		return reconcile.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+		Ctx:           ctx,
+		Client:        r.Client,
+		Logger:        log,
+		Recorder:      r.Recorder,
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        r.Packer,
+		Manifests:     r.Manifests,
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
+	}
+
+	// clusters sharing a project are collected once per interval
+	project := fmt.Sprintf("%x", sha256.Sum256([]byte(clusterScope.HcloudClient().Token())))
+	r.lock.Lock()
+	if r.lastCollected == nil {
+		r.lastCollected = make(map[string]time.Time)
+	}
+	if wait := r.Interval - time.Since(r.lastCollected[project]); wait > 0 {
+		r.lock.Unlock()
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+	r.lastCollected[project] = time.Now()
+	r.lock.Unlock()
+
+	if err := r.collect(ctx, clusterScope, project); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to collect orphaned resources")
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
+
+// orphan is a resource, which is not referenced anymore
+type orphan struct {
+	resource string
+	id       int
+	name     string
+	delete   func(ctx context.Context) error
+}
+
+func (o *orphan) key(project string) string {
+	return fmt.Sprintf("%s/%s/%d", project, o.resource, o.id)
+}
+
+// collect finds the orphans in the project of the cluster and deletes the ones,
+// whose grace period has expired
+func (r *HcloudGarbageCollector) collect(ctx context.Context, clusterScope *scope.ClusterScope, project string) error {
+	refs, err := r.listReferences(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := findOrphans(ctx, clusterScope.HcloudClient(), refs)
+	if err != nil {
+		return err
+	}
+
+	clusterName := fmt.Sprintf("%s/%s", clusterScope.HcloudCluster.Namespace, clusterScope.HcloudCluster.Name)
+	counts := map[string]float64{orphanServer: 0, orphanLoadBalancer: 0, orphanNetwork: 0, orphanVolume: 0}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.orphanedSince == nil {
+		r.orphanedSince = make(map[string]time.Time)
+	}
+
+	found := make(map[string]bool, len(orphans))
+	var errs []error
+	for _, o := range orphans {
+		counts[o.resource]++
+		key := o.key(project)
+		found[key] = true
+
+		since, ok := r.orphanedSince[key]
+		if !ok {
+			since = time.Now()
+			r.orphanedSince[key] = since
+			r.Recorder.Eventf(
+				clusterScope.HcloudCluster,
+				corev1.EventTypeWarning,
+				"OrphanedResourceFound",
+				"Found orphaned %s %s with id %d",
+				o.resource,
+				o.name,
+				o.id,
+			)
+		}
+
+		if r.DryRun || time.Since(since) < r.GracePeriod {
+			continue
+		}
+
+		if err := o.delete(ctx); err != nil && !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete orphaned %s %d", o.resource, o.id))
+			continue
+		}
+		delete(r.orphanedSince, key)
+		orphanedResourcesDeletedTotal.WithLabelValues(clusterName, o.resource).Inc()
+		r.Recorder.Eventf(
+			clusterScope.HcloudCluster,
+			corev1.EventTypeNormal,
+			"OrphanedResourceDeleted",
+			"Deleted orphaned %s %s with id %d",
+			o.resource,
+			o.name,
+			o.id,
+		)
+	}
+
+	// forget resources, which are not orphaned anymore
+	for key := range r.orphanedSince {
+		if strings.HasPrefix(key, project+"/") && !found[key] {
+			delete(r.orphanedSince, key)
+		}
+	}
+
+	for resource, count := range counts {
+		orphanedResources.WithLabelValues(clusterName, resource).Set(count)
+	}
+
+	return errorutil.NewAggregate(errs)
+}
+
+// gcReferences are the resources referenced by the objects of the management
+// cluster
+type gcReferences struct {
+	clusters      map[string]bool
+	machines      map[string]bool
+	loadBalancers map[int]bool
+	networks      map[int]bool
+	volumes       map[int]bool
+	// adoptedServers are referenced by HcloudMachines, which have not
+	// adopted them yet
+	adoptedServers map[int]bool
+
+	// clusters, whose load balancer or network has been recorded in their
+	// status already, for other clusters it might be being created
+	clustersWithLoadBalancer map[string]bool
+	clustersWithNetwork      map[string]bool
+}
+
+func (r *HcloudGarbageCollector) listReferences(ctx context.Context) (*gcReferences, error) {
+	refs := &gcReferences{
+		clusters:                 make(map[string]bool),
+		machines:                 make(map[string]bool),
+		loadBalancers:            make(map[int]bool),
+		networks:                 make(map[int]bool),
+		volumes:                  make(map[int]bool),
+		adoptedServers:           make(map[int]bool),
+		clustersWithLoadBalancer: make(map[string]bool),
+		clustersWithNetwork:      make(map[string]bool),
+	}
+
+	var hcloudClusters infrav1.HcloudClusterList
+	if err := r.List(ctx, &hcloudClusters); err != nil {
+		return nil, errors.Wrap(err, "failed to list HcloudClusters")
+	}
+	for pos := range hcloudClusters.Items {
+		c := &hcloudClusters.Items[pos]
+		// legacy resources are only labelled with the name of the cluster
+		keys := []string{c.Name, clusterKey(c.Namespace, c.Name, c.ResourceUID())}
+		for _, key := range keys {
+			refs.clusters[key] = true
+		}
+		if id := c.Status.ControlPlaneLoadBalancer.ID; id != 0 {
+			refs.loadBalancers[id] = true
+			for _, key := range keys {
+				refs.clustersWithLoadBalancer[key] = true
+			}
+		}
+		if n := c.Status.Network; n != nil && n.ID != 0 {
+			refs.networks[n.ID] = true
+			for _, key := range keys {
+				refs.clustersWithNetwork[key] = true
+			}
+		}
+	}
+
+	var hcloudMachines infrav1.HcloudMachineList
+	if err := r.List(ctx, &hcloudMachines); err != nil {
+		return nil, errors.Wrap(err, "failed to list HcloudMachines")
+	}
+	for _, m := range hcloudMachines.Items {
+		refs.machines[m.Name] = true
+		refs.machines[m.Namespace+"/"+m.Name] = true
+		if a := m.Spec.Adopt; a != nil {
+			refs.adoptedServers[a.ServerID] = true
+		}
+	}
+
+	var hcloudVolumes infrav1.HcloudVolumeList
+	if err := r.List(ctx, &hcloudVolumes); err != nil {
+		return nil, errors.Wrap(err, "failed to list HcloudVolumes")
+	}
+	for _, v := range hcloudVolumes.Items {
+		if id := v.Status.VolumeID; id != nil {
+			refs.volumes[int(*id)] = true
+		}
+	}
+
+	return refs, nil
+}
+
+// clusterKey identifies a cluster by its namespace, name and resource UID
+func clusterKey(namespace, name, uid string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, name, uid)
+}
+
+// ownerCluster returns the key of the cluster, which owns a resource
+// according to its labels. Legacy resources, which are not labelled with the
+// namespace of the cluster, are keyed by the name of the cluster.
+func ownerCluster(labels map[string]string) (string, bool) {
+	for key, value := range labels {
+		if strings.HasPrefix(key, infrav1.NameHcloudProviderOwned) && infrav1.ResourceLifecycle(value) == infrav1.ResourceLifecycleOwned {
+			name := strings.TrimPrefix(key, infrav1.NameHcloudProviderOwned)
+			if namespace, ok := labels[infrav1.ClusterNamespaceTagKey]; ok {
+				return clusterKey(namespace, name, labels[infrav1.ClusterUIDTagKey]), true
+			}
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (refs *gcReferences) orphanedServer(id int, labels map[string]string) bool {
+	cluster, ok := ownerCluster(labels)
+	if !ok || refs.adoptedServers[id] {
+		return false
+	}
+	if !refs.clusters[cluster] {
+		return true
+	}
+	machine, ok := labels[infrav1.MachineNameTagKey]
+	if namespace, namespaced := labels[infrav1.ClusterNamespaceTagKey]; namespaced {
+		machine = namespace + "/" + machine
+	}
+	return ok && !refs.machines[machine]
+}
+
+func (refs *gcReferences) orphanedLoadBalancer(id int, labels map[string]string) bool {
+	cluster, ok := ownerCluster(labels)
+	if !ok {
+		return false
+	}
+	return !refs.clusters[cluster] || (refs.clustersWithLoadBalancer[cluster] && !refs.loadBalancers[id])
+}
+
+func (refs *gcReferences) orphanedNetwork(id int, labels map[string]string) bool {
+	cluster, ok := ownerCluster(labels)
+	if !ok {
+		return false
+	}
+	return !refs.clusters[cluster] || (refs.clustersWithNetwork[cluster] && !refs.networks[id])
+}
+
+func (refs *gcReferences) orphanedVolume(id int, labels map[string]string) bool {
+	cluster, ok := ownerCluster(labels)
+	if !ok {
+		return false
+	}
+	return !refs.clusters[cluster] || !refs.volumes[id]
+}
+
+// findOrphans lists the resources of the project and returns the orphans
+func findOrphans(ctx context.Context, hc scope.HcloudClient, refs *gcReferences) ([]*orphan, error) {
+	var orphans []*orphan
+
+	servers, err := hc.ListServers(ctx, hcloud.ServerListOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list servers")
+	}
+	for _, server := range servers {
+		if !refs.orphanedServer(server.ID, server.Labels) {
+			continue
+		}
+		server := server
+		orphans = append(orphans, &orphan{
+			resource: orphanServer,
+			id:       server.ID,
+			name:     server.Name,
+			delete: func(ctx context.Context) error {
+				_, err := hc.DeleteServer(ctx, server)
+				return err
+			},
+		})
+	}
+
+	loadBalancers, err := hc.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list load balancers")
+	}
+	for _, lb := range loadBalancers {
+		if !refs.orphanedLoadBalancer(lb.ID, lb.Labels) {
+			continue
+		}
+		lb := lb
+		orphans = append(orphans, &orphan{
+			resource: orphanLoadBalancer,
+			id:       lb.ID,
+			name:     lb.Name,
+			delete: func(ctx context.Context) error {
+				_, err := hc.DeleteLoadBalancer(ctx, lb)
+				return err
+			},
+		})
+	}
+
+	networks, err := hc.ListNetworks(ctx, hcloud.NetworkListOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list networks")
+	}
+	for _, network := range networks {
+		if !refs.orphanedNetwork(network.ID, network.Labels) {
+			continue
+		}
+		network := network
+		orphans = append(orphans, &orphan{
+			resource: orphanNetwork,
+			id:       network.ID,
+			name:     network.Name,
+			delete: func(ctx context.Context) error {
+				_, err := hc.DeleteNetwork(ctx, network)
+				return err
+			},
+		})
+	}
+
+	volumes, err := hc.ListVolumes(ctx, hcloud.VolumeListOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list volumes")
+	}
+	for _, volume := range volumes {
+		if !refs.orphanedVolume(volume.ID, volume.Labels) {
+			continue
+		}
+		volume := volume
+		orphans = append(orphans, &orphan{
+			resource: orphanVolume,
+			id:       volume.ID,
+			name:     volume.Name,
+			delete: func(ctx context.Context) error {
+				_, err := hc.DeleteVolume(ctx, volume)
+				return err
+			},
+		})
+	}
+
+	return orphans, nil
+}
+
+func (r *HcloudGarbageCollector) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("hcloudgarbagecollector").
+		WithOptions(options).
+		For(&infrav1.HcloudCluster{}).
+		Complete(r)
+}
@@ -0,0 +1,390 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func gcLabels(cluster, namespace, uid string) map[string]string {
+	labels := map[string]string{
+		infrav1.ClusterTagKey(cluster): string(infrav1.ResourceLifecycleOwned),
+	}
+	if namespace != "" {
+		labels[infrav1.ClusterNamespaceTagKey] = namespace
+		labels[infrav1.ClusterUIDTagKey] = uid
+	}
+	return labels
+}
+
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	labels[key] = value
+	return labels
+}
+
+func TestOwnerCluster(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		labels   map[string]string
+		expected string
+		owned    bool
+	}{
+		{
+			name:     "namespaced labels",
+			labels:   gcLabels("test", "default", "1234"),
+			expected: "default/test/1234",
+			owned:    true,
+		},
+		{
+			name:     "legacy labels",
+			labels:   gcLabels("test", "", ""),
+			expected: "test",
+			owned:    true,
+		},
+		{
+			name:   "shared resource",
+			labels: map[string]string{infrav1.ClusterTagKey("test"): string(infrav1.ResourceLifecycleShared)},
+		},
+		{
+			name:   "foreign resource",
+			labels: map[string]string{"team": "a"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, owned := ownerCluster(tc.labels)
+			if actual != tc.expected || owned != tc.owned {
+				t.Errorf("expected %q, %v, got %q, %v", tc.expected, tc.owned, actual, owned)
+			}
+		})
+	}
+}
+
+// newTestReferences references the cluster default/test with the load
+// balancer 10 and the network 20, its machine default/test-worker, the volume
+// 30 and the adopted server 40
+func newTestReferences() *gcReferences {
+	return &gcReferences{
+		clusters:                 map[string]bool{"test": true, "default/test/1234": true},
+		machines:                 map[string]bool{"test-worker": true, "default/test-worker": true},
+		loadBalancers:            map[int]bool{10: true},
+		networks:                 map[int]bool{20: true},
+		volumes:                  map[int]bool{30: true},
+		adoptedServers:           map[int]bool{40: true},
+		clustersWithLoadBalancer: map[string]bool{"test": true, "default/test/1234": true},
+		clustersWithNetwork:      map[string]bool{"test": true, "default/test/1234": true},
+	}
+}
+
+func TestGCReferences_OrphanedServer(t *testing.T) {
+	refs := newTestReferences()
+	for _, tc := range []struct {
+		name     string
+		id       int
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:   "referenced machine",
+			id:     1,
+			labels: withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-worker"),
+		},
+		{
+			name:   "referenced machine with legacy labels",
+			id:     1,
+			labels: withLabel(gcLabels("test", "", ""), infrav1.MachineNameTagKey, "test-worker"),
+		},
+		{
+			name:     "deleted machine",
+			id:       1,
+			labels:   withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-deleted"),
+			expected: true,
+		},
+		{
+			name:     "machine of another namespace",
+			id:       1,
+			labels:   withLabel(gcLabels("test", "other", "1234"), infrav1.MachineNameTagKey, "test-worker"),
+			expected: true,
+		},
+		{
+			name:     "deleted cluster",
+			id:       1,
+			labels:   withLabel(gcLabels("test", "default", "5678"), infrav1.MachineNameTagKey, "test-worker"),
+			expected: true,
+		},
+		{
+			name:   "server to be adopted",
+			id:     40,
+			labels: withLabel(gcLabels("test", "default", "5678"), infrav1.MachineNameTagKey, "test-deleted"),
+		},
+		{
+			name:   "foreign server",
+			id:     1,
+			labels: map[string]string{"team": "a"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := refs.orphanedServer(tc.id, tc.labels); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGCReferences_OrphanedLoadBalancerAndNetwork(t *testing.T) {
+	refs := newTestReferences()
+	// the load balancer and network of default/other/9999 are being created
+	refs.clusters["default/other/9999"] = true
+
+	for _, tc := range []struct {
+		name     string
+		id       int
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:   "referenced",
+			labels: gcLabels("test", "default", "1234"),
+		},
+		{
+			name:     "not referenced by its cluster",
+			id:       1,
+			labels:   gcLabels("test", "default", "1234"),
+			expected: true,
+		},
+		{
+			name:     "deleted cluster",
+			labels:   gcLabels("test", "default", "5678"),
+			expected: true,
+		},
+		{
+			name:   "cluster without status",
+			id:     1,
+			labels: gcLabels("other", "default", "9999"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := refs.orphanedLoadBalancer(tc.id+10, tc.labels); actual != tc.expected {
+				t.Errorf("load balancer: expected %v, got %v", tc.expected, actual)
+			}
+			if actual := refs.orphanedNetwork(tc.id+20, tc.labels); actual != tc.expected {
+				t.Errorf("network: expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGCReferences_OrphanedVolume(t *testing.T) {
+	refs := newTestReferences()
+	for _, tc := range []struct {
+		name     string
+		id       int
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:   "referenced",
+			id:     30,
+			labels: gcLabels("test", "default", "1234"),
+		},
+		{
+			name:     "not referenced",
+			id:       31,
+			labels:   gcLabels("test", "default", "1234"),
+			expected: true,
+		},
+		{
+			name:     "deleted cluster",
+			id:       30,
+			labels:   gcLabels("test", "default", "5678"),
+			expected: true,
+		},
+		{
+			name: "foreign volume",
+			id:   31,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := refs.orphanedVolume(tc.id, tc.labels); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	labels := gcLabels("test", "default", "1234")
+	hc := mock_scope.NewMockHcloudClient(mockCtrl)
+	hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return([]*hcloud.Server{
+		{ID: 1, Labels: withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-worker")},
+		{ID: 2, Labels: withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-deleted")},
+	}, nil)
+	hc.EXPECT().ListLoadBalancers(gomock.Any(), gomock.Any()).Return([]*hcloud.LoadBalancer{
+		{ID: 10, Labels: labels},
+		{ID: 11, Labels: labels},
+	}, nil)
+	hc.EXPECT().ListNetworks(gomock.Any(), gomock.Any()).Return([]*hcloud.Network{
+		{ID: 20, Labels: labels},
+		{ID: 21, Labels: labels},
+	}, nil)
+	hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return([]*hcloud.Volume{
+		{ID: 30, Labels: labels},
+		{ID: 31, Labels: labels},
+	}, nil)
+
+	orphans, err := findOrphans(context.TODO(), hc, newTestReferences())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actual []string
+	for _, o := range orphans {
+		actual = append(actual, o.key("project"))
+	}
+	expected := []string{
+		"project/server/2",
+		"project/load_balancer/11",
+		"project/network/21",
+		"project/volume/31",
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected orphans %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected orphans %v, got %v", expected, actual)
+			break
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234"},
+	}
+	adoptingMachine := &infrav1.HcloudMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-adopting"},
+		Spec: infrav1.HcloudMachineSpec{
+			Adopt: &infrav1.HcloudMachineAdoption{ServerID: 2},
+		},
+	}
+	orphan := &hcloud.Server{
+		ID:     1,
+		Name:   "orphan",
+		Labels: withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-deleted"),
+	}
+	adopted := &hcloud.Server{
+		ID:     2,
+		Name:   "adopted",
+		Labels: withLabel(gcLabels("test", "default", "1234"), infrav1.MachineNameTagKey, "test-deleted"),
+	}
+
+	for _, tc := range []struct {
+		name        string
+		gracePeriod time.Duration
+		dryRun      bool
+		// orphanedFor is the time the orphan has been found before
+		orphanedFor time.Duration
+		deleted     bool
+		events      []string
+	}{
+		{
+			name:        "found orphan",
+			gracePeriod: time.Hour,
+			events:      []string{"Warning OrphanedResourceFound Found orphaned server orphan with id 1"},
+		},
+		{
+			name:        "grace period not expired",
+			gracePeriod: time.Hour,
+			orphanedFor: time.Minute,
+		},
+		{
+			name:        "grace period expired",
+			gracePeriod: time.Hour,
+			orphanedFor: 2 * time.Hour,
+			deleted:     true,
+			events:      []string{"Normal OrphanedResourceDeleted Deleted orphaned server orphan with id 1"},
+		},
+		{
+			name:        "dry run",
+			gracePeriod: time.Hour,
+			dryRun:      true,
+			orphanedFor: 2 * time.Hour,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c := newTestClient(cluster, hcloudCluster, adoptingMachine)
+			hc := mock_scope.NewMockHcloudClient(mockCtrl)
+			hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return([]*hcloud.Server{orphan, adopted}, nil)
+			hc.EXPECT().ListLoadBalancers(gomock.Any(), gomock.Any()).Return(nil, nil)
+			hc.EXPECT().ListNetworks(gomock.Any(), gomock.Any()).Return(nil, nil)
+			hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return(nil, nil)
+			if tc.deleted {
+				hc.EXPECT().DeleteServer(gomock.Any(), orphan).Return(nil, nil)
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			r := &HcloudGarbageCollector{
+				Client:        c,
+				Recorder:      recorder,
+				GracePeriod:   tc.gracePeriod,
+				DryRun:        tc.dryRun,
+				orphanedSince: make(map[string]time.Time),
+			}
+			if tc.orphanedFor > 0 {
+				r.orphanedSince["project/server/1"] = time.Now().Add(-tc.orphanedFor)
+			}
+
+			clusterScope := newTestClusterScope(t, mockCtrl, c, hc, cluster, hcloudCluster)
+			if err := r.collect(context.TODO(), clusterScope, "project"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expected := range tc.events {
+				if actual := <-recorder.Events; actual != expected {
+					t.Errorf("expected event %q, got %q", expected, actual)
+				}
+			}
+			if len(recorder.Events) > 0 {
+				t.Errorf("unexpected event %q", <-recorder.Events)
+			}
+			if _, found := r.orphanedSince["project/server/1"]; found == tc.deleted {
+				t.Errorf("expected orphan to be tracked %v, got %v", !tc.deleted, found)
+			}
+			if _, found := r.orphanedSince["project/server/2"]; found {
+				t.Errorf("adopted server is tracked as orphan")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	manifestsapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// reconcileAddons applies every addon selected in the spec independently, so
+// a changed addon doesn't reapply the others. Addons removed from the spec are
+// pruned from the workload cluster.
+func (r *HcloudClusterReconciler) reconcileAddons(clusterScope *scope.ClusterScope) error {
+	hcloudCluster := clusterScope.HcloudCluster
+
+	if hcloudCluster.Status.Manifests == nil {
+		hcloudCluster.Status.Manifests = &infrav1.HcloudClusterStatusManifests{}
+	}
+	status := hcloudCluster.Status.Manifests
+
+	// only look for an API server, if there is something to apply
+	var clientConfig clientcmd.ClientConfig
+	getClientConfig := func() (clientcmd.ClientConfig, error) {
+		if clientConfig != nil {
+			return clientConfig, nil
+		}
+		c, err := r.readyClientConfig(clusterScope)
+		if err != nil {
+			return nil, err
+		}
+		clientConfig = c
+		return clientConfig, nil
+	}
+
+	for _, addonType := range infrav1.HcloudClusterAddonTypes {
+		addon := hcloudCluster.Spec.Addons.Get(addonType)
+		current := status.Addon(addonType)
+
+		if addon == nil {
+			if current == nil {
+				continue
+			}
+
+			c, err := getClientConfig()
+			if err != nil {
+				return err
+			}
+			remaining, err := r.pruneManifests(clusterScope, c, current.AppliedObjects, nil)
+			if err != nil {
+				current.AppliedObjects = remaining
+				return errors.Wrapf(err, "failed to remove %s addon %s", addonType, current.Name)
+			}
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeNormal,
+				"AddonRemoved",
+				"The %s addon %s has been removed",
+				addonType,
+				current.Name,
+			)
+			status.RemoveAddon(addonType)
+			continue
+		}
+
+		ref := manifestsapi.Addon{Name: addon.Name, Version: addon.Version}
+		expectedHash, err := clusterScope.AddonHash(ref)
+		if err != nil {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeWarning,
+				"InvalidAddon",
+				"The %s addon %s can not be rendered: %s",
+				addonType,
+				ref,
+				err,
+			)
+			return errors.Wrapf(err, "failed to render %s addon %s", addonType, ref)
+		}
+
+		if current != nil &&
+			current.Name == addon.Name &&
+			current.Version == addon.Version &&
+			current.AppliedHash != nil &&
+			*current.AppliedHash == expectedHash {
+			continue
+		}
+
+		c, err := getClientConfig()
+		if err != nil {
+			return err
+		}
+
+		results, err := clusterScope.ApplyAddonWithClientConfig(clusterScope.Ctx, c, ref)
+		var applyErrs manifestsapi.ApplyErrors
+		if errors.As(err, &applyErrs) {
+			for _, applyErr := range applyErrs {
+				r.Recorder.Eventf(
+					hcloudCluster,
+					corev1.EventTypeWarning,
+					"FailedApplyAddon",
+					"Failed to apply %s of %s addon %s (%s): %s",
+					applyErr.Object,
+					addonType,
+					ref,
+					applyErr.Reason,
+					applyErr.Err,
+				)
+			}
+			return errors.Errorf("failed to apply %d of %d objects of %s addon %s", len(applyErrs), len(results), addonType, ref)
+		} else if err != nil {
+			return errors.Wrapf(err, "error applying %s addon %s", addonType, ref)
+		}
+
+		var previous []infrav1.HcloudClusterManifestObject
+		var previousHash string
+		if current != nil {
+			previous = current.AppliedObjects
+			if current.AppliedHash != nil {
+				previousHash = *current.AppliedHash
+			}
+		}
+
+		addonStatus := infrav1.HcloudClusterAddonStatus{
+			Type:    addonType,
+			Name:    addon.Name,
+			Version: addon.Version,
+		}
+		addonStatus.AppliedObjects, err = r.pruneManifests(clusterScope, c, previous, results)
+		if err != nil {
+			status.SetAddon(addonStatus)
+			return err
+		}
+		addonStatus.AppliedHash = &expectedHash
+		status.SetAddon(addonStatus)
+
+		if previousHash == "" {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeNormal,
+				"AddonApplied",
+				"The %s addon %s (hash=%s) has been successfully applied",
+				addonType,
+				ref,
+				expectedHash,
+			)
+		} else {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeNormal,
+				"AddonApplied",
+				"The %s addon %s (hash=%s) has been successfully applied to update the existing (hash=%s)",
+				addonType,
+				ref,
+				expectedHash,
+				previousHash,
+			)
+		}
+	}
+
+	return nil
+}
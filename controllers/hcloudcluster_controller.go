@@ -26,22 +26,20 @@ import (
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	certificatesv1 "k8s.io/api/certificates/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/client-go/kubernetes"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
-	recorder "k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
 	ctrl "sigs.k8s.io/controller-runtime"
 	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -50,6 +48,7 @@ import (
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/location"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/network"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests"
+	manifestsapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
 )
@@ -65,14 +64,35 @@ type HcloudClusterReconciler struct {
 	Manifests *manifests.Manifests
 	Recorder  record.EventRecorder
 
-	targetClusterManagersStopCh map[types.NamespacedName]chan struct{}
-	targetClusterManagersLock   sync.Mutex
+	// RequireSeparateWorkloadCredentials refuses to pass the credentials of
+	// the controller to the manifests of the workload clusters
+	RequireSeparateWorkloadCredentials bool
+
+	// CSRDNSSuffixes are allowed to be appended to the node name in the DNS
+	// names of kubelet serving certificates
+	CSRDNSSuffixes []string
+
+	// CSRReportOnly records the decisions about kubelet serving certificates
+	// without approving or denying them
+	CSRReportOnly bool
+
+	targetClusterManagers     map[types.NamespacedName]*targetClusterManager
+	targetClusterManagersLock sync.Mutex
+	targetClusterEvents       chan event.GenericEvent
+
+	// labelsMigrated records the clusters, whose legacy resources have been
+	// relabelled, by the UID of their resources
+	labelsMigrated     map[types.UID]bool
+	labelsMigratedLock sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudclusters/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=addons.cluster.x-k8s.io,resources=clusterresourcesets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=addons.cluster.x-k8s.io,resources=clusterresourcesetbindings,verbs=get;list;watch
 
 func (r *HcloudClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.TODO()
@@ -115,6 +135,8 @@ func (r *HcloudClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 		Packer:        r.Packer,
 		Manifests:     r.Manifests,
 		Recorder:      r.Recorder,
+
+		RequireSeparateWorkloadCredentials: r.RequireSeparateWorkloadCredentials,
 	})
 	if err != nil {
 		return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
@@ -146,7 +168,14 @@ func (r *HcloudClusterReconciler) reconcileDelete(clusterScope *scope.ClusterSco
 		return reconcile.Result{}, err
 	}
 
-	// wait for all hcloudMachines to be deleted
+	// resources of legacy clusters are only found once they are relabelled
+	if err := r.migrateResourceLabels(clusterScope); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to migrate resource labels for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	// wait for all hcloudMachines to be deleted, after the force deletion
+	// timeout their servers are deleted directly
+	forced := false
 	if machines, _, err := clusterScope.ListMachines(ctx); err != nil {
 		return reconcile.Result{}, errors.Wrapf(err, "failed to list machines for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
 	} else if len(machines) > 0 {
@@ -154,15 +183,34 @@ func (r *HcloudClusterReconciler) reconcileDelete(clusterScope *scope.ClusterSco
 		for _, m := range machines {
 			names = append(names, fmt.Sprintf("machine/%s", m.Name))
 		}
+		if !forceDeletionDue(hcloudCluster) {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeNormal,
+				"WaitingForMachineDeletion",
+				"Machines %s still running, waiting with deletion of HcloudCluster",
+				strings.Join(names, ", "),
+			)
+			return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+
+		forced = true
 		r.Recorder.Eventf(
 			hcloudCluster,
-			corev1.EventTypeNormal,
-			"WaitingForMachineDeletion",
-			"Machines %s still running, waiting with deletion of HcloudCluster",
+			corev1.EventTypeWarning,
+			"ForcedDeletion",
+			"Machines %s not deleted within %s, forcefully deleting the remaining resources of HcloudCluster",
 			strings.Join(names, ", "),
+			hcloudCluster.Spec.ForceDeletionTimeout.Duration,
 		)
-		// TODO: send delete for machines still running
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		deleted, err := r.forceDeleteServers(clusterScope)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to forcefully delete servers for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+		}
+		// the network can only be deleted once the servers are gone
+		if deleted > 0 {
+			return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		}
 	}
 
 	// delete load balancers
@@ -175,6 +223,15 @@ func (r *HcloudClusterReconciler) reconcileDelete(clusterScope *scope.ClusterSco
 		return reconcile.Result{}, errors.Wrapf(err, "failed to delete network for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
 	}
 
+	if forced {
+		r.Recorder.Eventf(
+			hcloudCluster,
+			corev1.EventTypeWarning,
+			"ForceDeletedInfrastructure",
+			"Forcefully deleted the load balancer and network of HcloudCluster",
+		)
+	}
+
 	// Cluster is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(clusterScope.HcloudCluster, infrav1.ClusterFinalizer)
 
@@ -189,6 +246,12 @@ func (r *HcloudClusterReconciler) reconcileNormal(clusterScope *scope.ClusterSco
 	// If the HcloudCluster doesn't have our finalizer, add it.
 	controllerutil.AddFinalizer(hcloudCluster, infrav1.ClusterFinalizer)
 
+	// label the resources of the cluster with a UID, which survives moving it
+	ensureResourceUID(hcloudCluster)
+	if err := r.migrateResourceLabels(clusterScope); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to migrate resource labels for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
 	// ensure a valid location is set
 	if err := location.NewService(clusterScope).Reconcile(ctx); err != nil {
 		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile location for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
@@ -230,8 +293,16 @@ func (r *HcloudClusterReconciler) reconcileNormal(clusterScope *scope.ClusterSco
 		hcloudCluster.Status.Ready = true
 
 	}
-	// reconcile cluster manifests
-	if err := r.reconcileManifests(clusterScope); err == errNoReadyAPIServer {
+
+	// start targetClusterManager, independent of the result of the manifests
+	// below, so its health and the approval of CSRs are kept up to date
+	managerErr := r.reconcileTargetClusterManager(clusterScope)
+
+	// reconcile cluster manifests and addons
+	err := r.reconcileManifestsAndAddons(clusterScope)
+	if err == errResourceSetNotApplied {
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, managerErr
+	} else if err == errNoReadyAPIServer {
 		r.Recorder.Eventf(
 			hcloudCluster,
 			corev1.EventTypeNormal,
@@ -239,129 +310,114 @@ func (r *HcloudClusterReconciler) reconcileNormal(clusterScope *scope.ClusterSco
 			"No ready API server available yet to reconcile: %s",
 			err,
 		)
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, managerErr
 	} else if err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile manifests for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+		return reconcile.Result{}, errorutil.NewAggregate([]error{
+			errors.Wrapf(err, "failed to reconcile manifests for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name),
+			managerErr,
+		})
 	}
 
-	// start targetClusterManager
-	if err := r.reconcileTargetClusterManager(clusterScope); err != nil {
-		return reconcile.Result{}, err
-	}
-
-	return reconcile.Result{}, nil
+	return reconcile.Result{}, managerErr
 }
 
-func (r *HcloudClusterReconciler) reconcileTargetClusterManager(clusterScope *scope.ClusterScope) error {
-	hcloudCluster := clusterScope.HcloudCluster
-	deleted := !hcloudCluster.DeletionTimestamp.IsZero()
-
-	r.targetClusterManagersLock.Lock()
-	defer r.targetClusterManagersLock.Unlock()
-	key := types.NamespacedName{
-		Namespace: hcloudCluster.Namespace,
-		Name:      hcloudCluster.Name,
+// reconcileManifestsAndAddons applies the manifests and the addons
+// independently, so a failure of one does not block the other. It returns
+// errNoReadyAPIServer, if that is the only error.
+func (r *HcloudClusterReconciler) reconcileManifestsAndAddons(clusterScope *scope.ClusterScope) error {
+	if clusterScope.HcloudCluster.Spec.Manifests.GetMode() == infrav1.HcloudClusterManifestsModeClusterResourceSet {
+		return r.reconcileClusterResourceSet(clusterScope)
 	}
-	if stopCh, ok := r.targetClusterManagersStopCh[key]; !ok && !deleted {
-		// create a new cluster manager
-		m, err := r.newTargetClusterManager(clusterScope)
+
+	var errs []error
+	onlyNoReadyAPIServer := true
+	for _, err := range []error{
+		r.deleteClusterResourceSet(clusterScope),
+		r.reconcileManifests(clusterScope),
+		r.reconcileAddons(clusterScope),
+	} {
 		if err != nil {
-			return errors.Wrapf(err, "failed to create a clusterManager for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+			errs = append(errs, err)
+			onlyNoReadyAPIServer = onlyNoReadyAPIServer && err == errNoReadyAPIServer
 		}
-		r.targetClusterManagersStopCh[key] = make(chan struct{})
-
-		go func() {
-			if err := m.Start(r.targetClusterManagersStopCh[key]); err != nil {
-				clusterScope.Error(err, "failed to start a targetClusterManager")
-			} else {
-				clusterScope.Info("stoppend targetClusterManager")
-			}
-			r.targetClusterManagersLock.Lock()
-			defer r.targetClusterManagersLock.Unlock()
-			delete(r.targetClusterManagersStopCh, key)
-		}()
-	} else if ok && deleted {
-		close(stopCh)
-		delete(r.targetClusterManagersStopCh, key)
 	}
-
-	return nil
+	if len(errs) > 0 && onlyNoReadyAPIServer {
+		return errNoReadyAPIServer
+	}
+	return errorutil.NewAggregate(errs)
 }
 
-func (r *HcloudClusterReconciler) newTargetClusterManager(clusterScope *scope.ClusterScope) (ctrl.Manager, error) {
+// readyClientConfig returns a client config for the API server of the first
+// ready control plane machine
+func (r *HcloudClusterReconciler) readyClientConfig(clusterScope *scope.ClusterScope) (clientcmd.ClientConfig, error) {
 	hcloudCluster := clusterScope.HcloudCluster
 
-	clientConfig, err := clusterScope.ClientConfig()
+	machines, hcloudMachines, err := clusterScope.ListMachines(clusterScope.Ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get a clientConfig for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+		return nil, errors.Wrapf(err, "failed to list machines for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
 	}
 
-	restConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get a restConfig for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
-	}
+	var clientConfig clientcmd.ClientConfig
+	var readyErrors []error
+machines:
+	for pos, m := range machines {
+		if !util.IsControlPlaneMachine(m) {
+			continue
+		}
 
-	clientSet, err := kubernetes.NewForConfig(restConfig)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get a clientSet for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
-	}
+		if !m.Status.InfrastructureReady {
+			continue
+		}
 
-	scheme := runtime.NewScheme()
-	_ = certificatesv1.AddToScheme(scheme)
+		// find a ready clientconfig
+		for _, address := range hcloudMachines[pos].Status.Addresses {
+			if address.Type != corev1.NodeExternalIP && address.Type != corev1.NodeExternalDNS {
+				continue
+			}
 
-	clusterMgr, err := ctrl.NewManager(
-		restConfig,
-		ctrl.Options{
-			Scheme:             scheme,
-			MetricsBindAddress: "0",
-			LeaderElection:     false,
-		},
-	)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to setup guest cluster manager")
-	}
+			c, err := clusterScope.ClientConfigWithAPIEndpoint(clusterv1.APIEndpoint{
+				Host: address.Address,
+				Port: clusterScope.ControlPlaneAPIEndpointPort(),
+			})
+			if err != nil {
+				return nil, err
+			}
 
-	gr := &GuestCSRReconciler{
-		Client: clusterMgr.GetClient(),
-		Log:    r.Log,
-		mCluster: &managementCluster{
-			Client:        r.Client,
-			hcloudCluster: hcloudCluster,
-			recorder:      r.Recorder,
-		},
-		clientSet: clientSet,
-	}
+			if err := scope.IsControlPlaneReady(clusterScope.Ctx, c); err != nil {
+				readyErrors = append(readyErrors, fmt.Errorf("APIserver '%s': %w", hcloudMachines[pos].Name, err))
+			}
 
-	if err := gr.SetupWithManager(clusterMgr, controller.Options{}); err != nil {
-		errors.Wrapf(err, "failed to setup CSR controller")
+			// APIserver ready
+			clientConfig = c
+			break machines
+		}
 	}
 
-	return clusterMgr, nil
-}
-
-var _ ManagementCluster = &managementCluster{}
-
-type managementCluster struct {
-	controllerclient.Client
-	hcloudCluster *infrav1.HcloudCluster
-	recorder      recorder.EventRecorder
-}
-
-func (c *managementCluster) Namespace() string {
-	return c.hcloudCluster.Namespace
-}
-
-func (c *managementCluster) Event(eventtype, reason, message string) {
-	c.recorder.Event(c.hcloudCluster, eventtype, reason, message)
-}
+	if clientConfig == nil {
+		if err := errorutil.NewAggregate(readyErrors); err != nil {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeWarning,
+				"APIServerNotReady",
+				"Health check for API servers failed: %s",
+				err,
+			)
+			return nil, errNoReadyAPIServer
+		}
+		return nil, errNoReadyAPIServer
+	}
 
-func (c *managementCluster) Eventf(eventtype, reason, message string, args ...interface{}) {
-	c.recorder.Eventf(c.hcloudCluster, eventtype, reason, message, args...)
+	return clientConfig, nil
 }
 
 func (r *HcloudClusterReconciler) reconcileManifests(clusterScope *scope.ClusterScope) error {
 	hcloudCluster := clusterScope.HcloudCluster
 
+	// without a manifests config only the addons are applied
+	if !clusterScope.ManifestsEnabled() {
+		return nil
+	}
+
 	// Check if manifests need to be applied or reapplied
 	expectedHash, err := clusterScope.ManifestsHash()
 	if err != nil {
@@ -369,68 +425,41 @@ func (r *HcloudClusterReconciler) reconcileManifests(clusterScope *scope.Cluster
 	}
 
 	applyManifests := func() error {
-		machines, hcloudMachines, err := clusterScope.ListMachines(clusterScope.Ctx)
+		clientConfig, err := r.readyClientConfig(clusterScope)
 		if err != nil {
-			return errors.Wrapf(err, "failed to list machines for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
-		}
-
-		var clientConfig clientcmd.ClientConfig
-		var readyErrors []error
-	machines:
-		for pos, m := range machines {
-			if !util.IsControlPlaneMachine(m) {
-				continue
-			}
-
-			if !m.Status.InfrastructureReady {
-				continue
-			}
-
-			// find a ready clientconfig
-			for _, address := range hcloudMachines[pos].Status.Addresses {
-				if address.Type != corev1.NodeExternalIP && address.Type != corev1.NodeExternalDNS {
-					continue
-				}
-
-				c, err := clusterScope.ClientConfigWithAPIEndpoint(clusterv1.APIEndpoint{
-					Host: address.Address,
-					Port: clusterScope.ControlPlaneAPIEndpointPort(),
-				})
-				if err != nil {
-					return err
-				}
-
-				if err := scope.IsControlPlaneReady(clusterScope.Ctx, c); err != nil {
-					readyErrors = append(readyErrors, fmt.Errorf("APIserver '%s': %w", hcloudMachines[pos].Name, err))
-				}
-
-				// APIserver ready
-				clientConfig = c
-				break machines
-			}
+			return err
 		}
 
-		if clientConfig == nil {
-			if err := errorutil.NewAggregate(readyErrors); err != nil {
+		results, err := clusterScope.ApplyManifestsWithClientConfig(clusterScope.Ctx, clientConfig)
+		var applyErrs manifestsapi.ApplyErrors
+		if errors.As(err, &applyErrs) {
+			for _, applyErr := range applyErrs {
 				r.Recorder.Eventf(
 					hcloudCluster,
 					corev1.EventTypeWarning,
-					"APIServerNotReady",
-					"Health check for API servers failed: %s",
-					err,
+					"FailedApplyManifest",
+					"Failed to apply %s (%s): %s",
+					applyErr.Object,
+					applyErr.Reason,
+					applyErr.Err,
 				)
-				return errNoReadyAPIServer
 			}
-			return errNoReadyAPIServer
-		}
-
-		if err := clusterScope.ApplyManifestsWithClientConfig(clusterScope.Ctx, clientConfig); err != nil {
+			return errors.Errorf("failed to apply %d of %d manifest objects", len(applyErrs), len(results))
+		} else if err != nil {
 			return errors.Wrap(err, "error applying manifests to first API server")
 		}
+		clusterScope.V(1).Info("Manifests applied", "objects", len(results))
 
 		if hcloudCluster.Status.Manifests == nil {
 			hcloudCluster.Status.Manifests = &infrav1.HcloudClusterStatusManifests{}
 		}
+
+		appliedObjects, err := r.pruneManifests(clusterScope, clientConfig, hcloudCluster.Status.Manifests.AppliedObjects, results)
+		hcloudCluster.Status.Manifests.AppliedObjects = appliedObjects
+		if err != nil {
+			return err
+		}
+
 		var myTrue = true
 		hcloudCluster.Status.Manifests.Initialized = &myTrue
 		hcloudCluster.Status.Manifests.AppliedHash = &expectedHash
@@ -469,11 +498,90 @@ func (r *HcloudClusterReconciler) reconcileManifests(clusterScope *scope.Cluster
 	return nil
 }
 
+// pruneManifests deletes objects that have been applied previously, but are no
+// longer part of the applied results. It returns the objects to be tracked in
+// the status, which includes objects that failed to be pruned.
+func (r *HcloudClusterReconciler) pruneManifests(clusterScope *scope.ClusterScope, clientConfig clientcmd.ClientConfig, previous []infrav1.HcloudClusterManifestObject, results []manifestsapi.ApplyResult) ([]infrav1.HcloudClusterManifestObject, error) {
+	hcloudCluster := clusterScope.HcloudCluster
+
+	appliedObjects := make([]infrav1.HcloudClusterManifestObject, 0, len(results))
+	rendered := make([]manifestsapi.ObjectReference, 0, len(results))
+	for _, result := range results {
+		rendered = append(rendered, result.Object)
+		appliedObjects = append(appliedObjects, infrav1.HcloudClusterManifestObject{
+			APIVersion: result.Object.APIVersion,
+			Kind:       result.Object.Kind,
+			Namespace:  result.Object.Namespace,
+			Name:       result.Object.Name,
+		})
+	}
+
+	applied := make([]manifestsapi.ObjectReference, 0, len(previous))
+	for _, o := range previous {
+		applied = append(applied, manifestsapi.ObjectReference{
+			APIVersion: o.APIVersion,
+			Kind:       o.Kind,
+			Namespace:  o.Namespace,
+			Name:       o.Name,
+		})
+	}
+	stale := manifestsapi.StaleObjects(applied, rendered)
+	if len(stale) == 0 {
+		return appliedObjects, nil
+	}
+
+	pruneResults, err := clusterScope.PruneManifestsWithClientConfig(clusterScope.Ctx, clientConfig, stale)
+	var pruneErrs manifestsapi.ApplyErrors
+	if errors.As(err, &pruneErrs) {
+		for _, pruneErr := range pruneErrs {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeWarning,
+				"FailedPruneManifest",
+				"Failed to prune %s (%s): %s",
+				pruneErr.Object,
+				pruneErr.Reason,
+				pruneErr.Err,
+			)
+			// keep tracking the object, so pruning is retried
+			appliedObjects = append(appliedObjects, infrav1.HcloudClusterManifestObject{
+				APIVersion: pruneErr.Object.APIVersion,
+				Kind:       pruneErr.Object.Kind,
+				Namespace:  pruneErr.Object.Namespace,
+				Name:       pruneErr.Object.Name,
+			})
+		}
+		return appliedObjects, errors.Errorf("failed to prune %d of %d manifest objects", len(pruneErrs), len(stale))
+	} else if err != nil {
+		return previous, errors.Wrap(err, "error pruning manifests")
+	}
+
+	var kept int
+	for _, result := range pruneResults {
+		if result.Kept {
+			kept++
+		}
+	}
+	r.Recorder.Eventf(
+		hcloudCluster,
+		corev1.EventTypeNormal,
+		"ManifestsPruned",
+		"Pruned %d objects no longer part of the manifests (%d kept)",
+		len(pruneResults)-kept,
+		kept,
+	)
+
+	return appliedObjects, nil
+}
+
 func (r *HcloudClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	r.targetClusterManagersLock.Lock()
 	defer r.targetClusterManagersLock.Unlock()
-	if r.targetClusterManagersStopCh == nil {
-		r.targetClusterManagersStopCh = make(map[types.NamespacedName]chan struct{})
+	if r.targetClusterManagers == nil {
+		r.targetClusterManagers = make(map[types.NamespacedName]*targetClusterManager)
+	}
+	if r.targetClusterEvents == nil {
+		r.targetClusterEvents = make(chan event.GenericEvent, targetClusterEventsBuffer)
 	}
 	if r.Recorder == nil {
 		r.Recorder = mgr.GetEventRecorderFor("hcloud-cluster-reconciler")
@@ -493,5 +601,22 @@ func (r *HcloudClusterReconciler) SetupWithManager(mgr ctrl.Manager, options con
 				ToRequests: util.ClusterToInfrastructureMapFunc(controlledTypeGVK),
 			},
 		).
+		// Restart the targetClusterManager on a rotated kubeconfig.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.kubeconfigSecretToHcloudCluster),
+			},
+		).
+		// Reconcile on health changes of the targetClusterManager.
+		Watches(
+			&source.Channel{Source: r.targetClusterEvents},
+			&handler.EnqueueRequestForObject{},
+		).
+		// Reconcile on external changes of the load balancer or network.
+		Watches(
+			&source.Channel{Source: scope.DefaultHcloudClientCache.ClusterEvents()},
+			&handler.EnqueueRequestForObject{},
+		).
 		Complete(r)
 }
@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func TestReconcileManifestsAndAddons(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		manifestsEnabled bool
+		expectedErrors   []string
+	}{
+		{
+			name:           "manifests disabled",
+			expectedErrors: []string{"failed to render cni addon cilium"},
+		},
+		{
+			name:             "failing manifests",
+			manifestsEnabled: true,
+			expectedErrors:   []string{"broken manifests", "failed to render cni addon cilium"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			}
+			hcloudCluster := &infrav1.HcloudCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Spec: infrav1.HcloudClusterSpec{
+					ControlPlaneEndpoint: &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+					Addons: &infrav1.HcloudClusterAddonsSpec{
+						CNI: &infrav1.HcloudClusterAddon{Name: "cilium"},
+					},
+				},
+			}
+			c := newTestClient(cluster, hcloudCluster)
+
+			// a failure of the manifests does not stop the addons from being
+			// reconciled
+			manifests := mock_scope.NewMockManifests(mockCtrl)
+			manifests.EXPECT().Enabled().Return(tc.manifestsEnabled)
+			if tc.manifestsEnabled {
+				manifests.EXPECT().Hash(gomock.Any()).Return("", errors.New("broken manifests"))
+			}
+			manifests.EXPECT().AddonHash(gomock.Any(), gomock.Any()).Return("", errors.New("broken addon"))
+
+			clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+				Ctx:    context.TODO(),
+				Client: c,
+				HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+					return mock_scope.NewMockHcloudClient(mockCtrl), nil
+				},
+				Cluster:       cluster,
+				HcloudCluster: hcloudCluster,
+				Packer:        mock_scope.NewMockPacker(mockCtrl),
+				Manifests:     manifests,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			r := &HcloudClusterReconciler{
+				Client:   c,
+				Recorder: recorder,
+			}
+			err = r.reconcileManifestsAndAddons(clusterScope)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			for _, expected := range tc.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %q", expected, err)
+				}
+			}
+			if expected, actual := "Warning InvalidAddon The cni addon cilium can not be rendered: broken addon", <-recorder.Events; !strings.HasPrefix(actual, expected) {
+				t.Errorf("expected event %q, got %q", expected, actual)
+			}
+		})
+	}
+}
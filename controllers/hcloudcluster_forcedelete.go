@@ -0,0 +1,139 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	loadbalancer "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/loadbalancer"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/server"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/utils"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// forceDeletionDue returns true, if the machines of the deleted cluster have
+// not been deleted within the force deletion timeout
+func forceDeletionDue(hcloudCluster *infrav1.HcloudCluster) bool {
+	timeout := hcloudCluster.Spec.ForceDeletionTimeout
+	if timeout == nil || hcloudCluster.DeletionTimestamp == nil {
+		return false
+	}
+	return time.Since(hcloudCluster.DeletionTimestamp.Time) >= timeout.Duration
+}
+
+// forceDeleteServers removes the remaining servers of the cluster from the
+// load balancer and deletes them directly. Adopted servers, whose ownership
+// has not been transferred to their machines, are released instead: the
+// labels of the cluster are removed, but the servers are not deleted. It
+// returns the number of deleted servers.
+func (r *HcloudClusterReconciler) forceDeleteServers(clusterScope *scope.ClusterScope) (int, error) {
+	ctx := clusterScope.Ctx
+	hcloudCluster := clusterScope.HcloudCluster
+
+	opts := hcloud.ServerListOpts{}
+	opts.LabelSelector = utils.LabelsToLabelSelector(hcloudCluster.ResourceLabels())
+	servers, err := clusterScope.HcloudClient().ListServers(ctx, opts)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list servers")
+	}
+	if len(servers) == 0 {
+		return 0, nil
+	}
+
+	// adopted servers are only deleted, if their ownership has been
+	// transferred to the machine
+	var hcloudMachines infrav1.HcloudMachineList
+	if err := r.List(ctx, &hcloudMachines, client.InNamespace(hcloudCluster.Namespace)); err != nil {
+		return 0, errors.Wrap(err, "failed to list HcloudMachines")
+	}
+	adopted := make(map[int]bool)
+	for _, m := range hcloudMachines.Items {
+		if a := m.Spec.Adopt; a != nil && !a.TransferOwnership {
+			adopted[a.ServerID] = true
+		}
+	}
+
+	lb, err := loadbalancer.FindLoadBalancer(clusterScope)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find load balancer")
+	}
+
+	var deleted int
+	for _, hcloudServer := range servers {
+		if lb != nil && isLoadBalancerTarget(lb, hcloudServer) {
+			if _, _, err := clusterScope.HcloudClient().DeleteTargetServerOfLoadBalancer(ctx, lb, hcloudServer); err != nil {
+				return deleted, errors.Wrapf(err, "failed to remove server %d from load balancer %d", hcloudServer.ID, lb.ID)
+			}
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeWarning,
+				"ForceRemovedLoadBalancerTarget",
+				"Forcefully removed server %s with id %d from load balancer %s",
+				hcloudServer.Name,
+				hcloudServer.ID,
+				lb.Name,
+			)
+		}
+
+		if adopted[hcloudServer.ID] {
+			// without the labels of the cluster, the garbage collector does
+			// not consider the released server an orphan
+			labels := server.ReleasedLabels(hcloudCluster, hcloudServer.Labels)
+			if _, _, err := clusterScope.HcloudClient().UpdateServer(ctx, hcloudServer, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+				return deleted, errors.Wrapf(err, "failed to remove labels of server %d", hcloudServer.ID)
+			}
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeNormal,
+				"ReleasedAdoptedServer",
+				"Released adopted server %s with id %d without deleting it, its ownership has not been transferred",
+				hcloudServer.Name,
+				hcloudServer.ID,
+			)
+			continue
+		}
+
+		if _, err := clusterScope.HcloudClient().DeleteServer(ctx, hcloudServer); err != nil && !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return deleted, errors.Wrapf(err, "failed to delete server %d", hcloudServer.ID)
+		}
+		deleted++
+		r.Recorder.Eventf(
+			hcloudCluster,
+			corev1.EventTypeWarning,
+			"ForceDeletedServer",
+			"Forcefully deleted server %s with id %d",
+			hcloudServer.Name,
+			hcloudServer.ID,
+		)
+	}
+
+	return deleted, nil
+}
+
+func isLoadBalancerTarget(lb *hcloud.LoadBalancer, server *hcloud.Server) bool {
+	for _, target := range lb.Targets {
+		if target.Server.Server != nil && target.Server.Server.ID == server.ID {
+			return true
+		}
+	}
+	return false
+}
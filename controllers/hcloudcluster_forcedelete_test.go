@@ -0,0 +1,92 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func TestForceDeleteServers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+	}
+	adoptedMachine := &infrav1.HcloudMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-adopted"},
+		Spec: infrav1.HcloudMachineSpec{
+			Adopt: &infrav1.HcloudMachineAdoption{ServerID: 2},
+		},
+	}
+	c := newTestClient(cluster, hcloudCluster, adoptedMachine)
+
+	serverLabels := hcloudCluster.ResourceLabels()
+	serverLabels[infrav1.MachineNameTagKey] = "test-worker"
+	adoptedLabels := hcloudCluster.ResourceLabels()
+	adoptedLabels[infrav1.MachineNameTagKey] = "test-adopted"
+	adoptedLabels["machine_type"] = "worker"
+	adoptedLabels["team"] = "a"
+
+	hc := mock_scope.NewMockHcloudClient(mockCtrl)
+	hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return([]*hcloud.Server{
+		{ID: 1, Name: "worker", Labels: serverLabels},
+		{ID: 2, Name: "adopted", Labels: adoptedLabels},
+	}, nil)
+	hc.EXPECT().ListLoadBalancers(gomock.Any(), gomock.Any()).Return(nil, nil)
+	hc.EXPECT().DeleteServer(gomock.Any(), &hcloud.Server{ID: 1, Name: "worker", Labels: serverLabels}).Return(nil, nil)
+	// the adopted server keeps only the labels, which have not been set by
+	// the provider
+	hc.EXPECT().UpdateServer(
+		gomock.Any(),
+		&hcloud.Server{ID: 2, Name: "adopted", Labels: adoptedLabels},
+		hcloud.ServerUpdateOpts{Labels: map[string]string{"team": "a"}},
+	).Return(nil, nil, nil)
+
+	recorder := record.NewFakeRecorder(10)
+	r := &HcloudClusterReconciler{
+		Client:   c,
+		Recorder: recorder,
+	}
+	deleted, err := r.forceDeleteServers(newTestClusterScope(t, mockCtrl, c, hc, cluster, hcloudCluster))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted server, got %d", deleted)
+	}
+
+	for _, expected := range []string{
+		"Warning ForceDeletedServer Forcefully deleted server worker with id 1",
+		"Normal ReleasedAdoptedServer Released adopted server adopted with id 2 without deleting it, its ownership has not been transferred",
+	} {
+		if actual := <-recorder.Events; actual != expected {
+			t.Errorf("expected event %q, got %q", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/server"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// ensureResourceUID records the UID the resources of the cluster are labelled
+// with in an annotation, so they are still found after the HcloudCluster has
+// been moved to another management cluster
+func ensureResourceUID(hcloudCluster *infrav1.HcloudCluster) {
+	if hcloudCluster.Annotations[infrav1.ClusterUIDTagKey] != "" {
+		return
+	}
+	if hcloudCluster.Annotations == nil {
+		hcloudCluster.Annotations = make(map[string]string)
+	}
+	hcloudCluster.Annotations[infrav1.ClusterUIDTagKey] = string(hcloudCluster.UID)
+}
+
+// migrateResourceLabels adds the namespace and the UID of the cluster to the
+// labels of its legacy resources. The legacy labels do not tell clusters of
+// the same name in different namespaces apart, so only resources referenced
+// by the status of the cluster, its machines and the volumes in its namespace
+// are relabelled. The migration runs until it succeeded once per cluster.
+func (r *HcloudClusterReconciler) migrateResourceLabels(clusterScope *scope.ClusterScope) error {
+	hcloudCluster := clusterScope.HcloudCluster
+	uid := types.UID(hcloudCluster.ResourceUID())
+
+	r.labelsMigratedLock.Lock()
+	migrated := r.labelsMigrated[uid]
+	r.labelsMigratedLock.Unlock()
+	if migrated {
+		return nil
+	}
+
+	ctx := clusterScope.Ctx
+	refs, err := r.clusterReferences(clusterScope)
+	if err != nil {
+		return err
+	}
+
+	hc := clusterScope.HcloudClient()
+	selector := hcloudCluster.LegacyResourceLabelSelector()
+	var relabelled int
+	var errs []error
+
+	servers, err := hc.ListServers(ctx, hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}})
+	if err != nil {
+		return errors.Wrap(err, "failed to list servers")
+	}
+	for _, s := range servers {
+		if !refs.servers[s.ID] {
+			continue
+		}
+		opts := hcloud.ServerUpdateOpts{Labels: migratedLabels(hcloudCluster, s.Labels)}
+		if _, _, err := hc.UpdateServer(ctx, s, opts); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to relabel server %d", s.ID))
+			continue
+		}
+		relabelled++
+	}
+
+	loadBalancers, err := hc.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}})
+	if err != nil {
+		return errors.Wrap(err, "failed to list load balancers")
+	}
+	for _, lb := range loadBalancers {
+		if lb.ID != hcloudCluster.Status.ControlPlaneLoadBalancer.ID {
+			continue
+		}
+		opts := hcloud.LoadBalancerUpdateOpts{Labels: migratedLabels(hcloudCluster, lb.Labels)}
+		if _, _, err := hc.UpdateLoadBalancer(ctx, lb, opts); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to relabel load balancer %d", lb.ID))
+			continue
+		}
+		relabelled++
+	}
+
+	networks, err := hc.ListNetworks(ctx, hcloud.NetworkListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}})
+	if err != nil {
+		return errors.Wrap(err, "failed to list networks")
+	}
+	for _, n := range networks {
+		if status := hcloudCluster.Status.Network; status == nil || n.ID != status.ID {
+			continue
+		}
+		opts := hcloud.NetworkUpdateOpts{Labels: migratedLabels(hcloudCluster, n.Labels)}
+		if _, _, err := hc.UpdateNetwork(ctx, n, opts); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to relabel network %d", n.ID))
+			continue
+		}
+		relabelled++
+	}
+
+	volumes, err := hc.ListVolumes(ctx, hcloud.VolumeListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}})
+	if err != nil {
+		return errors.Wrap(err, "failed to list volumes")
+	}
+	for _, v := range volumes {
+		name, ok := refs.volumes[v.ID]
+		if !ok {
+			continue
+		}
+		// the name of the HcloudVolume matches changes of the volume with
+		// its owner
+		labels := migratedLabels(hcloudCluster, v.Labels)
+		labels[infrav1.VolumeNameTagKey] = name
+		opts := hcloud.VolumeUpdateOpts{Labels: labels}
+		if _, _, err := hc.UpdateVolume(ctx, v, opts); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to relabel volume %d", v.ID))
+			continue
+		}
+		relabelled++
+	}
+
+	if relabelled > 0 {
+		r.Recorder.Eventf(
+			hcloudCluster,
+			corev1.EventTypeNormal,
+			"MigratedResourceLabels",
+			"Added namespace and UID of the cluster to the labels of %d resources",
+			relabelled,
+		)
+	}
+	if len(errs) > 0 {
+		return errorutil.NewAggregate(errs)
+	}
+
+	r.labelsMigratedLock.Lock()
+	if r.labelsMigrated == nil {
+		r.labelsMigrated = make(map[types.UID]bool)
+	}
+	r.labelsMigrated[uid] = true
+	r.labelsMigratedLock.Unlock()
+	return nil
+}
+
+// clusterReferences are the IDs of the servers and volumes referenced by the
+// objects of a cluster, the volumes map to the names of their HcloudVolumes
+type clusterReferences struct {
+	servers map[int]bool
+	volumes map[int]string
+}
+
+func (r *HcloudClusterReconciler) clusterReferences(clusterScope *scope.ClusterScope) (*clusterReferences, error) {
+	refs := &clusterReferences{
+		servers: make(map[int]bool),
+		volumes: make(map[int]string),
+	}
+
+	_, hcloudMachines, err := clusterScope.ListMachines(clusterScope.Ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+	for _, m := range hcloudMachines {
+		if id := m.Status.ServerID; id != nil {
+			refs.servers[*id] = true
+		} else if m.Spec.ProviderID != nil {
+			if id, ok := server.ServerIDFromProviderID(*m.Spec.ProviderID); ok {
+				refs.servers[id] = true
+			}
+		}
+	}
+
+	var hcloudVolumes infrav1.HcloudVolumeList
+	if err := r.List(clusterScope.Ctx, &hcloudVolumes, client.InNamespace(clusterScope.Namespace())); err != nil {
+		return nil, errors.Wrap(err, "failed to list HcloudVolumes")
+	}
+	for _, v := range hcloudVolumes.Items {
+		if id := v.Status.VolumeID; id != nil {
+			refs.volumes[int(*id)] = v.Name
+		}
+	}
+
+	return refs, nil
+}
+
+// migratedLabels returns the labels of a legacy resource with the labels of
+// the cluster added
+func migratedLabels(hcloudCluster *infrav1.HcloudCluster, labels map[string]string) map[string]string {
+	migrated := make(map[string]string, len(labels)+3)
+	for key, value := range labels {
+		migrated[key] = value
+	}
+	for key, value := range hcloudCluster.ResourceLabels() {
+		migrated[key] = value
+	}
+	return migrated
+}
@@ -0,0 +1,145 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func TestMigrateResourceLabels(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	legacyLabels := map[string]string{
+		infrav1.ClusterTagKey("test"): string(infrav1.ResourceLifecycleOwned),
+	}
+	serverID := 1
+	volumeID := infrav1.HcloudVolumeID(3)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+		Status: infrav1.HcloudClusterStatus{
+			ControlPlaneLoadBalancer: infrav1.HcloudLoadBalancerStatus{ID: 10},
+			Network:                  &infrav1.HcloudNetworkStatus{ID: 20},
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test",
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "HcloudMachine",
+				Name:       "test-worker",
+			},
+		},
+	}
+	hcloudMachine := &infrav1.HcloudMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+		Status:     infrav1.HcloudMachineStatus{ServerID: &serverID},
+	}
+	hcloudVolume := &infrav1.HcloudVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-data"},
+		Status:     infrav1.HcloudVolumeStatus{VolumeID: &volumeID},
+	}
+
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+	_ = clusterv1.AddToScheme(sch)
+	_ = infrav1.AddToScheme(sch)
+	c := fake.NewFakeClientWithScheme(sch, cluster, hcloudCluster, machine, hcloudMachine, hcloudVolume)
+
+	// resources with the IDs 2, 4, 11 and 21 are labelled with the name of
+	// the cluster, but not referenced by it, e.g. as they belong to a cluster
+	// of the same name in another namespace
+	hc := mock_scope.NewMockHcloudClient(mockCtrl)
+	hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return([]*hcloud.Server{
+		{ID: 1, Labels: legacyLabels},
+		{ID: 2, Labels: legacyLabels},
+	}, nil)
+	hc.EXPECT().ListLoadBalancers(gomock.Any(), gomock.Any()).Return([]*hcloud.LoadBalancer{
+		{ID: 10, Labels: legacyLabels},
+		{ID: 11, Labels: legacyLabels},
+	}, nil)
+	hc.EXPECT().ListNetworks(gomock.Any(), gomock.Any()).Return([]*hcloud.Network{
+		{ID: 20, Labels: legacyLabels},
+		{ID: 21, Labels: legacyLabels},
+	}, nil)
+	hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return([]*hcloud.Volume{
+		{ID: 3, Labels: legacyLabels},
+		{ID: 4, Labels: legacyLabels},
+	}, nil)
+
+	expectedLabels := hcloudCluster.ResourceLabels()
+	hc.EXPECT().UpdateServer(gomock.Any(), &hcloud.Server{ID: 1, Labels: legacyLabels}, hcloud.ServerUpdateOpts{Labels: expectedLabels}).Return(nil, nil, nil)
+	hc.EXPECT().UpdateLoadBalancer(gomock.Any(), &hcloud.LoadBalancer{ID: 10, Labels: legacyLabels}, hcloud.LoadBalancerUpdateOpts{Labels: expectedLabels}).Return(nil, nil, nil)
+	hc.EXPECT().UpdateNetwork(gomock.Any(), &hcloud.Network{ID: 20, Labels: legacyLabels}, hcloud.NetworkUpdateOpts{Labels: expectedLabels}).Return(nil, nil, nil)
+	// volumes are labelled with the name of their HcloudVolume, which owns
+	// them
+	expectedVolumeLabels := hcloudCluster.ResourceLabels()
+	expectedVolumeLabels[infrav1.VolumeNameTagKey] = "test-data"
+	hc.EXPECT().UpdateVolume(gomock.Any(), &hcloud.Volume{ID: 3, Labels: legacyLabels}, hcloud.VolumeUpdateOpts{Labels: expectedVolumeLabels}).Return(nil, nil, nil)
+
+	clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+		Ctx:    context.TODO(),
+		Client: c,
+		HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+			return hc, nil
+		},
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        mock_scope.NewMockPacker(mockCtrl),
+		Manifests:     mock_scope.NewMockManifests(mockCtrl),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &HcloudClusterReconciler{
+		Client:   c,
+		Recorder: recorder,
+	}
+	if err := r.migrateResourceLabels(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected, actual := "Normal MigratedResourceLabels Added namespace and UID of the cluster to the labels of 4 resources", <-recorder.Events; actual != expected {
+		t.Errorf("expected event %q, got %q", expected, actual)
+	}
+
+	// the migration runs once per cluster, further calls would fail the mock
+	if err := r.migrateResourceLabels(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	manifestsapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// resourceSetHashLength is the length of the hash suffix of the ConfigMap and
+// Secret names. ClusterResourceSets apply a resource only once, so changed
+// manifests are written to new resources.
+const resourceSetHashLength = 10
+
+// resourceSetContent holds the rendered manifests and addons, split into the
+// data of the ConfigMap and of the Secret
+type resourceSetContent struct {
+	configMapData map[string]string
+	secretData    map[string][]byte
+}
+
+func (c *resourceSetContent) add(key string, objects []*unstructured.Unstructured) error {
+	var objs, secrets []byte
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return errors.Wrapf(err, "error marshalling %s/%s", obj.GetKind(), obj.GetName())
+		}
+		doc := append([]byte("---\n"), data...)
+		if obj.GroupVersionKind().GroupKind().String() == "Secret" {
+			secrets = append(secrets, doc...)
+		} else {
+			objs = append(objs, doc...)
+		}
+	}
+	if len(objs) > 0 {
+		c.configMapData[key] = string(objs)
+	}
+	if len(secrets) > 0 {
+		c.secretData[key] = secrets
+	}
+	return nil
+}
+
+// hash builds a sha256 hash over the content in a stable order
+func (c *resourceSetContent) hash() string {
+	keys := make([]string, 0, len(c.configMapData))
+	for k := range c.configMapData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	secretKeys := make([]string, 0, len(c.secretData))
+	for k := range c.secretData {
+		secretKeys = append(secretKeys, k)
+	}
+	sort.Strings(secretKeys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "configmap/%s\n%s\n", k, c.configMapData[k])
+	}
+	for _, k := range secretKeys {
+		fmt.Fprintf(h, "secret/%s\n%s\n", k, c.secretData[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func appliedObjects(objects []*unstructured.Unstructured) []infrav1.HcloudClusterManifestObject {
+	result := make([]infrav1.HcloudClusterManifestObject, len(objects))
+	for pos, obj := range objects {
+		result[pos] = infrav1.HcloudClusterManifestObject{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		}
+	}
+	return result
+}
+
+func resourceSetName(hcloudCluster *infrav1.HcloudCluster) string {
+	return hcloudCluster.Name + "-manifests"
+}
+
+// errResourceSetNotApplied is returned, while the ClusterResourceSetBinding
+// of the cluster does not show the current resources as applied
+var errResourceSetNotApplied = errors.New("ClusterResourceSet has not been applied yet")
+
+// reconcileClusterResourceSet writes the rendered manifests and addons into a
+// ConfigMap and a Secret, which are applied to the workload cluster by a
+// ClusterResourceSet selecting the Cluster. The resources are written on every
+// reconcile, so deleted ones are recreated. Once the ClusterResourceSetBinding
+// of the cluster shows them as applied, the status is updated like in Apply
+// mode, so the addon readiness is reported the same way.
+func (r *HcloudClusterReconciler) reconcileClusterResourceSet(clusterScope *scope.ClusterScope) error {
+	hcloudCluster := clusterScope.HcloudCluster
+	ctx := clusterScope.Ctx
+
+	if hcloudCluster.Status.Manifests == nil {
+		hcloudCluster.Status.Manifests = &infrav1.HcloudClusterStatusManifests{}
+	}
+	status := hcloudCluster.Status.Manifests
+
+	content := &resourceSetContent{
+		configMapData: make(map[string]string),
+		secretData:    make(map[string][]byte),
+	}
+
+	// without a manifests config only the addons are written
+	var manifestObjects []*unstructured.Unstructured
+	if clusterScope.ManifestsEnabled() {
+		var err error
+		manifestObjects, err = clusterScope.RenderManifests()
+		if err != nil {
+			return errors.Wrap(err, "failed to render manifests")
+		}
+		if err := content.add("manifests.yaml", manifestObjects); err != nil {
+			return err
+		}
+	}
+
+	addons := make(map[infrav1.HcloudClusterAddonType][]*unstructured.Unstructured)
+	for _, addonType := range infrav1.HcloudClusterAddonTypes {
+		addon := hcloudCluster.Spec.Addons.Get(addonType)
+		if addon == nil {
+			continue
+		}
+		ref := manifestsapi.Addon{Name: addon.Name, Version: addon.Version}
+		objects, err := clusterScope.RenderAddon(ref)
+		if err != nil {
+			r.Recorder.Eventf(
+				hcloudCluster,
+				corev1.EventTypeWarning,
+				"InvalidAddon",
+				"The %s addon %s can not be rendered: %s",
+				addonType,
+				ref,
+				err,
+			)
+			return errors.Wrapf(err, "failed to render %s addon %s", addonType, ref)
+		}
+		if err := content.add(fmt.Sprintf("addon-%s.yaml", addonType), objects); err != nil {
+			return err
+		}
+		addons[addonType] = objects
+	}
+
+	expectedHash := content.hash()
+
+	// select the cluster by a label
+	cluster := clusterScope.Cluster
+	if cluster.Labels[infrav1.ManifestsClusterResourceSetLabelKey] != hcloudCluster.Name {
+		patch := controllerclient.MergeFrom(cluster.DeepCopy())
+		if cluster.Labels == nil {
+			cluster.Labels = make(map[string]string)
+		}
+		cluster.Labels[infrav1.ManifestsClusterResourceSetLabelKey] = hcloudCluster.Name
+		if err := r.Patch(ctx, cluster, patch); err != nil {
+			return errors.Wrap(err, "failed to label cluster")
+		}
+	}
+
+	name := resourceSetName(hcloudCluster)
+	resourceName := fmt.Sprintf("%s-%s", name, expectedHash[:resourceSetHashLength])
+	resourceMeta := func(obj metav1.Object) error {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[infrav1.ManifestsClusterResourceSetLabelKey] = hcloudCluster.Name
+		obj.SetLabels(labels)
+		return controllerutil.SetControllerReference(hcloudCluster, obj, r.Scheme)
+	}
+
+	var resources []addonsv1.ResourceRef
+	var written bool
+	if len(content.configMapData) > 0 {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: hcloudCluster.Namespace, Name: resourceName}}
+		if result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+			configMap.Data = content.configMapData
+			return resourceMeta(configMap)
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write configmap %s", resourceName)
+		} else if result != controllerutil.OperationResultNone {
+			written = true
+		}
+		resources = append(resources, addonsv1.ResourceRef{Name: resourceName, Kind: string(addonsv1.ConfigMapClusterResourceSetResourceKind)})
+	}
+	if len(content.secretData) > 0 {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: hcloudCluster.Namespace, Name: resourceName}}
+		if result, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+			secret.Type = addonsv1.ClusterResourceSetSecretType
+			secret.Data = content.secretData
+			return resourceMeta(secret)
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write secret %s", resourceName)
+		} else if result != controllerutil.OperationResultNone {
+			written = true
+		}
+		resources = append(resources, addonsv1.ResourceRef{Name: resourceName, Kind: string(addonsv1.SecretClusterResourceSetResourceKind)})
+	}
+
+	crs := &addonsv1.ClusterResourceSet{ObjectMeta: metav1.ObjectMeta{Namespace: hcloudCluster.Namespace, Name: name}}
+	if result, err := controllerutil.CreateOrUpdate(ctx, r.Client, crs, func() error {
+		crs.Spec.ClusterSelector = metav1.LabelSelector{
+			MatchLabels: map[string]string{infrav1.ManifestsClusterResourceSetLabelKey: hcloudCluster.Name},
+		}
+		crs.Spec.Resources = resources
+		return controllerutil.SetControllerReference(hcloudCluster, crs, r.Scheme)
+	}); err != nil {
+		return errors.Wrapf(err, "failed to write clusterresourceset %s", name)
+	} else if result != controllerutil.OperationResultNone {
+		written = true
+	}
+
+	if err := r.deleteStaleResourceSetResources(clusterScope, resourceName); err != nil {
+		return err
+	}
+
+	if written {
+		r.Recorder.Eventf(
+			hcloudCluster,
+			corev1.EventTypeNormal,
+			"ClusterResourceSetUpdated",
+			"Manifests (hash=%s) have been written to ClusterResourceSet %s",
+			expectedHash,
+			name,
+		)
+	}
+
+	if status.AppliedHash != nil && *status.AppliedHash == expectedHash {
+		return nil
+	}
+	applied, err := r.resourceSetApplied(clusterScope, name, resources)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return errResourceSetNotApplied
+	}
+
+	var myTrue = true
+	status.Initialized = &myTrue
+	status.AppliedHash = &expectedHash
+	status.AppliedObjects = appliedObjects(manifestObjects)
+	for _, addonType := range infrav1.HcloudClusterAddonTypes {
+		addon := hcloudCluster.Spec.Addons.Get(addonType)
+		if addon == nil {
+			status.RemoveAddon(addonType)
+			continue
+		}
+		status.SetAddon(infrav1.HcloudClusterAddonStatus{
+			Type:           addonType,
+			Name:           addon.Name,
+			Version:        addon.Version,
+			AppliedHash:    &expectedHash,
+			AppliedObjects: appliedObjects(addons[addonType]),
+		})
+	}
+
+	r.Recorder.Eventf(
+		hcloudCluster,
+		corev1.EventTypeNormal,
+		"ClusterResourceSetApplied",
+		"Manifests (hash=%s) have been applied by ClusterResourceSet %s",
+		expectedHash,
+		name,
+	)
+	return nil
+}
+
+// resourceSetApplied returns true, if the ClusterResourceSetBinding of the
+// cluster shows all resources of the ClusterResourceSet as applied
+func (r *HcloudClusterReconciler) resourceSetApplied(clusterScope *scope.ClusterScope, name string, resources []addonsv1.ResourceRef) (bool, error) {
+	cluster := clusterScope.Cluster
+
+	binding := &addonsv1.ClusterResourceSetBinding{}
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	if err := r.Get(clusterScope.Ctx, key, binding); apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to get clusterresourcesetbinding %s", key)
+	}
+
+	for _, b := range binding.Spec.Bindings {
+		if b.ClusterResourceSetName != name {
+			continue
+		}
+		for _, resource := range resources {
+			if !b.IsApplied(resource) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// deleteStaleResourceSetResources deletes the ConfigMaps and Secrets of
+// previous manifests, their objects stay in the workload cluster
+func (r *HcloudClusterReconciler) deleteStaleResourceSetResources(clusterScope *scope.ClusterScope, current string) error {
+	hcloudCluster := clusterScope.HcloudCluster
+	ctx := clusterScope.Ctx
+	selector := controllerclient.MatchingLabels{infrav1.ManifestsClusterResourceSetLabelKey: hcloudCluster.Name}
+
+	var configMaps corev1.ConfigMapList
+	if err := r.List(ctx, &configMaps, controllerclient.InNamespace(hcloudCluster.Namespace), selector); err != nil {
+		return errors.Wrap(err, "failed to list configmaps of clusterresourceset")
+	}
+	for pos := range configMaps.Items {
+		if configMaps.Items[pos].Name == current {
+			continue
+		}
+		if err := r.Delete(ctx, &configMaps.Items[pos]); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete configmap %s", configMaps.Items[pos].Name)
+		}
+	}
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, controllerclient.InNamespace(hcloudCluster.Namespace), selector); err != nil {
+		return errors.Wrap(err, "failed to list secrets of clusterresourceset")
+	}
+	for pos := range secrets.Items {
+		if secrets.Items[pos].Name == current {
+			continue
+		}
+		if err := r.Delete(ctx, &secrets.Items[pos]); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete secret %s", secrets.Items[pos].Name)
+		}
+	}
+	return nil
+}
+
+// deleteClusterResourceSet removes the ClusterResourceSet and its resources
+// after switching to Apply mode, the applied objects are taken over by the
+// next apply
+func (r *HcloudClusterReconciler) deleteClusterResourceSet(clusterScope *scope.ClusterScope) error {
+	hcloudCluster := clusterScope.HcloudCluster
+	cluster := clusterScope.Cluster
+	if _, ok := cluster.Labels[infrav1.ManifestsClusterResourceSetLabelKey]; !ok {
+		return nil
+	}
+
+	crs := &addonsv1.ClusterResourceSet{ObjectMeta: metav1.ObjectMeta{Namespace: hcloudCluster.Namespace, Name: resourceSetName(hcloudCluster)}}
+	if err := r.Delete(clusterScope.Ctx, crs); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return errors.Wrapf(err, "failed to delete clusterresourceset %s", crs.Name)
+	}
+	if err := r.deleteStaleResourceSetResources(clusterScope, ""); err != nil {
+		return err
+	}
+
+	patch := controllerclient.MergeFrom(cluster.DeepCopy())
+	delete(cluster.Labels, infrav1.ManifestsClusterResourceSetLabelKey)
+	if err := r.Patch(clusterScope.Ctx, cluster, patch); err != nil {
+		return errors.Wrap(err, "failed to remove label from cluster")
+	}
+	return nil
+}
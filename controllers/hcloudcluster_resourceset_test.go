@@ -0,0 +1,178 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func TestReconcileClusterResourceSet(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ctx := context.TODO()
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234"},
+		Spec: infrav1.HcloudClusterSpec{
+			ControlPlaneEndpoint: &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+			Manifests: &infrav1.HcloudClusterManifestsSpec{
+				Mode: infrav1.HcloudClusterManifestsModeClusterResourceSet,
+			},
+		},
+	}
+	c := newTestClient(cluster, hcloudCluster)
+
+	manifests := mock_scope.NewMockManifests(mockCtrl)
+	manifests.EXPECT().Enabled().Return(true).AnyTimes()
+	manifests.EXPECT().Render(gomock.Any()).DoAndReturn(func(interface{}) ([]*unstructured.Unstructured, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace("kube-system")
+		obj.SetName("config")
+		return []*unstructured.Unstructured{obj}, nil
+	}).AnyTimes()
+
+	clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+		Ctx:    ctx,
+		Client: c,
+		HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+			return mock_scope.NewMockHcloudClient(mockCtrl), nil
+		},
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        mock_scope.NewMockPacker(mockCtrl),
+		Manifests:     manifests,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &HcloudClusterReconciler{
+		Client:   c,
+		Recorder: recorder,
+		Scheme:   newTestScheme(),
+	}
+	crsKey := types.NamespacedName{Namespace: "default", Name: "test-manifests"}
+	expectEvent := func(expected string) {
+		t.Helper()
+		select {
+		case actual := <-recorder.Events:
+			if !strings.HasPrefix(actual, expected) {
+				t.Errorf("expected event %q, got %q", expected, actual)
+			}
+		default:
+			t.Errorf("expected event %q", expected)
+		}
+	}
+
+	// the status is not updated before the resources have been applied
+	if err := r.reconcileClusterResourceSet(clusterScope); err != errResourceSetNotApplied {
+		t.Fatalf("expected %v, got %v", errResourceSetNotApplied, err)
+	}
+	expectEvent("Normal ClusterResourceSetUpdated")
+	if status := hcloudCluster.Status.Manifests; status.AppliedHash != nil || status.Initialized != nil || len(status.AppliedObjects) > 0 {
+		t.Errorf("expected status not to be applied, got %+v", status)
+	}
+
+	var crs addonsv1.ClusterResourceSet
+	if err := c.Get(ctx, crsKey, &crs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(crs.Spec.Resources) != 1 {
+		t.Fatalf("expected one resource, got %v", crs.Spec.Resources)
+	}
+	var configMap corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: crs.Spec.Resources[0].Name}, &configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the binding shows the resources as applied
+	binding := &addonsv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: addonsv1.ClusterResourceSetBindingSpec{
+			Bindings: []*addonsv1.ResourceSetBinding{{
+				ClusterResourceSetName: crsKey.Name,
+				Resources: []addonsv1.ResourceBinding{{
+					ResourceRef: crs.Spec.Resources[0],
+					Applied:     true,
+				}},
+			}},
+		},
+	}
+	if err := c.Create(ctx, binding); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.reconcileClusterResourceSet(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectEvent("Normal ClusterResourceSetApplied")
+	status := hcloudCluster.Status.Manifests
+	if status.AppliedHash == nil || status.Initialized == nil || !*status.Initialized {
+		t.Errorf("expected status to be applied, got %+v", status)
+	}
+	if expected := []infrav1.HcloudClusterManifestObject{{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "config"}}; len(status.AppliedObjects) != 1 || status.AppliedObjects[0] != expected[0] {
+		t.Errorf("expected applied objects %v, got %v", expected, status.AppliedObjects)
+	}
+
+	// a deleted ClusterResourceSet is recreated, though the hash matches
+	if err := c.Delete(ctx, &crs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.reconcileClusterResourceSet(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectEvent("Normal ClusterResourceSetUpdated")
+	if err := c.Get(ctx, crsKey, &crs); err != nil {
+		t.Errorf("expected ClusterResourceSet to be recreated, got %v", err)
+	}
+
+	// unchanged resources are not written again
+	if err := r.reconcileClusterResourceSet(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.Events) > 0 {
+		t.Errorf("unexpected event %q", <-recorder.Events)
+	}
+
+	// switching to Apply mode deletes the ClusterResourceSet
+	if err := r.deleteClusterResourceSet(clusterScope); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Get(ctx, crsKey, &crs); !apierrors.IsNotFound(err) {
+		t.Errorf("expected ClusterResourceSet to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,471 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	recorder "k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+const (
+	// targetClusterHealthCheckInterval is the interval in which the API
+	// server of a workload cluster is probed
+	targetClusterHealthCheckInterval = 30 * time.Second
+
+	// targetClusterHealthCheckTimeout is the timeout of a single probe
+	targetClusterHealthCheckTimeout = 10 * time.Second
+
+	// targetClusterRestartTimeout is the duration after which the manager of
+	// an unreachable workload cluster is restarted with fresh credentials
+	targetClusterRestartTimeout = 5 * time.Minute
+
+	// targetClusterEventsBuffer is the number of pending reconcile triggers
+	// of all targetClusterManagers
+	targetClusterEventsBuffer = 64
+)
+
+// targetClusterManager runs the guest reconcilers of a workload cluster and
+// monitors the health of its API server
+type targetClusterManager struct {
+	ctrl.Manager
+	stopCh chan struct{}
+
+	// kubeconfigHash identifies the kubeconfig the manager has been created
+	// with
+	kubeconfigHash string
+
+	// clientSet is used for the health checks
+	clientSet kubernetes.Interface
+
+	lock   sync.Mutex
+	health targetClusterHealth
+}
+
+// targetClusterHealth is the last known state of a targetClusterManager
+type targetClusterHealth struct {
+	// Checked is true after the first health check
+	Checked bool
+	Healthy bool
+	Err     error
+
+	// UnhealthySince is the time of the first of consecutive failed health
+	// checks
+	UnhealthySince time.Time
+
+	// Exited is true once the manager stopped
+	Exited  bool
+	ExitErr error
+}
+
+func (m *targetClusterManager) Health() targetClusterHealth {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.health
+}
+
+// Stop stops the manager and its health monitoring
+func (m *targetClusterManager) Stop() {
+	select {
+	case <-m.stopCh:
+	default:
+		close(m.stopCh)
+	}
+}
+
+func (m *targetClusterManager) stopped() bool {
+	select {
+	case <-m.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// run starts the manager and blocks until it exits. onExit is called if the
+// manager exited without being stopped.
+func (m *targetClusterManager) run(onExit func(error)) {
+	err := m.Start(m.stopCh)
+
+	m.lock.Lock()
+	m.health.Exited = true
+	m.health.ExitErr = err
+	m.lock.Unlock()
+
+	if !m.stopped() {
+		onExit(err)
+	}
+}
+
+// monitor checks the health of the API server until the manager is stopped.
+// onChange is called if the API server becomes healthy or unhealthy and once
+// it is unhealthy for longer than the restart timeout.
+func (m *targetClusterManager) monitor(onChange func()) {
+	ticker := time.NewTicker(targetClusterHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if m.checkHealth() {
+			onChange()
+		}
+
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHealth probes the API server and returns true if the state changed in
+// a way the reconciler has to act on
+func (m *targetClusterManager) checkHealth() bool {
+	_, err := m.clientSet.Discovery().ServerVersion()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	previous := m.health
+	m.health.Checked = true
+	m.health.Healthy = err == nil
+	m.health.Err = err
+
+	if err == nil {
+		m.health.UnhealthySince = time.Time{}
+		return !previous.Checked || !previous.Healthy
+	}
+
+	now := time.Now()
+	if previous.UnhealthySince.IsZero() {
+		m.health.UnhealthySince = now
+		return true
+	}
+
+	// notify once the restart timeout has been exceeded
+	restartAt := m.health.UnhealthySince.Add(targetClusterRestartTimeout)
+	return now.After(restartAt) && now.Add(-targetClusterHealthCheckInterval).Before(restartAt)
+}
+
+// targetClusterKubeconfigHash identifies the kubeconfig of the workload
+// cluster to detect rotated credentials and changed endpoints
+func targetClusterKubeconfigHash(clientConfig clientcmd.ClientConfig) (string, error) {
+	raw, err := clientConfig.RawConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "error retrieving rawConfig from clientConfig")
+	}
+	data, err := clientcmd.Write(raw)
+	if err != nil {
+		return "", errors.Wrap(err, "error serializing kubeconfig")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+func (r *HcloudClusterReconciler) reconcileTargetClusterManager(clusterScope *scope.ClusterScope) error {
+	hcloudCluster := clusterScope.HcloudCluster
+	deleted := !hcloudCluster.DeletionTimestamp.IsZero()
+
+	r.targetClusterManagersLock.Lock()
+	defer r.targetClusterManagersLock.Unlock()
+	key := types.NamespacedName{
+		Namespace: hcloudCluster.Namespace,
+		Name:      hcloudCluster.Name,
+	}
+	m, ok := r.targetClusterManagers[key]
+
+	if deleted {
+		if ok {
+			m.Stop()
+			delete(r.targetClusterManagers, key)
+		}
+		return nil
+	}
+
+	clientConfig, err := clusterScope.ClientConfig()
+	if apierrors.IsNotFound(errors.Cause(err)) {
+		// the kubeconfig secret is watched, so the manager is started once
+		// it has been created
+		conditions.MarkFalse(hcloudCluster, infrav1.RemoteClusterConnectedCondition, infrav1.RemoteClusterConnectingReason, clusterv1.ConditionSeverityInfo, "kubeconfig not yet available")
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get a clientConfig for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+	kubeconfigHash, err := targetClusterKubeconfigHash(clientConfig)
+	if err != nil {
+		return errors.Wrapf(err, "failed to hash the kubeconfig of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	if ok {
+		health := m.Health()
+
+		var eventType, restartReason string
+		switch {
+		case health.Exited:
+			eventType = corev1.EventTypeWarning
+			restartReason = fmt.Sprintf("manager exited: %v", health.ExitErr)
+		case m.kubeconfigHash != kubeconfigHash:
+			eventType = corev1.EventTypeNormal
+			restartReason = "kubeconfig has changed"
+		case !health.Healthy && !health.UnhealthySince.IsZero() && time.Since(health.UnhealthySince) > targetClusterRestartTimeout:
+			eventType = corev1.EventTypeWarning
+			restartReason = fmt.Sprintf("API server unreachable since %s: %v", health.UnhealthySince.Format(time.RFC3339), health.Err)
+		}
+
+		if restartReason == "" {
+			setRemoteClusterConnectedCondition(hcloudCluster, health)
+			return nil
+		}
+
+		m.Stop()
+		delete(r.targetClusterManagers, key)
+		r.Recorder.Eventf(
+			hcloudCluster,
+			eventType,
+			"TargetClusterManagerRestarted",
+			"Restarting the manager of the workload cluster: %s",
+			restartReason,
+		)
+	}
+
+	// create a new cluster manager
+	m, err = r.newTargetClusterManager(clusterScope, clientConfig)
+	if err != nil {
+		conditions.MarkFalse(hcloudCluster, infrav1.RemoteClusterConnectedCondition, infrav1.RemoteClusterManagerFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrapf(err, "failed to create a clusterManager for HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+	m.kubeconfigHash = kubeconfigHash
+	r.targetClusterManagers[key] = m
+
+	go m.run(func(err error) {
+		if err != nil {
+			clusterScope.Error(err, "targetClusterManager exited")
+		} else {
+			clusterScope.Info("targetClusterManager exited")
+		}
+		r.enqueueTargetCluster(key, m.stopCh)
+	})
+	go m.monitor(func() {
+		r.enqueueTargetCluster(key, m.stopCh)
+	})
+
+	setRemoteClusterConnectedCondition(hcloudCluster, m.Health())
+	return nil
+}
+
+func (r *HcloudClusterReconciler) newTargetClusterManager(clusterScope *scope.ClusterScope, clientConfig clientcmd.ClientConfig) (*targetClusterManager, error) {
+	hcloudCluster := clusterScope.HcloudCluster
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get a restConfig for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get a clientSet for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	healthConfig := rest.CopyConfig(restConfig)
+	healthConfig.Timeout = targetClusterHealthCheckTimeout
+	healthClientSet, err := kubernetes.NewForConfig(healthConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get a health check clientSet for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get a dynamic client for the API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	csrGroupVersion, err := csrGroupVersion(clientSet.Discovery())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover the CSR API of HcloudCluster %s/%s", hcloudCluster.Namespace, hcloudCluster.Name)
+	}
+
+	scheme := runtime.NewScheme()
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	clusterMgr, err := ctrl.NewManager(
+		restConfig,
+		ctrl.Options{
+			Scheme:             scheme,
+			MetricsBindAddress: "0",
+			LeaderElection:     false,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to setup guest cluster manager")
+	}
+
+	gr := &GuestCSRReconciler{
+		Client: clusterMgr.GetClient(),
+		Log:    r.Log,
+		mCluster: &managementCluster{
+			Client:        r.Client,
+			hcloudCluster: hcloudCluster,
+			recorder:      r.Recorder,
+		},
+		dynamicClient: dynamicClient,
+		groupVersion:  csrGroupVersion,
+		DNSSuffixes:   r.CSRDNSSuffixes,
+		ReportOnly:    r.CSRReportOnly,
+	}
+
+	if err := gr.SetupWithManager(clusterMgr, controller.Options{}); err != nil {
+		return nil, errors.Wrapf(err, "failed to setup CSR controller")
+	}
+
+	ar := &GuestAddonReconciler{
+		Client: clusterMgr.GetClient(),
+		Log:    r.Log.WithName("addons"),
+		mCluster: &managementCluster{
+			Client:        r.Client,
+			hcloudCluster: hcloudCluster,
+			recorder:      r.Recorder,
+		},
+	}
+
+	if err := ar.SetupWithManager(clusterMgr, controller.Options{}); err != nil {
+		return nil, errors.Wrapf(err, "failed to setup addon controller")
+	}
+
+	nr := &GuestNodeReconciler{
+		Client: clusterMgr.GetClient(),
+		Log:    r.Log.WithName("nodes"),
+		mCluster: &managementCluster{
+			Client:        r.Client,
+			hcloudCluster: hcloudCluster,
+			recorder:      r.Recorder,
+		},
+	}
+
+	if err := nr.SetupWithManager(clusterMgr, controller.Options{}); err != nil {
+		return nil, errors.Wrapf(err, "failed to setup node controller")
+	}
+
+	return &targetClusterManager{
+		Manager:   clusterMgr,
+		stopCh:    make(chan struct{}),
+		clientSet: healthClientSet,
+	}, nil
+}
+
+// setRemoteClusterConnectedCondition reports the health of the workload
+// cluster API server
+func setRemoteClusterConnectedCondition(hcloudCluster *infrav1.HcloudCluster, health targetClusterHealth) {
+	switch {
+	case health.Healthy:
+		conditions.MarkTrue(hcloudCluster, infrav1.RemoteClusterConnectedCondition)
+	case health.Err != nil:
+		conditions.MarkFalse(hcloudCluster, infrav1.RemoteClusterConnectedCondition, infrav1.RemoteClusterUnreachableReason, clusterv1.ConditionSeverityWarning, "%s", health.Err)
+	default:
+		conditions.MarkFalse(hcloudCluster, infrav1.RemoteClusterConnectedCondition, infrav1.RemoteClusterConnectingReason, clusterv1.ConditionSeverityInfo, "")
+	}
+}
+
+// enqueueTargetCluster triggers a reconcile of the HcloudCluster after a
+// change of the health of its targetClusterManager. It gives up once the
+// manager is stopped, so a full channel does not block the manager's
+// goroutines forever.
+func (r *HcloudClusterReconciler) enqueueTargetCluster(key types.NamespacedName, stopCh <-chan struct{}) {
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+		},
+	}
+	select {
+	case r.targetClusterEvents <- event.GenericEvent{
+		Meta:   hcloudCluster,
+		Object: hcloudCluster,
+	}:
+	case <-stopCh:
+	}
+}
+
+// kubeconfigSecretToHcloudCluster maps the kubeconfig secret of a Cluster to
+// its HcloudCluster, so a rotated kubeconfig restarts the targetClusterManager
+func (r *HcloudClusterReconciler) kubeconfigSecretToHcloudCluster(o handler.MapObject) []ctrl.Request {
+	clusterName, ok := o.Meta.GetLabels()[clusterv1.ClusterLabelName]
+	if !ok || o.Meta.GetName() != secret.Name(clusterName, secret.Kubeconfig) {
+		return nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		return nil
+	}
+
+	return util.ClusterToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("HcloudCluster"))(handler.MapObject{
+		Meta:   cluster,
+		Object: cluster,
+	})
+}
+
+var _ ManagementCluster = &managementCluster{}
+
+type managementCluster struct {
+	controllerclient.Client
+	hcloudCluster *infrav1.HcloudCluster
+	recorder      recorder.EventRecorder
+}
+
+func (c *managementCluster) Namespace() string {
+	return c.hcloudCluster.Namespace
+}
+
+func (c *managementCluster) Name() string {
+	return c.hcloudCluster.Name
+}
+
+func (c *managementCluster) Event(eventtype, reason, message string) {
+	c.recorder.Event(c.hcloudCluster, eventtype, reason, message)
+}
+
+func (c *managementCluster) Eventf(eventtype, reason, message string, args ...interface{}) {
+	c.recorder.Eventf(c.hcloudCluster, eventtype, reason, message, args...)
+}
+
+func (c *managementCluster) ObjectEventf(object runtime.Object, eventtype, reason, message string, args ...interface{}) {
+	c.recorder.Eventf(object, eventtype, reason, message, args...)
+}
@@ -54,6 +54,7 @@ type HcloudMachineReconciler struct {
 // +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudmachines,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudmachines/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
 
 func (r *HcloudMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.TODO()
@@ -179,6 +180,12 @@ func (r *HcloudMachineReconciler) reconcileNormal(machineScope *scope.MachineSco
 	machineScope.Info("Reconciling HcloudMachine")
 	hcloudMachine := machineScope.HcloudMachine
 
+	// A machine with a terminal failure is replaced by Cluster API
+	if hcloudMachine.Status.FailureReason != nil {
+		machineScope.Info("HcloudMachine has failed, waiting for its replacement", "reason", *hcloudMachine.Status.FailureReason)
+		return reconcile.Result{}, nil
+	}
+
 	// If the HcloudMachine doesn't have our finalizer, add it.
 	controllerutil.AddFinalizer(machineScope.HcloudMachine, infrav1.MachineFinalizer)
 
@@ -210,6 +217,11 @@ func (r *HcloudMachineReconciler) SetupWithManager(mgr ctrl.Manager, options con
 			&source.Kind{Type: &infrav1.HcloudCluster{}},
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.HcloudClusterToHcloudMachines)},
 		).
+		// Reconcile on external changes of the server.
+		Watches(
+			&source.Channel{Source: scope.DefaultHcloudClientCache.MachineEvents()},
+			&handler.EnqueueRequestForObject{},
+		).
 		Complete(r)
 }
 
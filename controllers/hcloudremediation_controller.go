@@ -0,0 +1,358 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/baremetal"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/server"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+)
+
+// HcloudRemediationReconciler reconciles a HcloudRemediation object. It
+// implements the external remediation of a MachineHealthCheck by rebooting
+// the server of the unhealthy machine, the MachineHealthCheck deletes the
+// HcloudRemediation once the machine is healthy again. After the retry limit
+// has been exceeded, bare metal servers are optionally reinstalled, then the
+// machine is handed back to its owner for deletion.
+type HcloudRemediationReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Packer    *packer.Packer
+	Manifests *manifests.Manifests
+	Recorder  record.EventRecorder
+
+	// HcloudClientFactory overrides the creation of the HcloudClient from
+	// the token of the HcloudCluster
+	HcloudClientFactory scope.HcloudClientFactory
+}
+
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudremediations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudremediations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=hcloudremediationtemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster-api-provider-hcloud.capihc.com,resources=baremetalmachines;baremetalmachines/status,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;patch
+
+func (r *HcloudRemediationReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.TODO()
+	log := r.Log.WithValues("namespace", req.Namespace, "hcloudRemediation", req.Name)
+
+	// Fetch the HcloudRemediation instance
+	remediation := &infrav1.HcloudRemediation{}
+	if err := r.Get(ctx, req.NamespacedName, remediation); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !remediation.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	// Fetch the Machine
+	machine, err := util.GetOwnerMachine(ctx, r.Client, remediation.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if machine == nil {
+		log.Info("MachineHealthCheck has not yet set OwnerRef")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("machine", machine.Name)
+
+	// Fetch the Cluster.
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, machine.ObjectMeta)
+	if err != nil {
+		log.Info("Machine is missing cluster label or cluster does not exist")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("cluster", cluster.Name)
+
+	if util.IsPaused(cluster, remediation) {
+		log.Info("HcloudRemediation or linked Cluster is marked as paused. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(remediation, r.Client)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to init patch helper")
+	}
+
+	// Always patch the remediation when exiting this function so we can persist any changes.
+	defer func() {
+		if err := patchHelper.Patch(ctx, remediation); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	// the machine has been handed back to its owner
+	if remediation.Status.Phase == infrav1.RemediationPhaseDeleting {
+		return reconcile.Result{}, nil
+	}
+
+	// wait for the node to become healthy after the last reboot
+	timeout := remediation.Spec.GetTimeout().Duration
+	if last := remediation.Status.LastRemediated; last != nil {
+		if wait := time.Until(last.Add(timeout)); wait > 0 {
+			return reconcile.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	// once the retry limit has been exceeded, the server is optionally
+	// reinstalled a single time before the machine is handed back
+	reinstall := remediation.Status.RetryCount >= remediation.Spec.GetRetryLimit()
+	if reinstall && (!remediation.Spec.GetReinstall() || remediation.Status.Reinstalled) {
+		return reconcile.Result{}, r.escalate(ctx, remediation, machine)
+	}
+
+	rebooter, err := r.newRebooter(ctx, log, machine, cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if rebooter == nil {
+		return reconcile.Result{}, nil
+	}
+
+	if reinstall {
+		return r.reinstall(ctx, remediation, machine, rebooter)
+	}
+
+	// the first reboot is a soft reboot, further reboots reset the server
+	soft := remediation.Status.RetryCount == 0
+	if err := rebooter.Reboot(ctx, soft); err != nil {
+		r.Recorder.Eventf(
+			remediation,
+			corev1.EventTypeWarning,
+			"FailedReboot",
+			"Failed to reboot the server of machine %s: %s",
+			machine.Name,
+			err,
+		)
+		return reconcile.Result{}, err
+	}
+
+	now := metav1.Now()
+	remediation.Status.Phase = infrav1.RemediationPhaseRunning
+	remediation.Status.RetryCount++
+	remediation.Status.LastRemediated = &now
+
+	kind := "Reset"
+	if soft {
+		kind = "Soft rebooted"
+	}
+	r.Recorder.Eventf(
+		remediation,
+		corev1.EventTypeNormal,
+		"ServerRebooted",
+		"%s the server of machine %s (attempt %d of %d)",
+		kind,
+		machine.Name,
+		remediation.Status.RetryCount,
+		remediation.Spec.GetRetryLimit(),
+	)
+
+	return reconcile.Result{RequeueAfter: timeout}, nil
+}
+
+// rebooter reboots the server of a machine
+type rebooter interface {
+	Reboot(ctx context.Context, soft bool) error
+}
+
+// reinstaller fails a machine for the reinstallation of its server
+type reinstaller interface {
+	Reinstall(ctx context.Context) error
+}
+
+// reinstall fails the machine for the reinstallation of its server, if
+// supported by its infrastructure, then the machine is handed back to its
+// owner, which replaces it
+func (r *HcloudRemediationReconciler) reinstall(ctx context.Context, remediation *infrav1.HcloudRemediation, machine *clusterv1.Machine, rebooter rebooter) (ctrl.Result, error) {
+	reinstaller, ok := rebooter.(reinstaller)
+	if !ok {
+		return reconcile.Result{}, r.escalate(ctx, remediation, machine)
+	}
+
+	remediation.Status.Reinstalled = true
+	if err := reinstaller.Reinstall(ctx); err != nil {
+		r.Recorder.Eventf(
+			remediation,
+			corev1.EventTypeWarning,
+			"FailedReinstall",
+			"Failed to reinstall the server of machine %s: %s",
+			machine.Name,
+			err,
+		)
+		return reconcile.Result{}, r.escalate(ctx, remediation, machine)
+	}
+
+	r.Recorder.Eventf(
+		remediation,
+		corev1.EventTypeNormal,
+		"ServerReinstallRequested",
+		"Failed machine %s after %d reboots, its server is reinstalled for a replacement",
+		machine.Name,
+		remediation.Status.RetryCount,
+	)
+	return reconcile.Result{}, r.escalate(ctx, remediation, machine)
+}
+
+// newRebooter returns the rebooter for the infrastructure of the machine, nil
+// if the infrastructure is not supported
+func (r *HcloudRemediationReconciler) newRebooter(ctx context.Context, log logr.Logger, machine *clusterv1.Machine, cluster *clusterv1.Cluster) (rebooter, error) {
+	infraRef := machine.Spec.InfrastructureRef
+	if infraRef.Kind != "HcloudMachine" && infraRef.Kind != "BareMetalMachine" {
+		log.Info("Remediation of the machine infrastructure is not supported", "kind", infraRef.Kind)
+		return nil, nil
+	}
+
+	hcloudCluster := &infrav1.HcloudCluster{}
+	hcloudClusterName := client.ObjectKey{
+		Namespace: machine.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+	if err := r.Get(ctx, hcloudClusterName, hcloudCluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get HcloudCluster %s", hcloudClusterName)
+	}
+
+	clusterScopeParams := scope.ClusterScopeParams{
+		Ctx:           ctx,
+		Client:        r.Client,
+		Logger:        log,
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        r.Packer,
+		Manifests:     r.Manifests,
+		Recorder:      r.Recorder,
+
+		HcloudClientFactory: r.HcloudClientFactory,
+	}
+	infraName := client.ObjectKey{Namespace: machine.Namespace, Name: infraRef.Name}
+
+	if infraRef.Kind == "BareMetalMachine" {
+		bareMetalMachine := &infrav1.BareMetalMachine{}
+		if err := r.Get(ctx, infraName, bareMetalMachine); err != nil {
+			return nil, errors.Wrapf(err, "failed to get BareMetalMachine %s", infraName)
+		}
+
+		bareMetalMachineScope, err := scope.NewBareMetalMachineScope(scope.BareMetalMachineScopeParams{
+			ClusterScopeParams: clusterScopeParams,
+			Machine:            machine,
+			BareMetalMachine:   bareMetalMachine,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to create scope: %+v", err)
+		}
+
+		return &bareMetalRebooter{
+			Service: baremetal.NewService(bareMetalMachineScope),
+			scope:   bareMetalMachineScope,
+		}, nil
+	}
+
+	hcloudMachine := &infrav1.HcloudMachine{}
+	if err := r.Get(ctx, infraName, hcloudMachine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get HcloudMachine %s", infraName)
+	}
+
+	machineScope, err := scope.NewMachineScope(scope.MachineScopeParams{
+		ClusterScopeParams: clusterScopeParams,
+		Machine:            machine,
+		HcloudMachine:      hcloudMachine,
+	})
+	if err != nil {
+		return nil, errors.Errorf("failed to create scope: %+v", err)
+	}
+
+	return server.NewService(machineScope), nil
+}
+
+// bareMetalRebooter persists the remediation attempts, which the bare metal
+// service records in the status of the BareMetalMachine
+type bareMetalRebooter struct {
+	*baremetal.Service
+	scope *scope.BareMetalMachineScope
+}
+
+func (b *bareMetalRebooter) Reboot(ctx context.Context, soft bool) error {
+	return b.persist(b.Service.Reboot(ctx, soft))
+}
+
+func (b *bareMetalRebooter) Reinstall(ctx context.Context) error {
+	return b.persist(b.Service.Reinstall(ctx))
+}
+
+func (b *bareMetalRebooter) persist(err error) error {
+	if patchErr := b.scope.Close(); patchErr != nil && err == nil {
+		return errors.Wrap(patchErr, "failed to patch BareMetalMachine")
+	}
+	return err
+}
+
+// escalate hands the machine back to its owner, which deletes it, as done
+// by a MachineHealthCheck without external remediation
+func (r *HcloudRemediationReconciler) escalate(ctx context.Context, remediation *infrav1.HcloudRemediation, machine *clusterv1.Machine) error {
+	patchHelper, err := patch.NewHelper(machine, r.Client)
+	if err != nil {
+		return errors.Wrap(err, "failed to init patch helper")
+	}
+
+	conditions.MarkFalse(machine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "")
+	if err := patchHelper.Patch(ctx, machine); err != nil {
+		return errors.Wrapf(err, "failed to mark machine %s for remediation by its owner", machine.Name)
+	}
+
+	remediation.Status.Phase = infrav1.RemediationPhaseDeleting
+	r.Recorder.Eventf(
+		remediation,
+		corev1.EventTypeWarning,
+		"RemediationFailed",
+		"Machine %s is still unhealthy after %d reboots, it is going to be deleted",
+		machine.Name,
+		remediation.Status.RetryCount,
+	)
+	return nil
+}
+
+func (r *HcloudRemediationReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&infrav1.HcloudRemediation{}).
+		Complete(r)
+}
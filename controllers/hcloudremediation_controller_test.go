@@ -0,0 +1,274 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func TestHcloudRemediationReconciler(t *testing.T) {
+	retryLimit := 2
+	timeout := 10 * time.Minute
+	now := metav1.Now()
+	expired := metav1.NewTime(now.Add(-timeout - time.Minute))
+
+	for _, tc := range []struct {
+		name          string
+		reinstall     bool
+		status        infrav1.HcloudRemediationStatus
+		expectHcloud  func(hc *mock_scope.MockHcloudClient, server *hcloud.Server)
+		expectErr     bool
+		expectRequeue bool
+		expectStatus  infrav1.HcloudRemediationStatus
+		expectEvent   string
+	}{
+		{
+			name: "soft reboot first",
+			expectHcloud: func(hc *mock_scope.MockHcloudClient, server *hcloud.Server) {
+				hc.EXPECT().GetServerByID(gomock.Any(), server.ID).Return(server, nil, nil)
+				hc.EXPECT().RebootServer(gomock.Any(), server).Return(nil, nil, nil)
+			},
+			expectRequeue: true,
+			expectStatus:  infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 1},
+			expectEvent:   "Normal ServerRebooted Soft rebooted",
+		},
+		{
+			name:   "reset after the timeout",
+			status: infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 1, LastRemediated: &expired},
+			expectHcloud: func(hc *mock_scope.MockHcloudClient, server *hcloud.Server) {
+				hc.EXPECT().GetServerByID(gomock.Any(), server.ID).Return(server, nil, nil)
+				hc.EXPECT().ResetServer(gomock.Any(), server).Return(nil, nil, nil)
+			},
+			expectRequeue: true,
+			expectStatus:  infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 2},
+			expectEvent:   "Normal ServerRebooted Reset",
+		},
+		{
+			name:          "wait for the timeout",
+			status:        infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 1, LastRemediated: &now},
+			expectRequeue: true,
+			expectStatus:  infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 1},
+		},
+		{
+			name: "failed reboot",
+			expectHcloud: func(hc *mock_scope.MockHcloudClient, server *hcloud.Server) {
+				hc.EXPECT().GetServerByID(gomock.Any(), server.ID).Return(server, nil, nil)
+				hc.EXPECT().RebootServer(gomock.Any(), server).Return(nil, nil, errors.New("server is locked"))
+			},
+			expectErr:    true,
+			expectStatus: infrav1.HcloudRemediationStatus{},
+			expectEvent:  "Warning FailedReboot",
+		},
+		{
+			name:         "escalate after the retry limit",
+			status:       infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 2, LastRemediated: &expired},
+			expectStatus: infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseDeleting, RetryCount: 2},
+			expectEvent:  "Warning RemediationFailed",
+		},
+		{
+			// the servers of HcloudMachines cannot be reinstalled
+			name:         "escalate instead of reinstalling",
+			reinstall:    true,
+			status:       infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 2, LastRemediated: &expired},
+			expectStatus: infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseDeleting, RetryCount: 2},
+			expectEvent:  "Warning RemediationFailed",
+		},
+		{
+			name:         "handed back to the owner",
+			status:       infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseDeleting, RetryCount: 2, LastRemediated: &expired},
+			expectStatus: infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseDeleting, RetryCount: 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &corev1.ObjectReference{Kind: "HcloudCluster", Name: "test"},
+				},
+			}
+			hcloudCluster := &infrav1.HcloudCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+			}
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "test-worker",
+					Labels:    map[string]string{clusterv1.ClusterLabelName: "test"},
+					// the conditions are patched with an optimistic lock
+					ResourceVersion: "1",
+				},
+				Spec: clusterv1.MachineSpec{
+					ClusterName:       "test",
+					InfrastructureRef: corev1.ObjectReference{Kind: "HcloudMachine", Name: "test-worker"},
+				},
+			}
+			serverID := 1
+			hcloudMachine := &infrav1.HcloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+				Status:     infrav1.HcloudMachineStatus{ServerID: &serverID},
+			}
+			remediation := &infrav1.HcloudRemediation{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "test-worker",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: clusterv1.GroupVersion.String(),
+						Kind:       "Machine",
+						Name:       "test-worker",
+					}},
+				},
+				Spec: infrav1.HcloudRemediationSpec{
+					Strategy: &infrav1.RemediationStrategy{
+						RetryLimit: &retryLimit,
+						Timeout:    &metav1.Duration{Duration: timeout},
+						Reinstall:  tc.reinstall,
+					},
+				},
+				Status: tc.status,
+			}
+			c := newTestClient(cluster, hcloudCluster, machine, hcloudMachine, remediation)
+
+			serverLabels := hcloudCluster.ResourceLabels()
+			serverLabels[infrav1.MachineNameTagKey] = "test-worker"
+			server := &hcloud.Server{ID: serverID, Name: "test-worker", Labels: serverLabels}
+			hc := mock_scope.NewMockHcloudClient(mockCtrl)
+			if tc.expectHcloud != nil {
+				tc.expectHcloud(hc, server)
+			}
+
+			// the nil Packer and Manifests of the reconciler are unused
+			recorder := record.NewFakeRecorder(10)
+			r := &HcloudRemediationReconciler{
+				Client:   c,
+				Log:      klogr.New(),
+				Recorder: recorder,
+				HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+					return hc, nil
+				},
+			}
+			result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-worker"}})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (result.RequeueAfter > 0) != tc.expectRequeue {
+				t.Errorf("unexpected requeue after %s", result.RequeueAfter)
+			}
+			if result.RequeueAfter > timeout {
+				t.Errorf("expected to requeue within the timeout, got %s", result.RequeueAfter)
+			}
+
+			actual := &infrav1.HcloudRemediation{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "test-worker"}, actual); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual.Status.Phase != tc.expectStatus.Phase {
+				t.Errorf("expected phase %q, got %q", tc.expectStatus.Phase, actual.Status.Phase)
+			}
+			if actual.Status.RetryCount != tc.expectStatus.RetryCount {
+				t.Errorf("expected retry count %d, got %d", tc.expectStatus.RetryCount, actual.Status.RetryCount)
+			}
+			if tc.expectStatus.RetryCount > tc.status.RetryCount {
+				// the time is persisted with a precision of seconds
+				if last := actual.Status.LastRemediated; last == nil || last.Time.Before(now.Add(-time.Second)) {
+					t.Errorf("expected the reboot to be recorded, got %v", last)
+				}
+			}
+
+			// an escalated machine is remediated by its owner
+			actualMachine := &clusterv1.Machine{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "test-worker"}, actualMachine); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			escalated := tc.expectEvent == "Warning RemediationFailed"
+			if conditions.IsFalse(actualMachine, clusterv1.MachineOwnerRemediatedCondition) != escalated {
+				t.Errorf("expected the machine to be escalated: %v, got condition %v", escalated, conditions.Get(actualMachine, clusterv1.MachineOwnerRemediatedCondition))
+			}
+			if escalated && conditions.GetReason(actualMachine, clusterv1.MachineOwnerRemediatedCondition) != clusterv1.WaitingForRemediationReason {
+				t.Errorf("expected reason %s, got %s", clusterv1.WaitingForRemediationReason, conditions.GetReason(actualMachine, clusterv1.MachineOwnerRemediatedCondition))
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if tc.expectEvent == "" || !strings.HasPrefix(event, tc.expectEvent) {
+					t.Errorf("expected event %q, got %q", tc.expectEvent, event)
+				}
+			default:
+				if tc.expectEvent != "" {
+					t.Errorf("expected event %q", tc.expectEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	// the conditions are patched with an optimistic lock
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker", ResourceVersion: "1"},
+	}
+	remediation := &infrav1.HcloudRemediation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+		Status:     infrav1.HcloudRemediationStatus{Phase: infrav1.RemediationPhaseRunning, RetryCount: 2},
+	}
+	c := newTestClient(machine)
+
+	recorder := record.NewFakeRecorder(10)
+	r := &HcloudRemediationReconciler{
+		Client:   c,
+		Recorder: recorder,
+	}
+	if err := r.escalate(context.TODO(), remediation, machine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if remediation.Status.Phase != infrav1.RemediationPhaseDeleting {
+		t.Errorf("expected phase %q, got %q", infrav1.RemediationPhaseDeleting, remediation.Status.Phase)
+	}
+	actual := &clusterv1.Machine{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "test-worker"}, actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	condition := conditions.Get(actual, clusterv1.MachineOwnerRemediatedCondition)
+	if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != clusterv1.WaitingForRemediationReason {
+		t.Errorf("expected the machine to wait for the remediation by its owner, got %v", condition)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning RemediationFailed") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
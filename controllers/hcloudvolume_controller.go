@@ -29,7 +29,9 @@ import (
 	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/cloud/resources/location"
@@ -161,5 +163,10 @@ func (r *HcloudVolumeReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
 		For(&infrav1.HcloudVolume{}).
+		// Reconcile on external changes of the volume.
+		Watches(
+			&source.Channel{Source: scope.DefaultHcloudClientCache.VolumeEvents()},
+			&handler.EnqueueRequestForObject{},
+		).
 		Complete(r)
 }
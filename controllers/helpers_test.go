@@ -0,0 +1,69 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+// newTestScheme returns a scheme, which knows the types of the management
+// cluster
+func newTestScheme() *runtime.Scheme {
+	sch := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(sch)
+	_ = clusterv1.AddToScheme(sch)
+	_ = addonsv1.AddToScheme(sch)
+	_ = infrav1.AddToScheme(sch)
+	return sch
+}
+
+// newTestClient returns a fake client of the test scheme
+func newTestClient(objs ...runtime.Object) client.Client {
+	return fake.NewFakeClientWithScheme(newTestScheme(), objs...)
+}
+
+// newTestClusterScope returns a cluster scope, which uses the given client
+// and HcloudClient
+func newTestClusterScope(t *testing.T, mockCtrl *gomock.Controller, c client.Client, hc scope.HcloudClient, cluster *clusterv1.Cluster, hcloudCluster *infrav1.HcloudCluster) *scope.ClusterScope {
+	clusterScope, err := scope.NewClusterScope(scope.ClusterScopeParams{
+		Ctx:    context.TODO(),
+		Client: c,
+		HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+			return hc, nil
+		},
+		Cluster:       cluster,
+		HcloudCluster: hcloudCluster,
+		Packer:        mock_scope.NewMockPacker(mockCtrl),
+		Manifests:     mock_scope.NewMockManifests(mockCtrl),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return clusterScope
+}
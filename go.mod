@@ -7,14 +7,17 @@ require (
 	github.com/fatih/color v1.9.0
 	github.com/go-logr/logr v0.1.0
 	github.com/golang/mock v1.4.3
+	github.com/google/go-cmp v0.5.0
 	github.com/google/go-jsonnet v0.16.0
 	github.com/hetznercloud/hcloud-go v1.22.0
 	github.com/nl2go/hrobot-go v0.1.3
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.5.1
 	github.com/spf13/cobra v1.0.0
 	github.com/tcnksm/ghr v0.13.0
 	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
 	golang.org/x/sys v0.0.0-20201005172224-997123666555 // indirect
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	gopkg.in/yaml.v3 v3.0.0-20200121175148-a6ecf24a6d71
 	k8s.io/api v0.17.9
 	k8s.io/apimachinery v0.17.9
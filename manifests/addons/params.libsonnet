@@ -0,0 +1,25 @@
+// parameters passed as ext-vars to every addon of the catalog
+local splitList(s) = if s == '' then [] else std.split(s, ',');
+
+{
+  hcloudNetwork: std.extVar('hcloud-network'),
+  kubeAPIServerIPv4: std.extVar('kube-apiserver-ip'),
+  kubeAPIServerDomain: std.extVar('kube-apiserver-domain'),
+  port: std.parseInt(std.extVar('port')),
+  clusterName: std.extVar('cluster-name'),
+  clusterNamespace: std.extVar('cluster-namespace'),
+  podCIDRBlocks: splitList(std.extVar('pod-cidr-blocks')),
+  serviceCIDRBlocks: splitList(std.extVar('service-cidr-blocks')),
+  locations: splitList(std.extVar('locations')),
+  networkZone: std.extVar('network-zone'),
+
+  // podCIDR is the block pod addresses are allocated from by the CNI
+  podCIDR: if std.length(self.podCIDRBlocks) > 0 then self.podCIDRBlocks[0] else '10.244.0.0/16',
+
+  // mtu of the interfaces of hcloud servers attached to a network
+  mtu: 1450,
+
+  // rootServerLabel marks the nodes of bare metal servers of the Hetzner
+  // robot, which are skipped by the hcloud CCM and CSI driver
+  rootServerLabel: 'instance.hetzner.cloud/is-root-server',
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -156,6 +157,11 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 
 	providerID := fmt.Sprintf("hcloud://%d", actualServer.ServerNumber)
 	s.scope.BareMetalMachine.Status.ServerState = "running"
+	s.scope.BareMetalMachine.Status.ServerID = actualServer.ServerNumber
+	s.scope.BareMetalMachine.Status.ServerName = s.serverName()
+	s.scope.BareMetalMachine.Status.Datacenter = actualServer.Dc
+	s.scope.BareMetalMachine.Status.IPv4 = actualServer.ServerIP
+	s.scope.BareMetalMachine.Status.IPv6 = serverIPv6(actualServer)
 	s.scope.BareMetalMachine.Spec.ProviderID = &providerID
 
 	// TODO: Ask for the state of the server and only if it is ready set it to true
@@ -164,6 +170,26 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 	return nil, nil
 }
 
+// serverName is the name of an attached server, which is also the hostname
+// and therefore the node name
+func (s *Service) serverName() string {
+	return s.scope.Cluster.Name + delimiter + *s.scope.BareMetalMachine.Spec.ServerType + delimiter + s.scope.BareMetalMachine.Name
+}
+
+// serverIPv6 returns the ::2 address of the first IPv6 subnet, which is
+// configured by installimage
+func serverIPv6(server *models.Server) string {
+	for _, subnet := range server.Subnet {
+		ip := net.ParseIP(subnet.IP)
+		if ip == nil || ip.To4() != nil {
+			continue
+		}
+		ip[len(ip)-1] = 2
+		return ip.String()
+	}
+	return ""
+}
+
 // looks if a machine of the correct name has been attached already
 func (s *Service) findAttachedMachine(servers []models.Server) (*models.Server, error) {
 
@@ -191,7 +217,7 @@ func (s *Service) findAttachedMachine(servers []models.Server) (*models.Server,
 			"MultipleBareMetalMachines",
 			"Found %v bare metal machines of the name %s attached to the cluster",
 			check, actualServer.ServerName)
-		return nil, errors.Errorf("There are %s servers which are attached to the cluster with name %s", check, actualServer.ServerName)
+		return nil, errors.Errorf("There are %d servers which are attached to the cluster with name %s", check, actualServer.ServerName)
 	} else if check == 0 {
 		// No attached server with the correct name found
 		return nil, nil
@@ -237,7 +263,7 @@ func (s *Service) provisionMachine(ctx context.Context, server models.Server) er
 	// We use SSH so the keys must be specified in a secret
 	sshKeyName, _, privateSSHKey, err := s.retrieveSSHSecret(ctx)
 	if err != nil {
-		return errors.Errorf("Unable to retrieve SSH secret: ", err)
+		return errors.Errorf("Unable to retrieve SSH secret: %s", err)
 	}
 
 	sshFingerprint, err := s.getSSHFingerprintFromName(sshKeyName)
@@ -278,7 +304,7 @@ func (s *Service) provisionMachine(ctx context.Context, server models.Server) er
 
 	userDataBytes := bytes.NewBuffer(nil)
 	if err := userData.WriteYAML(userDataBytes); err != nil {
-		return errors.Errorf("Error while writing yaml file", err)
+		return errors.Errorf("Error while writing yaml file: %s", err)
 	}
 
 	cloudInitConfigString := userDataBytes.String()
@@ -286,7 +312,7 @@ func (s *Service) provisionMachine(ctx context.Context, server models.Server) er
 	// First we have to activate rescue mode
 	_, err = s.scope.HrobotClient().ActivateRescue(server.ServerIP, sshFingerprint)
 	if err != nil {
-		return errors.Errorf("Unable to activate rescue system: ", err)
+		return errors.Errorf("Unable to activate rescue system: %s", err)
 	}
 	s.scope.V(4).Info("Reset machine")
 	// reboot system
@@ -335,7 +361,7 @@ HOSTNAME %s
 %s
 IMAGE %s
 EOF`,
-		drive, s.scope.Cluster.Name+delimiter+*s.scope.BareMetalMachine.Spec.ServerType+delimiter+s.scope.BareMetalMachine.Name, partitionString, *s.scope.BareMetalMachine.Spec.ImagePath)
+		drive, s.serverName(), partitionString, *s.scope.BareMetalMachine.Spec.ImagePath)
 
 	s.scope.V(4).Info("Send auto setup file to server")
 	// Send autosetup file to server
@@ -448,10 +474,9 @@ EOF`, cloudInitConfigString)
 	}
 	s.scope.V(4).Info("Set server name and finish")
 	// Finally set the machine's name. The name replaces labels as we cannot label bare metal machines directly
-	_, err = s.scope.HrobotClient().SetBMServerName(server.ServerIP,
-		s.scope.Cluster.Name+delimiter+*s.scope.BareMetalMachine.Spec.ServerType+delimiter+s.scope.BareMetalMachine.Name)
+	_, err = s.scope.HrobotClient().SetBMServerName(server.ServerIP, s.serverName())
 	if err != nil {
-		return errors.Errorf("Unable to change bare metal server name: ", err)
+		return errors.Errorf("Unable to change bare metal server name: %s", err)
 	}
 	s.scope.Recorder.Eventf(
 		s.scope.BareMetalMachine,
@@ -498,7 +523,7 @@ func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 	_, err = s.scope.HrobotClient().SetBMServerName(server.ServerIP,
 		*s.scope.BareMetalMachine.Spec.ServerType+delimiter+"unused-"+s.scope.BareMetalMachine.Name)
 	if err != nil {
-		return nil, errors.Errorf("Unable to change bare metal server name: ", err)
+		return nil, errors.Errorf("Unable to change bare metal server name: %s", err)
 	}
 
 	return nil, nil
@@ -0,0 +1,87 @@
+package baremetal
+
+import (
+	"context"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/nl2go/hrobot-go/models"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// Reboot resets the attached server of the machine. A soft reboot sends
+// CTRL+ALT+DEL to the server, otherwise its hardware is reset. The attempt
+// and its outcome are recorded in the status of the BareMetalMachine.
+func (s *Service) Reboot(ctx context.Context, soft bool) (err error) {
+	action, resetType := infrav1.BareMetalRemediationHardReset, "hw"
+	if soft {
+		action, resetType = infrav1.BareMetalRemediationSoftReset, "sw"
+	}
+	defer func() { s.recordRemediation(action, err) }()
+
+	server, err := s.attachedServer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.scope.HrobotClient().ResetBMServer(server.ServerIP, resetType); err != nil {
+		return errors.Wrapf(err, "failed to reset bare metal server %s", server.ServerName)
+	}
+	return nil
+}
+
+// Reinstall fails the machine, so that it is replaced by its owner. The
+// bootstrap data of the machine contains a join token, which has expired
+// since the node joined the cluster, so instead of provisioning the server
+// again in place, it is released on deletion of the machine and reinstalled
+// with fresh bootstrap data, once it is provisioned for a replacement. The
+// attempt and its outcome are recorded in the status of the BareMetalMachine.
+func (s *Service) Reinstall(ctx context.Context) (err error) {
+	defer func() { s.recordRemediation(infrav1.BareMetalRemediationReinstall, err) }()
+
+	server, err := s.attachedServer(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.scope.SetFailureReason(capierrors.UpdateMachineError)
+	s.scope.SetFailureMessage(errors.Errorf("bare metal server %s is still unhealthy and has to be reinstalled", server.ServerName))
+	return nil
+}
+
+// attachedServer returns the server attached to the machine
+func (s *Service) attachedServer(ctx context.Context) (*models.Server, error) {
+	serverList, err := s.listMatchingMachines(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+
+	server, err := s.findAttachedMachine(serverList)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find attached machine")
+	}
+	if server == nil {
+		return nil, errors.Errorf("no bare metal server attached to BareMetalMachine %s", s.scope.Name())
+	}
+	return server, nil
+}
+
+func (s *Service) recordRemediation(action infrav1.BareMetalRemediationAction, err error) {
+	status := &s.scope.BareMetalMachine.Status
+	if status.Remediation == nil {
+		status.Remediation = &infrav1.BareMetalRemediationStatus{}
+	}
+
+	now := metav1.Now()
+	status.Remediation.Attempts++
+	status.Remediation.LastAction = action
+	status.Remediation.LastAttempted = &now
+	if err != nil {
+		status.Remediation.LastOutcome = infrav1.BareMetalRemediationFailed
+		status.Remediation.LastMessage = err.Error()
+	} else {
+		status.Remediation.LastOutcome = infrav1.BareMetalRemediationSucceeded
+		status.Remediation.LastMessage = ""
+	}
+}
@@ -0,0 +1,208 @@
+package baremetal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nl2go/hrobot-go/models"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+// the Robot credentials are referenced by an unexported type
+const testHcloudCluster = `
+metadata:
+  namespace: default
+  name: test
+spec:
+  hrobotTokenRef:
+    tokenName: robot
+    userNameKey: username
+    passwordKey: password
+`
+
+var testServers = []models.Server{
+	{ServerIP: "1.2.3.4", ServerName: "test--EX42--worker-1"},
+	{ServerIP: "1.2.3.5", ServerName: "EX42--unused-worker-2"},
+}
+
+func newTestService(t *testing.T, mockCtrl *gomock.Controller, hrc scope.HrobotClient) *Service {
+	hcloudCluster := &infrav1.HcloudCluster{}
+	if err := yaml.Unmarshal([]byte(testHcloudCluster), hcloudCluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cluster := &clusterv1.Cluster{}
+	cluster.Namespace, cluster.Name = "default", "test"
+	machine := &clusterv1.Machine{}
+	machine.Namespace, machine.Name = "default", "worker-1"
+	serverType := "EX42"
+	bareMetalMachine := &infrav1.BareMetalMachine{
+		Spec: infrav1.BareMetalMachineSpec{ServerType: &serverType},
+	}
+	bareMetalMachine.Namespace, bareMetalMachine.Name = "default", "worker-1"
+
+	sch := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(sch)
+	_ = infrav1.AddToScheme(sch)
+	c := fake.NewFakeClientWithScheme(sch, cluster, hcloudCluster, machine, bareMetalMachine)
+
+	bareMetalMachineScope, err := scope.NewBareMetalMachineScope(scope.BareMetalMachineScopeParams{
+		ClusterScopeParams: scope.ClusterScopeParams{
+			Ctx:    context.TODO(),
+			Client: c,
+			HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+				return mock_scope.NewMockHcloudClient(mockCtrl), nil
+			},
+			HrobotClientFactory: func(context.Context) (scope.HrobotClient, error) {
+				return hrc, nil
+			},
+			Recorder:      record.NewFakeRecorder(10),
+			Cluster:       cluster,
+			HcloudCluster: hcloudCluster,
+			Packer:        mock_scope.NewMockPacker(mockCtrl),
+			Manifests:     mock_scope.NewMockManifests(mockCtrl),
+		},
+		Machine:          machine,
+		BareMetalMachine: bareMetalMachine,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewService(bareMetalMachineScope)
+}
+
+func TestReboot(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		soft      bool
+		resetType string
+		resetErr  error
+		action    infrav1.BareMetalRemediationAction
+		outcome   infrav1.BareMetalRemediationOutcome
+	}{
+		{
+			name:      "soft reboot",
+			soft:      true,
+			resetType: "sw",
+			action:    infrav1.BareMetalRemediationSoftReset,
+			outcome:   infrav1.BareMetalRemediationSucceeded,
+		},
+		{
+			name:      "hardware reset",
+			resetType: "hw",
+			action:    infrav1.BareMetalRemediationHardReset,
+			outcome:   infrav1.BareMetalRemediationSucceeded,
+		},
+		{
+			name:      "failed reset",
+			resetType: "hw",
+			resetErr:  errors.New("server is locked"),
+			action:    infrav1.BareMetalRemediationHardReset,
+			outcome:   infrav1.BareMetalRemediationFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			hrc := mock_scope.NewMockHrobotClient(mockCtrl)
+			hrc.EXPECT().ListBMServers().Return(testServers, nil)
+			hrc.EXPECT().ResetBMServer("1.2.3.4", tc.resetType).Return(&models.ResetPost{}, tc.resetErr)
+
+			s := newTestService(t, mockCtrl, hrc)
+			err := s.Reboot(context.TODO(), tc.soft)
+			if (err != nil) != (tc.resetErr != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			remediation := s.scope.BareMetalMachine.Status.Remediation
+			if remediation == nil {
+				t.Fatal("expected the remediation to be recorded")
+			}
+			if remediation.Attempts != 1 {
+				t.Errorf("expected 1 attempt, got %d", remediation.Attempts)
+			}
+			if remediation.LastAction != tc.action {
+				t.Errorf("expected last action %s, got %s", tc.action, remediation.LastAction)
+			}
+			if remediation.LastOutcome != tc.outcome {
+				t.Errorf("expected last outcome %s, got %s", tc.outcome, remediation.LastOutcome)
+			}
+			if (remediation.LastMessage != "") != (tc.resetErr != nil) {
+				t.Errorf("unexpected last message %q", remediation.LastMessage)
+			}
+			if s.scope.BareMetalMachine.Status.FailureReason != nil {
+				t.Errorf("expected the machine not to fail, got %s", *s.scope.BareMetalMachine.Status.FailureReason)
+			}
+		})
+	}
+}
+
+func TestReinstall(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		servers []models.Server
+		failed  bool
+		outcome infrav1.BareMetalRemediationOutcome
+	}{
+		{
+			name:    "attached server",
+			servers: testServers,
+			failed:  true,
+			outcome: infrav1.BareMetalRemediationSucceeded,
+		},
+		{
+			name:    "no attached server",
+			servers: testServers[1:],
+			outcome: infrav1.BareMetalRemediationFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			// the server is not provisioned again with the bootstrap data
+			// of the machine, which contains an expired join token
+			hrc := mock_scope.NewMockHrobotClient(mockCtrl)
+			hrc.EXPECT().ListBMServers().Return(tc.servers, nil)
+
+			s := newTestService(t, mockCtrl, hrc)
+			err := s.Reinstall(context.TODO())
+			if (err != nil) == tc.failed {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			status := s.scope.BareMetalMachine.Status
+			if tc.failed {
+				if status.FailureReason == nil || *status.FailureReason != capierrors.UpdateMachineError {
+					t.Errorf("expected failure reason %s, got %v", capierrors.UpdateMachineError, status.FailureReason)
+				}
+				if status.FailureMessage == nil {
+					t.Error("expected a failure message")
+				}
+			} else if status.FailureReason != nil {
+				t.Errorf("expected the machine not to fail, got %s", *status.FailureReason)
+			}
+
+			if status.Remediation == nil {
+				t.Fatal("expected the remediation to be recorded")
+			}
+			if status.Remediation.LastAction != infrav1.BareMetalRemediationReinstall {
+				t.Errorf("expected last action %s, got %s", infrav1.BareMetalRemediationReinstall, status.Remediation.LastAction)
+			}
+			if status.Remediation.LastOutcome != tc.outcome {
+				t.Errorf("expected last outcome %s, got %s", tc.outcome, status.Remediation.LastOutcome)
+			}
+		})
+	}
+}
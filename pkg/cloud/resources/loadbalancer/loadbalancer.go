@@ -84,7 +84,7 @@ func (s *Service) createLoadBalancer(ctx context.Context, spec infrav1.HcloudLoa
 
 	hc := s.scope.HcloudCluster
 
-	name := names.SimpleNameGenerator.GenerateName(hc.Name + "-kube-apiserver-")
+	name := names.SimpleNameGenerator.GenerateName(hc.ResourceName("kube-apiserver") + "-")
 	if s.scope.HcloudCluster.Spec.ControlPlaneLoadBalancer.Name != nil {
 		name = *s.scope.HcloudCluster.Spec.ControlPlaneLoadBalancer.Name
 	}
@@ -131,17 +131,13 @@ func (s *Service) createLoadBalancer(ctx context.Context, spec infrav1.HcloudLoa
 		Proxyprotocol:   &mybool,
 	}
 
-	clusterTagKey := infrav1.ClusterTagKey(hc.Name)
-
-	labels := map[string]string{clusterTagKey: string(infrav1.ResourceLifecycleOwned)}
-
 	opts := hcloud.LoadBalancerCreateOpts{
 		LoadBalancerType: loadBalancerType,
 		Name:             name,
 		Algorithm:        loadBalancerAlgorithm,
 		Location:         location,
 		Network:          network,
-		Labels:           labels,
+		Labels:           hc.ResourceLabels(),
 		Services:         []hcloud.LoadBalancerCreateOptsService{createServiceOpts},
 	}
 
@@ -239,10 +235,8 @@ func (s *Service) Delete(ctx context.Context) (err error) {
 
 func FindLoadBalancer(scope *scope.ClusterScope) (*hcloud.LoadBalancer, error) {
 
-	clusterTagKey := infrav1.ClusterTagKey(scope.HcloudCluster.Name)
-	labels := map[string]string{clusterTagKey: string(infrav1.ResourceLifecycleOwned)}
 	opts := hcloud.LoadBalancerListOpts{}
-	opts.LabelSelector = utils.LabelsToLabelSelector(labels)
+	opts.LabelSelector = utils.LabelsToLabelSelector(scope.HcloudCluster.ResourceLabels())
 
 	loadBalancers, err := scope.HcloudClient().ListLoadBalancers(scope.Ctx, opts)
 	if err != nil {
@@ -54,10 +54,7 @@ func (s *Service) defaults() *infrav1.HcloudNetworkSpec {
 }
 
 func (s *Service) labels() map[string]string {
-	clusterTagKey := infrav1.ClusterTagKey(s.scope.HcloudCluster.Name)
-	return map[string]string{
-		clusterTagKey: string(infrav1.ResourceLifecycleOwned),
-	}
+	return s.scope.HcloudCluster.ResourceLabels()
 }
 
 func (s *Service) Reconcile(ctx context.Context) (err error) {
@@ -117,7 +114,7 @@ func (s *Service) createNetwork(ctx context.Context, spec *infrav1.HcloudNetwork
 	}
 
 	opts := hcloud.NetworkCreateOpts{
-		Name:    hc.Name,
+		Name:    hc.ResourceName(""),
 		IPRange: network,
 		Labels:  s.labels(),
 		Subnets: subnets,
@@ -3,8 +3,10 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +17,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	kubeadmv1beta2 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta2"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -27,6 +31,13 @@ import (
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/userdata"
 )
 
+// maxServerNameLength is the maximum length of a server name, which is a
+// hostname
+const maxServerNameLength = 63
+
+// machineTypeTagKey labels servers as control plane or worker
+const machineTypeTagKey = "machine_type"
+
 type Service struct {
 	scope *scope.MachineScope
 }
@@ -38,6 +49,16 @@ func NewService(scope *scope.MachineScope) *Service {
 }
 
 func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
+	// adopted servers are not created, so neither an image nor bootstrap
+	// data is needed
+	if s.scope.HcloudMachine.Spec.Adopt != nil {
+		instance, result, err := s.adoptServer(ctx)
+		if result != nil || err != nil {
+			return result, err
+		}
+		return s.reconcileServer(ctx, instance)
+	}
+
 	// detect failure domain
 	failureDomain, err := s.scope.GetFailureDomain()
 	if err != nil {
@@ -51,7 +72,20 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 		KubernetesVersion: strings.Trim(*version, "v"),
 		Image:             s.scope.HcloudMachine.Spec.ImageName,
 	})
-	if err != nil {
+	var buildErr *packerapi.BuildError
+	if errors.As(err, &buildErr) {
+		s.scope.Recorder.Eventf(s.scope.HcloudMachine,
+			corev1.EventTypeWarning,
+			"FailedPackerBuild",
+			"Packer build for image %s failed (attempt %d), retrying in %s: %s (full log in configmap/%s)",
+			s.scope.HcloudMachine.Spec.ImageName,
+			buildErr.Attempts,
+			buildErr.RetryAfter.Round(time.Second),
+			buildErr.Summary,
+			scope.ImageBuildLogConfigMapName(buildErr.Hash),
+		)
+		return &ctrl.Result{RequeueAfter: buildErr.RetryAfter}, nil
+	} else if err != nil {
 		s.scope.Recorder.Eventf(s.scope.HcloudMachine,
 			corev1.EventTypeWarning,
 			"FailedEnsuringHcloudImage",
@@ -75,6 +109,14 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 		return nil, errors.Wrap(err, "failed to get server")
 	}
 
+	// A server, which has been provisioned before, has been deleted outside
+	// of the provider. Its machine is replaced by Cluster API, as a new
+	// server would join the cluster with stale bootstrap data.
+	if instance == nil && s.provisioned() {
+		s.markServerDeleted()
+		return nil, nil
+	}
+
 	// If no server is found we have to create one
 	if instance == nil {
 		instance, err = s.createServer(s.scope.Ctx, failureDomain, imageID)
@@ -90,6 +132,12 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 		)
 	}
 
+	return s.reconcileServer(ctx, instance)
+}
+
+// reconcileServer updates the status of the machine from its server and
+// attaches control planes to the load balancer
+func (s *Service) reconcileServer(ctx context.Context, instance *hcloud.Server) (_ *ctrl.Result, err error) {
 	if err := setStatusFromAPI(&s.scope.HcloudMachine.Status, instance); err != nil {
 		return nil, errors.New("error setting status")
 	}
@@ -149,6 +197,181 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 	return nil, fmt.Errorf("Not usable Address found")
 }
 
+// adoptServer returns the adopted server of the machine. On the first
+// adoption, the server is verified to match the machine and gets the labels
+// of a server created for the machine, then it is attached to the network
+// of the cluster. A server, which cannot be adopted, fails the machine.
+func (s *Service) adoptServer(ctx context.Context) (*hcloud.Server, *ctrl.Result, error) {
+	adopt := s.scope.HcloudMachine.Spec.Adopt
+
+	instance, err := s.findServer(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get server")
+	}
+	if instance == nil && s.provisioned() {
+		s.markServerDeleted()
+		return nil, &ctrl.Result{}, nil
+	}
+	if instance == nil {
+		instance, _, err = s.scope.HcloudClient().GetServerByID(ctx, adopt.ServerID)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get server %d", adopt.ServerID)
+		}
+		if instance == nil {
+			s.markAdoptionFailed(errors.Errorf("server %d does not exist", adopt.ServerID))
+			return nil, &ctrl.Result{}, nil
+		}
+	}
+
+	if err := s.verifyAdoptedServer(instance); err != nil {
+		s.markAdoptionFailed(err)
+		return nil, &ctrl.Result{}, nil
+	}
+
+	labels := make(map[string]string, len(instance.Labels))
+	for key, value := range instance.Labels {
+		labels[key] = value
+	}
+	var relabel bool
+	for key, value := range s.createLabels() {
+		if labels[key] != value {
+			labels[key] = value
+			relabel = true
+		}
+	}
+	if relabel {
+		instance, _, err = s.scope.HcloudClient().UpdateServer(ctx, instance, hcloud.ServerUpdateOpts{Labels: labels})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to label server %d", adopt.ServerID)
+		}
+		s.scope.Recorder.Eventf(
+			s.scope.HcloudMachine,
+			corev1.EventTypeNormal,
+			"ServerAdopted",
+			"Adopted existing server %s with id %d",
+			instance.Name,
+			instance.ID,
+		)
+	}
+
+	if net := s.scope.HcloudCluster.Status.Network; net != nil && !attachedToNetwork(instance, net.ID) {
+		opts := hcloud.ServerAttachToNetworkOpts{Network: &hcloud.Network{ID: net.ID}}
+		if _, _, err := s.scope.HcloudClient().AttachServerToNetwork(ctx, instance, opts); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to attach server %d to network %d", instance.ID, net.ID)
+		}
+		// the private IP is known once the server has been attached
+		return nil, &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	return instance, nil, nil
+}
+
+func attachedToNetwork(server *hcloud.Server, networkID int) bool {
+	for _, privateNet := range server.PrivateNet {
+		if privateNet.Network != nil && privateNet.Network.ID == networkID {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyAdoptedServer verifies that the server is not labelled for another
+// machine or cluster and that its type and location match the machine
+func (s *Service) verifyAdoptedServer(instance *hcloud.Server) error {
+	if name, ok := instance.Labels[infrav1.MachineNameTagKey]; ok && name != s.scope.Name() {
+		return errors.Errorf("server %d belongs to machine %s", instance.ID, name)
+	}
+	hcloudCluster := s.scope.HcloudCluster
+	for key := range instance.Labels {
+		if strings.HasPrefix(key, infrav1.NameHcloudProviderOwned) && key != infrav1.ClusterTagKey(hcloudCluster.Name) {
+			return errors.Errorf("server %d belongs to cluster %s", instance.ID, strings.TrimPrefix(key, infrav1.NameHcloudProviderOwned))
+		}
+	}
+	if namespace, ok := instance.Labels[infrav1.ClusterNamespaceTagKey]; ok && namespace != hcloudCluster.Namespace {
+		return errors.Errorf("server %d belongs to a cluster in namespace %s", instance.ID, namespace)
+	}
+	if uid, ok := instance.Labels[infrav1.ClusterUIDTagKey]; ok && uid != hcloudCluster.ResourceUID() {
+		return errors.Errorf("server %d belongs to a cluster with UID %s", instance.ID, uid)
+	}
+
+	if instance.ServerType == nil || instance.ServerType.Name != string(s.scope.HcloudMachine.Spec.Type) {
+		var serverType string
+		if instance.ServerType != nil {
+			serverType = instance.ServerType.Name
+		}
+		return errors.Errorf("server %d is of type %s, expected %s", instance.ID, serverType, s.scope.HcloudMachine.Spec.Type)
+	}
+
+	var location string
+	if instance.Datacenter != nil && instance.Datacenter.Location != nil {
+		location = instance.Datacenter.Location.Name
+	}
+	if failureDomain := s.scope.Machine.Spec.FailureDomain; failureDomain != nil && *failureDomain != location {
+		return errors.Errorf("server %d is located in %s, expected %s", instance.ID, location, *failureDomain)
+	}
+	if _, ok := s.scope.Cluster.Status.FailureDomains[location]; !ok && len(s.scope.Cluster.Status.FailureDomains) > 0 {
+		return errors.Errorf("server %d is located in %s, which is not a location of the cluster", instance.ID, location)
+	}
+
+	s.scope.HcloudMachine.Status.Location = infrav1.HcloudLocation(location)
+	return nil
+}
+
+// markAdoptionFailed sets a terminal failure on the machine, whose server
+// cannot be adopted
+func (s *Service) markAdoptionFailed(err error) {
+	s.scope.SetFailureReason(capierrors.InvalidConfigurationMachineError)
+	s.scope.SetFailureMessage(errors.Wrap(err, "failed to adopt server"))
+	s.scope.Recorder.Eventf(
+		s.scope.HcloudMachine,
+		corev1.EventTypeWarning,
+		"AdoptionFailed",
+		"Failed to adopt server %d: %s",
+		s.scope.HcloudMachine.Spec.Adopt.ServerID,
+		err,
+	)
+}
+
+// releaseServer detaches an adopted server, whose ownership has not been
+// transferred, from the load balancer and removes its labels instead of
+// deleting it
+func (s *Service) releaseServer(ctx context.Context, server *hcloud.Server) error {
+	if err := s.deleteServerOfLoadBalancer(ctx, server); err != nil {
+		return errors.Wrap(err, "failed to detach server from load balancer")
+	}
+
+	labels := ReleasedLabels(s.scope.HcloudCluster, server.Labels)
+	if _, _, err := s.scope.HcloudClient().UpdateServer(ctx, server, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+		return errors.Wrapf(err, "failed to remove labels of server %d", server.ID)
+	}
+
+	s.scope.Recorder.Eventf(
+		s.scope.HcloudMachine,
+		corev1.EventTypeNormal,
+		"ServerReleased",
+		"Released adopted server %s with id %d without deleting it",
+		server.Name,
+		server.ID,
+	)
+	return nil
+}
+
+// ReleasedLabels returns the labels of a server without the labels, which
+// make it a server of the cluster
+func ReleasedLabels(hcloudCluster *infrav1.HcloudCluster, serverLabels map[string]string) map[string]string {
+	ownLabels := hcloudCluster.ResourceLabels()
+	ownLabels[infrav1.MachineNameTagKey] = ""
+	ownLabels[machineTypeTagKey] = ""
+
+	labels := make(map[string]string, len(serverLabels))
+	for key, value := range serverLabels {
+		if _, ok := ownLabels[key]; !ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
 func (s *Service) createServer(ctx context.Context, failureDomain string, imageID *infrav1.HcloudImageID) (*hcloud.Server, error) {
 
 	s.scope.HcloudMachine.Status.ImageID = imageID
@@ -269,6 +492,12 @@ func (s *Service) createServer(ctx context.Context, failureDomain string, imageI
 
 	// TODO: Handle volumes
 
+	// the server name is unique in the project, so the node is named after
+	// the machine explicitly
+	if err := setNodeName(userData, kubeadmConfig, s.scope.Name()); err != nil {
+		return nil, err
+	}
+
 	if err := userData.SetKubeadmConfig(kubeadmConfig); err != nil {
 		return nil, err
 	}
@@ -281,7 +510,7 @@ func (s *Service) createServer(ctx context.Context, failureDomain string, imageI
 	var myTrue = true
 	var myFalse = false
 
-	name := s.scope.Name()
+	name := serverName(s.scope.HcloudCluster, s.scope.Name())
 	opts := hcloud.ServerCreateOpts{
 		Name:   name,
 		Labels: s.createLabels(),
@@ -340,12 +569,54 @@ func (s *Service) createServer(ctx context.Context, failureDomain string, imageI
 			s.scope.Name(),
 			err,
 		)
-		return nil, fmt.Errorf("Error while creating Hcloud server %s: %s", &s.scope.HcloudMachine.Name, err)
+		return nil, fmt.Errorf("Error while creating Hcloud server %s: %s", s.scope.HcloudMachine.Name, err)
 	}
 
 	return res.Server, nil
 }
 
+// serverName returns the name of a new server of the machine. It contains
+// the UID prefix of the cluster, so machines of the same name in other
+// clusters do not collide, and is shortened to a valid hostname by a hash.
+func serverName(hcloudCluster *infrav1.HcloudCluster, machineName string) string {
+	name := hcloudCluster.ResourceName(machineName)
+	if len(name) <= maxServerNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:8]
+	return strings.TrimRight(name[:maxServerNameLength-len(hash)-1], "-") + "-" + hash
+}
+
+// setNodeName names the node in the kubeadm config and sets the hostname of
+// the server after the machine. The kubelet registers with the provider ID of the server, which
+// cloud-init renders from the instance data, as the node name does not match
+// the server name.
+func setNodeName(userData *userdata.UserData, kubeadmConfig *userdata.KubeadmConfig, nodeName string) error {
+	var nodeRegistrations []*kubeadmv1beta2.NodeRegistrationOptions
+	if c := kubeadmConfig.InitConfiguration; c != nil {
+		nodeRegistrations = append(nodeRegistrations, &c.NodeRegistration)
+	}
+	if c := kubeadmConfig.JoinConfiguration; c != nil {
+		nodeRegistrations = append(nodeRegistrations, &c.NodeRegistration)
+	}
+
+	for _, n := range nodeRegistrations {
+		n.Name = nodeName
+		if n.KubeletExtraArgs == nil {
+			n.KubeletExtraArgs = make(map[string]string)
+		}
+		if _, ok := n.KubeletExtraArgs["provider-id"]; !ok {
+			n.KubeletExtraArgs["provider-id"] = "hcloud://{{ v1.instance_id }}"
+		}
+	}
+
+	if err := userData.SetHostname(nodeName); err != nil {
+		return err
+	}
+	// the provider ID is only rendered by cloud-init for Jinja templates
+	return userData.SetJinjaTemplate()
+}
+
 func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 	// find current server
 	server, err := s.findServer(ctx)
@@ -360,6 +631,12 @@ func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 		return result, nil
 	}
 
+	// adopted servers are only deleted, if their ownership has been
+	// transferred to the machine
+	if adopt := s.scope.HcloudMachine.Spec.Adopt; adopt != nil && !adopt.TransferOwnership {
+		return nil, s.releaseServer(ctx, server)
+	}
+
 	err = s.deleteServerOfLoadBalancer(ctx, server)
 	if err != nil {
 		return &reconcile.Result{}, errors.Errorf("Error while deleting attached server of loadbalancer: %s", err)
@@ -396,10 +673,68 @@ func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 	return result, nil
 }
 
+// Reboot reboots the server of the machine. A soft reboot sends an ACPI
+// request to the server, otherwise the server is reset.
+func (s *Service) Reboot(ctx context.Context, soft bool) error {
+	server, err := s.findServer(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find server")
+	}
+	if server == nil {
+		return errors.Errorf("no server found for HcloudMachine %s", s.scope.Name())
+	}
+
+	if soft {
+		_, _, err = s.scope.HcloudClient().RebootServer(ctx, server)
+	} else {
+		_, _, err = s.scope.HcloudClient().ResetServer(ctx, server)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to reboot server %d", server.ID)
+	}
+	return nil
+}
+
+// provisioned returns true if a server has been provisioned for the machine
+// before
+func (s *Service) provisioned() bool {
+	return s.scope.HcloudMachine.Status.ServerID != nil || s.scope.HcloudMachine.Spec.ProviderID != nil
+}
+
+// markServerDeleted sets a terminal failure on the machine, whose server has
+// been deleted outside of the provider
+func (s *Service) markServerDeleted() {
+	server := s.scope.Name()
+	if id := s.scope.HcloudMachine.Status.ServerID; id != nil {
+		server = strconv.Itoa(*id)
+	}
+
+	s.scope.SetFailureReason(capierrors.UpdateMachineError)
+	s.scope.SetFailureMessage(errors.Errorf("server %s has been deleted outside of the provider", server))
+	s.scope.HcloudMachine.Status.Ready = false
+	s.scope.HcloudMachine.Status.ServerState = ""
+	s.scope.HcloudMachine.Status.Addresses = nil
+
+	s.scope.Recorder.Eventf(
+		s.scope.HcloudMachine,
+		corev1.EventTypeWarning,
+		"ServerDeleted",
+		"Server %s of machine %s has been deleted outside of the provider, the machine has to be replaced",
+		server,
+		s.scope.Name(),
+	)
+}
+
 func setStatusFromAPI(status *infrav1.HcloudMachineStatus, server *hcloud.Server) error {
+	serverID := server.ID
+	status.ServerID = &serverID
 	status.ServerState = infrav1.HcloudServerState(server.Status)
 	status.Addresses = []corev1.NodeAddress{}
 
+	if server.Datacenter != nil {
+		status.Datacenter = server.Datacenter.Name
+	}
+
 	if ip := server.PublicNet.IPv4.IP.String(); ip != "" {
 		status.Addresses = append(
 			status.Addresses,
@@ -514,14 +849,83 @@ func (s *Service) deleteServerOfLoadBalancer(ctx context.Context, server *hcloud
 	return nil
 }
 
-// We write the server name in the labels, so that all labels are or should be unique
+// findServer gets the server by its ID, if it is known. Otherwise or if the
+// ID is stale, the server is searched by its labels.
 func (s *Service) findServer(ctx context.Context) (*hcloud.Server, error) {
-	opts := hcloud.ServerListOpts{}
-	opts.LabelSelector = utils.LabelsToLabelSelector(s.createLabels())
-	servers, err := s.scope.HcloudClient().ListServers(ctx, opts)
+	if serverID, ok := s.serverID(); ok {
+		server, _, err := s.scope.HcloudClient().GetServerByID(ctx, serverID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get server %d", serverID)
+		}
+		if server != nil && server.Labels[infrav1.MachineNameTagKey] == s.scope.Name() && s.ownedByCluster(server) {
+			return server, nil
+		}
+		if server != nil {
+			s.scope.Recorder.Eventf(s.scope.HcloudMachine,
+				corev1.EventTypeWarning,
+				"ServerLabelMismatch",
+				"Server %d is labelled with machine name %q, expected %q",
+				serverID,
+				server.Labels[infrav1.MachineNameTagKey],
+				s.scope.Name())
+		}
+		s.scope.V(1).Info("server ID is stale, searching server by labels", "serverID", serverID)
+	}
+
+	return s.findServerByLabels(ctx)
+}
+
+// serverID returns the ID of the server from the status or, for machines
+// provisioned before the ID has been recorded, from the provider ID
+func (s *Service) serverID() (int, bool) {
+	if id := s.scope.HcloudMachine.Status.ServerID; id != nil {
+		return *id, true
+	}
+	if providerID := s.scope.HcloudMachine.Spec.ProviderID; providerID != nil {
+		return ServerIDFromProviderID(*providerID)
+	}
+	return 0, false
+}
+
+// ownedByCluster returns false, if the server is labelled with the UID of
+// another cluster. Servers created before the UID was part of the labels
+// belong to the cluster, until they are relabelled.
+func (s *Service) ownedByCluster(server *hcloud.Server) bool {
+	uid, ok := server.Labels[infrav1.ClusterUIDTagKey]
+	return !ok || uid == s.scope.HcloudCluster.ResourceUID()
+}
+
+// ServerIDFromProviderID returns the ID of the server from a provider ID
+// of the form hcloud://<id>
+func ServerIDFromProviderID(providerID string) (int, bool) {
+	if !strings.HasPrefix(providerID, "hcloud://") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(providerID, "hcloud://"))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// We write the server name in the labels, so that all labels are or should be unique
+func (s *Service) findServerByLabels(ctx context.Context) (*hcloud.Server, error) {
+	servers, err := s.listServers(ctx, utils.LabelsToLabelSelector(s.createLabels()))
 	if err != nil {
 		return nil, err
 	}
+	if len(servers) == 0 {
+		// the server is only labelled with the name of the cluster, as the
+		// labels of the cluster have not been migrated yet
+		servers, err = s.listServers(ctx, fmt.Sprintf("%s,%s==%s",
+			s.scope.HcloudCluster.LegacyResourceLabelSelector(),
+			infrav1.MachineNameTagKey,
+			s.scope.Name(),
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(servers) > 1 {
 		s.scope.Recorder.Eventf(s.scope.HcloudMachine,
 			corev1.EventTypeWarning,
@@ -537,6 +941,12 @@ func (s *Service) findServer(ctx context.Context) (*hcloud.Server, error) {
 	return servers[0], nil
 }
 
+func (s *Service) listServers(ctx context.Context, selector string) ([]*hcloud.Server, error) {
+	opts := hcloud.ServerListOpts{}
+	opts.LabelSelector = selector
+	return s.scope.HcloudClient().ListServers(ctx, opts)
+}
+
 func stringSliceContains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -548,10 +958,8 @@ func stringSliceContains(s []string, e string) bool {
 
 func (s *Service) createLabels() map[string]string {
 
-	m := map[string]string{
-		infrav1.ClusterTagKey(s.scope.HcloudCluster.Name): string(infrav1.ResourceLifecycleOwned),
-		infrav1.MachineNameTagKey:                         s.scope.Name(),
-	}
+	m := s.scope.HcloudCluster.ResourceLabels()
+	m[infrav1.MachineNameTagKey] = s.scope.Name()
 
 	var machineType string
 	if s.scope.IsControlPlane() == true {
@@ -559,6 +967,6 @@ func (s *Service) createLabels() map[string]string {
 	} else {
 		machineType = "worker"
 	}
-	m["machine_type"] = machineType
+	m[machineTypeTagKey] = machineType
 	return m
 }
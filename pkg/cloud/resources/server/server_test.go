@@ -0,0 +1,426 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/userdata"
+)
+
+const joinKubeadmConfig = `  - path: /tmp/kubeadm-join-config.yaml
+    owner: root:root
+    permissions: '0640'
+    content: |
+        apiVersion: kubeadm.k8s.io/v1beta2
+        discovery:
+          bootstrapToken:
+            apiServerEndpoint: 1.2.3.4:6443
+            token: xxxxxx.yyyyyyyyyyyyyyyy
+            unsafeSkipCAVerification: true
+        kind: JoinConfiguration
+        nodeRegistration:
+          name: '{{ ds.meta_data.local_hostname }}'
+runcmd:
+  - kubeadm join --config /tmp/kubeadm-join-config.yaml
+`
+
+func TestSetNodeName(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header string
+	}{
+		{
+			name:   "jinja template",
+			header: "## template: jinja\n#cloud-config\n\n",
+		},
+		{
+			name:   "cloud-config",
+			header: "#cloud-config\n\n",
+		},
+		{
+			name:   "cloud-config without empty line",
+			header: "#cloud-config\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := userdata.NewFromReader(strings.NewReader(tc.header + "write_files:\n" + joinKubeadmConfig))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			kubeadmConfig, err := u.GetKubeadmConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := setNodeName(u, kubeadmConfig, "worker-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			b := bytes.NewBuffer(nil)
+			if err := u.WriteYAML(b); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rendered := b.String()
+
+			// the kubeadm config is written to the user data by createServer
+			nodeRegistration := kubeadmConfig.JoinConfiguration.NodeRegistration
+			if nodeRegistration.Name != "worker-1" {
+				t.Errorf("expected node name worker-1, got %q", nodeRegistration.Name)
+			}
+			if expected, actual := "hcloud://{{ v1.instance_id }}", nodeRegistration.KubeletExtraArgs["provider-id"]; actual != expected {
+				t.Errorf("expected provider ID %q, got %q", expected, actual)
+			}
+
+			// cloud-init only renders the provider ID of a Jinja template
+			if !strings.HasPrefix(rendered, "## template: jinja\n#cloud-config\n") {
+				t.Errorf("user data does not start with the Jinja header:\n%s", rendered)
+			}
+			if strings.Count(rendered, "#cloud-config") != 1 {
+				t.Errorf("user data contains the cloud-config header more than once:\n%s", rendered)
+			}
+			if !strings.Contains(rendered, "\nhostname: worker-1\n") {
+				t.Errorf("user data does not set the hostname:\n%s", rendered)
+			}
+		})
+	}
+}
+
+func TestServerName(t *testing.T) {
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", UID: "1234567890"},
+	}
+
+	if expected, actual := "test-12345678-worker-1", serverName(hcloudCluster, "worker-1"); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	long := serverName(hcloudCluster, strings.Repeat("a", 60)+"-1")
+	if len(long) > maxServerNameLength {
+		t.Errorf("server name %q is longer than %d characters", long, maxServerNameLength)
+	}
+	if long == serverName(hcloudCluster, strings.Repeat("a", 60)+"-2") {
+		t.Errorf("shortened server names of different machines collide: %q", long)
+	}
+}
+
+func newTestService(t *testing.T, mockCtrl *gomock.Controller, hc scope.HcloudClient, hcloudMachine *infrav1.HcloudMachine) *Service {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: hcloudMachine.Name},
+		Spec:       clusterv1.MachineSpec{ClusterName: "test"},
+	}
+
+	sch := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(sch)
+	_ = infrav1.AddToScheme(sch)
+	c := fake.NewFakeClientWithScheme(sch, cluster, hcloudCluster, machine, hcloudMachine)
+
+	machineScope, err := scope.NewMachineScope(scope.MachineScopeParams{
+		ClusterScopeParams: scope.ClusterScopeParams{
+			Ctx:    context.TODO(),
+			Client: c,
+			HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+				return hc, nil
+			},
+			Recorder:      record.NewFakeRecorder(10),
+			Cluster:       cluster,
+			HcloudCluster: hcloudCluster,
+			Packer:        mock_scope.NewMockPacker(mockCtrl),
+			Manifests:     mock_scope.NewMockManifests(mockCtrl),
+		},
+		Machine:       machine,
+		HcloudMachine: hcloudMachine,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewService(machineScope)
+}
+
+func TestFindServerByLabels(t *testing.T) {
+	legacySelector := infrav1.ClusterTagKey("test") + "==owned,!" + infrav1.ClusterUIDTagKey + "," + infrav1.MachineNameTagKey + "==test-worker"
+
+	for _, tc := range []struct {
+		name          string
+		servers       []*hcloud.Server
+		legacyServers []*hcloud.Server
+		expectedID    int
+	}{
+		{
+			name:       "labelled server",
+			servers:    []*hcloud.Server{{ID: 1}},
+			expectedID: 1,
+		},
+		{
+			name:          "server with legacy labels",
+			legacyServers: []*hcloud.Server{{ID: 2}},
+			expectedID:    2,
+		},
+		{
+			name: "no server",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			hc := mock_scope.NewMockHcloudClient(mockCtrl)
+			hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return(tc.servers, nil)
+			if len(tc.servers) == 0 {
+				hc.EXPECT().ListServers(gomock.Any(), hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: legacySelector}}).Return(tc.legacyServers, nil)
+			}
+
+			s := newTestService(t, mockCtrl, hc, &infrav1.HcloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+			})
+			server, err := s.findServerByLabels(context.TODO())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedID == 0 {
+				if server != nil {
+					t.Errorf("expected no server, got %d", server.ID)
+				}
+				return
+			}
+			if server == nil || server.ID != tc.expectedID {
+				t.Errorf("expected server %d, got %v", tc.expectedID, server)
+			}
+		})
+	}
+}
+
+func TestServerIDFromProviderID(t *testing.T) {
+	for _, tc := range []struct {
+		providerID string
+		expectedID int
+		expectedOK bool
+	}{
+		{providerID: "hcloud://123", expectedID: 123, expectedOK: true},
+		{providerID: "hcloud://"},
+		{providerID: "hcloud://abc"},
+		{providerID: "aws:///eu-central-1a/i-123"},
+		{providerID: "123"},
+	} {
+		t.Run(tc.providerID, func(t *testing.T) {
+			id, ok := ServerIDFromProviderID(tc.providerID)
+			if id != tc.expectedID || ok != tc.expectedOK {
+				t.Errorf("expected %d, %v, got %d, %v", tc.expectedID, tc.expectedOK, id, ok)
+			}
+		})
+	}
+}
+
+func TestFindServer(t *testing.T) {
+	serverID := 1
+	providerID := "hcloud://1"
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+	}
+	serverLabels := func(machine string) map[string]string {
+		labels := hcloudCluster.ResourceLabels()
+		labels[infrav1.MachineNameTagKey] = machine
+		return labels
+	}
+	otherClusterLabels := serverLabels("test-worker")
+	otherClusterLabels[infrav1.ClusterUIDTagKey] = "0987654321"
+
+	for _, tc := range []struct {
+		name          string
+		status        infrav1.HcloudMachineStatus
+		providerID    *string
+		serverByID    *hcloud.Server
+		labelSearch   bool
+		labelServers  []*hcloud.Server
+		expectedID    int
+		expectedEvent string
+	}{
+		{
+			name:       "server by ID",
+			status:     infrav1.HcloudMachineStatus{ServerID: &serverID},
+			serverByID: &hcloud.Server{ID: 1, Labels: serverLabels("test-worker")},
+			expectedID: 1,
+		},
+		{
+			// machines provisioned before the ID was recorded
+			name:       "server by provider ID",
+			providerID: &providerID,
+			serverByID: &hcloud.Server{ID: 1, Labels: serverLabels("test-worker")},
+			expectedID: 1,
+		},
+		{
+			name:         "stale ID",
+			status:       infrav1.HcloudMachineStatus{ServerID: &serverID},
+			labelSearch:  true,
+			labelServers: []*hcloud.Server{{ID: 2, Labels: serverLabels("test-worker")}},
+			expectedID:   2,
+		},
+		{
+			name:          "label mismatch",
+			status:        infrav1.HcloudMachineStatus{ServerID: &serverID},
+			serverByID:    &hcloud.Server{ID: 1, Labels: serverLabels("test-other")},
+			labelSearch:   true,
+			labelServers:  []*hcloud.Server{{ID: 2, Labels: serverLabels("test-worker")}},
+			expectedID:    2,
+			expectedEvent: "Warning ServerLabelMismatch",
+		},
+		{
+			name:          "server of another cluster",
+			status:        infrav1.HcloudMachineStatus{ServerID: &serverID},
+			serverByID:    &hcloud.Server{ID: 1, Labels: otherClusterLabels},
+			labelSearch:   true,
+			expectedEvent: "Warning ServerLabelMismatch",
+		},
+		{
+			name:         "no ID",
+			labelSearch:  true,
+			labelServers: []*hcloud.Server{{ID: 2, Labels: serverLabels("test-worker")}},
+			expectedID:   2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			hc := mock_scope.NewMockHcloudClient(mockCtrl)
+			if tc.status.ServerID != nil || tc.providerID != nil {
+				hc.EXPECT().GetServerByID(gomock.Any(), serverID).Return(tc.serverByID, nil, nil)
+			}
+			if tc.labelSearch {
+				hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return(tc.labelServers, nil)
+				if len(tc.labelServers) == 0 {
+					// the legacy labels are searched as well
+					hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return(nil, nil)
+				}
+			}
+
+			s := newTestService(t, mockCtrl, hc, &infrav1.HcloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+				Spec:       infrav1.HcloudMachineSpec{ProviderID: tc.providerID},
+				Status:     tc.status,
+			})
+			server, err := s.findServer(context.TODO())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedID == 0 {
+				if server != nil {
+					t.Errorf("expected no server, got %d", server.ID)
+				}
+			} else if server == nil || server.ID != tc.expectedID {
+				t.Errorf("expected server %d, got %v", tc.expectedID, server)
+			}
+
+			recorder := s.scope.Recorder.(*record.FakeRecorder)
+			select {
+			case event := <-recorder.Events:
+				if tc.expectedEvent == "" || !strings.HasPrefix(event, tc.expectedEvent) {
+					t.Errorf("expected event %q, got %q", tc.expectedEvent, event)
+				}
+			default:
+				if tc.expectedEvent != "" {
+					t.Errorf("expected event %q", tc.expectedEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestProvisioned(t *testing.T) {
+	serverID := 1
+	providerID := "hcloud://1"
+	for _, tc := range []struct {
+		name     string
+		machine  *infrav1.HcloudMachine
+		expected bool
+	}{
+		{
+			name:    "new machine",
+			machine: &infrav1.HcloudMachine{},
+		},
+		{
+			name:     "server ID",
+			machine:  &infrav1.HcloudMachine{Status: infrav1.HcloudMachineStatus{ServerID: &serverID}},
+			expected: true,
+		},
+		{
+			name:     "provider ID",
+			machine:  &infrav1.HcloudMachine{Spec: infrav1.HcloudMachineSpec{ProviderID: &providerID}},
+			expected: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			tc.machine.Namespace, tc.machine.Name = "default", "test-worker"
+			s := newTestService(t, mockCtrl, mock_scope.NewMockHcloudClient(mockCtrl), tc.machine)
+			if provisioned := s.provisioned(); provisioned != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, provisioned)
+			}
+		})
+	}
+}
+
+func TestDeletedServer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// the server of an adopted machine has been deleted outside of the
+	// provider, so it is found neither by its ID nor by its labels
+	serverID := 1
+	hc := mock_scope.NewMockHcloudClient(mockCtrl)
+	hc.EXPECT().GetServerByID(gomock.Any(), serverID).Return(nil, nil, nil)
+	hc.EXPECT().ListServers(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+
+	s := newTestService(t, mockCtrl, hc, &infrav1.HcloudMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-worker"},
+		Spec: infrav1.HcloudMachineSpec{
+			Adopt: &infrav1.HcloudMachineAdoption{ServerID: serverID},
+		},
+		Status: infrav1.HcloudMachineStatus{
+			ServerID:    &serverID,
+			Ready:       true,
+			ServerState: infrav1.HcloudServerState(hcloud.ServerStatusRunning),
+			Addresses:   []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "1.2.3.4"}},
+		},
+	})
+	if _, err := s.Reconcile(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status := s.scope.HcloudMachine.Status
+	if status.FailureReason == nil || *status.FailureReason != capierrors.UpdateMachineError {
+		t.Errorf("expected failure reason %s, got %v", capierrors.UpdateMachineError, status.FailureReason)
+	}
+	if status.FailureMessage == nil || !strings.Contains(*status.FailureMessage, "server 1 has been deleted") {
+		t.Errorf("unexpected failure message %v", status.FailureMessage)
+	}
+	if status.Ready || status.ServerState != "" || status.Addresses != nil {
+		t.Errorf("expected the status of the server to be reset, got %+v", status)
+	}
+
+	recorder := s.scope.Recorder.(*record.FakeRecorder)
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ServerDeleted") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
@@ -42,13 +42,17 @@ func apiToStatus(v *hcloud.Volume) *infrav1.HcloudVolumeStatus {
 }
 
 func (s *Service) name() string {
+	return s.scope.HcloudCluster.ResourceName(s.scope.HcloudVolume.Name)
+}
+
+// legacyName is the name of volumes created before the name contained the
+// UID of the cluster
+func (s *Service) legacyName() string {
 	return fmt.Sprintf("%s-%s", s.scope.HcloudCluster.Name, s.scope.HcloudVolume.Name)
 }
 
 func (s *Service) labels() map[string]string {
-	return map[string]string{
-		infrav1.ClusterTagKey(s.scope.HcloudCluster.Name): string(infrav1.ResourceLifecycleOwned),
-	}
+	return s.scope.HcloudCluster.ResourceLabels()
 }
 
 func (s *Service) Reconcile(ctx context.Context) (err error) {
@@ -109,9 +113,11 @@ func (s *Service) Delete(ctx context.Context) (err error) {
 func (s *Service) createVolume(ctx context.Context) error {
 	var automount = false
 	var format = "ext4"
+	labels := s.labels()
+	labels[infrav1.VolumeNameTagKey] = s.scope.HcloudVolume.Name
 	opts := hcloud.VolumeCreateOpts{
 		Name:      s.name(),
-		Labels:    s.labels(),
+		Labels:    labels,
 		Location:  &hcloud.Location{Name: string(s.scope.HcloudVolume.Spec.Location)},
 		Format:    &format,
 		Automount: &automount,
@@ -136,17 +142,41 @@ func (s *Service) deleteVolume(ctx context.Context, id infrav1.HcloudVolumeID) e
 func (s *Service) actualStatus(ctx context.Context) (*infrav1.HcloudVolumeStatus, error) {
 	opts := hcloud.VolumeListOpts{}
 	opts.LabelSelector = utils.LabelsToLabelSelector(s.labels())
-	volumes, err := s.scope.HcloudClient().ListVolumes(s.scope.Ctx, opts)
+	volumes, err := s.scope.HcloudClient().ListVolumes(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, v := range volumes {
-		if v.Name == s.name() {
+		if v.Name == s.name() || v.Name == s.legacyName() {
 			return apiToStatus(v), nil
 		}
 	}
 
-	return nil, nil
+	return s.actualLegacyStatus(ctx)
+}
 
+// actualLegacyStatus finds the volume, if it is only labelled with the name
+// of the cluster, as the labels of the cluster have not been migrated yet.
+// These labels are shared by clusters of the same name, so a volume is
+// matched by its ID, if it is known.
+func (s *Service) actualLegacyStatus(ctx context.Context) (*infrav1.HcloudVolumeStatus, error) {
+	opts := hcloud.VolumeListOpts{}
+	opts.LabelSelector = s.scope.HcloudCluster.LegacyResourceLabelSelector()
+	volumes, err := s.scope.HcloudClient().ListVolumes(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeID := s.scope.HcloudVolume.Status.VolumeID
+	for _, v := range volumes {
+		if volumeID != nil && v.ID != int(*volumeID) {
+			continue
+		}
+		if v.Name == s.legacyName() {
+			return apiToStatus(v), nil
+		}
+	}
+
+	return nil, nil
 }
@@ -0,0 +1,138 @@
+package volume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope"
+	mock_scope "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope/mock"
+)
+
+func newTestService(t *testing.T, mockCtrl *gomock.Controller, hc scope.HcloudClient, hcloudVolume *infrav1.HcloudVolume) *Service {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	hcloudCluster := &infrav1.HcloudCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "1234567890"},
+	}
+
+	sch := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(sch)
+	_ = infrav1.AddToScheme(sch)
+	c := fake.NewFakeClientWithScheme(sch, cluster, hcloudCluster, hcloudVolume)
+
+	volumeScope, err := scope.NewVolumeScope(scope.VolumeScopeParams{
+		ClusterScopeParams: scope.ClusterScopeParams{
+			Ctx:    context.TODO(),
+			Client: c,
+			HcloudClientFactory: func(context.Context) (scope.HcloudClient, error) {
+				return hc, nil
+			},
+			Cluster:       cluster,
+			HcloudCluster: hcloudCluster,
+			Packer:        mock_scope.NewMockPacker(mockCtrl),
+			Manifests:     mock_scope.NewMockManifests(mockCtrl),
+		},
+		HcloudVolume: hcloudVolume,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewService(volumeScope)
+}
+
+func TestActualStatus(t *testing.T) {
+	legacySelector := infrav1.ClusterTagKey("test") + "==owned,!" + infrav1.ClusterUIDTagKey
+	location := &hcloud.Location{Name: "fsn1"}
+	volumeID := infrav1.HcloudVolumeID(3)
+
+	for _, tc := range []struct {
+		name          string
+		volumeID      *infrav1.HcloudVolumeID
+		volumes       []*hcloud.Volume
+		legacyVolumes []*hcloud.Volume
+		expectedID    int
+	}{
+		{
+			name:       "labelled volume",
+			volumes:    []*hcloud.Volume{{ID: 1, Name: "test-12345678-data", Location: location}},
+			expectedID: 1,
+		},
+		{
+			name:          "volume with legacy labels",
+			legacyVolumes: []*hcloud.Volume{{ID: 3, Name: "test-data", Location: location}},
+			expectedID:    3,
+		},
+		{
+			// volume 4 belongs to a cluster of the same name in another namespace
+			name:     "volume with legacy labels matched by ID",
+			volumeID: &volumeID,
+			legacyVolumes: []*hcloud.Volume{
+				{ID: 4, Name: "test-data", Location: location},
+				{ID: 3, Name: "test-data", Location: location},
+			},
+			expectedID: 3,
+		},
+		{
+			name:          "no volume",
+			legacyVolumes: []*hcloud.Volume{{ID: 5, Name: "test-other", Location: location}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			hc := mock_scope.NewMockHcloudClient(mockCtrl)
+			hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return(tc.volumes, nil)
+			if len(tc.volumes) == 0 {
+				hc.EXPECT().ListVolumes(gomock.Any(), hcloud.VolumeListOpts{ListOpts: hcloud.ListOpts{LabelSelector: legacySelector}}).Return(tc.legacyVolumes, nil)
+			}
+
+			s := newTestService(t, mockCtrl, hc, &infrav1.HcloudVolume{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+				Status:     infrav1.HcloudVolumeStatus{VolumeID: tc.volumeID},
+			})
+			status, err := s.actualStatus(context.TODO())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedID == 0 {
+				if status != nil {
+					t.Errorf("expected no volume, got %d", *status.VolumeID)
+				}
+				return
+			}
+			if status == nil || int(*status.VolumeID) != tc.expectedID {
+				t.Errorf("expected volume %d, got %v", tc.expectedID, status)
+			}
+		})
+	}
+}
+
+func TestDeleteLegacyVolume(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	hc := mock_scope.NewMockHcloudClient(mockCtrl)
+	hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return(nil, nil)
+	hc.EXPECT().ListVolumes(gomock.Any(), gomock.Any()).Return([]*hcloud.Volume{
+		{ID: 3, Name: "test-data", Location: &hcloud.Location{Name: "fsn1"}},
+	}, nil)
+	hc.EXPECT().DeleteVolume(gomock.Any(), &hcloud.Volume{ID: 3}).Return(nil, nil)
+
+	s := newTestService(t, mockCtrl, hc, &infrav1.HcloudVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Spec:       infrav1.HcloudVolumeSpec{ReclaimPolicy: infrav1.HcloudVolumeReclaimDelete},
+	})
+	if err := s.Delete(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"encoding/asn1"
 	"fmt"
 	"reflect"
+	"strings"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
 )
@@ -16,7 +17,61 @@ import (
 const NodesPrefix = "system:node:"
 const NodesGroup = "system:nodes"
 
-func ValidateKubeletCSR(csr *x509.CertificateRequest, machine *infrav1.HcloudMachine) error {
+// KubeletServingSignerName is the signer of kubelet serving certificates
+const KubeletServingSignerName = "kubernetes.io/kubelet-serving"
+
+// kubelet serving certificates are requested with key encipherment for RSA
+// keys and without for other keys
+var (
+	kubeletServingUsages      = []string{"digital signature", "key encipherment", "server auth"}
+	kubeletServingUsagesNoRSA = []string{"digital signature", "server auth"}
+)
+
+// Machine is the infrastructure machine of a node, it is implemented by
+// HcloudMachine and BareMetalMachine
+type Machine interface {
+	NodeName() string
+	NodeIPAddresses() []string
+}
+
+var _ Machine = &infrav1.HcloudMachine{}
+var _ Machine = &infrav1.BareMetalMachine{}
+
+// IsKubeletServingRequest returns true if the request is signed by the kubelet
+// serving signer. Clusters before Kubernetes v1.18 don't set a signer, their
+// requests are identified by the server auth usage.
+func IsKubeletServingRequest(username, signerName string, usages []string) bool {
+	if !strings.HasPrefix(username, NodesPrefix) {
+		return false
+	}
+	if signerName != "" {
+		return signerName == KubeletServingSignerName
+	}
+	for _, usage := range usages {
+		if usage == "server auth" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateKubeletServingUsages verifies the requested usages are exactly the
+// usages of a kubelet serving certificate
+func ValidateKubeletServingUsages(usages []string) error {
+	actual := sets.NewString(usages...)
+	if actual.Len() != len(usages) {
+		return fmt.Errorf("duplicate usages requested: %v", usages)
+	}
+	if actual.Equal(sets.NewString(kubeletServingUsages...)) || actual.Equal(sets.NewString(kubeletServingUsagesNoRSA...)) {
+		return nil
+	}
+	return fmt.Errorf("usages %v are not allowed, expected %v or %v", usages, kubeletServingUsages, kubeletServingUsagesNoRSA)
+}
+
+// ValidateKubeletCSR validates a kubelet serving certificate request against
+// the machine of the node. Besides the node name, DNS names are allowed to be
+// the node name with one of the dnsSuffixes appended.
+func ValidateKubeletCSR(csr *x509.CertificateRequest, machine Machine, dnsSuffixes []string) error {
 	// check signature and exist quickly
 	if err := csr.CheckSignature(); err != nil {
 		return err
@@ -25,7 +80,7 @@ func ValidateKubeletCSR(csr *x509.CertificateRequest, machine *infrav1.HcloudMac
 	var errs []error
 
 	// validate subject
-	username := fmt.Sprintf("%s%s", NodesPrefix, machine.ObjectMeta.Name)
+	username := fmt.Sprintf("%s%s", NodesPrefix, machine.NodeName())
 	subjectExpected := pkix.Name{
 		CommonName:   username,
 		Organization: []string{NodesGroup},
@@ -42,10 +97,18 @@ func ValidateKubeletCSR(csr *x509.CertificateRequest, machine *infrav1.HcloudMac
 	if len(csr.EmailAddresses) > 0 {
 		errs = append(errs, fmt.Errorf("email addresses are not allow on the request: %v", csr.EmailAddresses))
 	}
+	if len(csr.URIs) > 0 {
+		errs = append(errs, fmt.Errorf("URIs are not allow on the request: %v", csr.URIs))
+	}
 
 	// allow only certain DNS names
 	allowedDNSNames := map[string]struct{}{
-		machine.ObjectMeta.Name: {},
+		machine.NodeName(): {},
+	}
+	for _, suffix := range dnsSuffixes {
+		if suffix = strings.Trim(suffix, "."); suffix != "" {
+			allowedDNSNames[machine.NodeName()+"."+suffix] = struct{}{}
+		}
 	}
 	for _, name := range csr.DNSNames {
 		if _, ok := allowedDNSNames[name]; !ok {
@@ -55,11 +118,8 @@ func ValidateKubeletCSR(csr *x509.CertificateRequest, machine *infrav1.HcloudMac
 
 	// allow only certain IP addresses
 	allowedIPAddresses := make(map[string]struct{})
-	for _, address := range machine.Status.Addresses {
-		switch address.Type {
-		case corev1.NodeInternalIP, corev1.NodeExternalIP:
-			allowedIPAddresses[address.Address] = struct{}{}
-		}
+	for _, address := range machine.NodeIPAddresses() {
+		allowedIPAddresses[address] = struct{}{}
 	}
 	for _, ip := range csr.IPAddresses {
 		if _, ok := allowedIPAddresses[ip.String()]; !ok {
@@ -67,7 +127,5 @@ func ValidateKubeletCSR(csr *x509.CertificateRequest, machine *infrav1.HcloudMac
 		}
 	}
 
-	// allow only certain IP addresses
-
 	return errors.NewAggregate(errs)
 }
@@ -1,9 +1,15 @@
 package csr_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/pem"
+	"net"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -40,8 +46,126 @@ func newMachine() *infrav1.HcloudMachine {
 }
 
 func TestValidateKubeletCSR(t *testing.T) {
-	err := p.ValidateKubeletCSR(newCSR(), newMachine())
+	err := p.ValidateKubeletCSR(newCSR(), newMachine(), nil)
 	if err != nil {
 		t.Errorf("unexpected error: %q", err)
 	}
 }
+
+func newBareMetalMachine() *infrav1.BareMetalMachine {
+	m := &infrav1.BareMetalMachine{}
+	m.Name = "z5lhh"
+	m.Status.ServerName = "christian-dev-control-plane-z5lhh"
+	m.Status.IPv4 = "94.130.226.160"
+	return m
+}
+
+func TestValidateKubeletCSR_BareMetalMachine(t *testing.T) {
+	// the private IP of the request is not an address of the server
+	if err := p.ValidateKubeletCSR(newCSR(), newBareMetalMachine(), nil); err == nil {
+		t.Error("expected error for unknown IP address")
+	} else if !strings.Contains(err.Error(), "10.0.0.2") || strings.Contains(err.Error(), "subject") {
+		t.Errorf("unexpected error: %q", err)
+	}
+
+	// the node name is the server name
+	m := newBareMetalMachine()
+	m.Status.ServerName = ""
+	if err := p.ValidateKubeletCSR(newCSR(), m, nil); err == nil {
+		t.Error("expected error for unexpected node name")
+	}
+
+	// a request for the server name and the IPv4 and IPv6 addresses of the
+	// server is valid
+	m = newBareMetalMachine()
+	m.Status.IPv6 = "2a01:4f8:10a:1f2::2"
+	csr := newNodeCSRWithIPs(t, m.Status.ServerName, []string{m.Status.ServerName}, []net.IP{
+		net.ParseIP(m.Status.IPv4),
+		net.ParseIP(m.Status.IPv6),
+	})
+	if err := p.ValidateKubeletCSR(csr, m, nil); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+}
+
+func newNodeCSR(t *testing.T, nodeName string, dnsNames []string) *x509.CertificateRequest {
+	return newNodeCSRWithIPs(t, nodeName, dnsNames, []net.IP{net.ParseIP("94.130.226.160")})
+}
+
+func newNodeCSRWithIPs(t *testing.T, nodeName string, dnsNames []string, ips []net.IP) *x509.CertificateRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:   p.NodesPrefix + nodeName,
+			Organization: []string{p.NodesGroup},
+		},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return csr
+}
+
+func TestValidateKubeletCSR_DNSSuffixes(t *testing.T) {
+	m := newMachine()
+	csr := newNodeCSR(t, m.Name, []string{m.Name, m.Name + ".nodes.example.com"})
+
+	if err := p.ValidateKubeletCSR(csr, m, nil); err == nil {
+		t.Error("expected error for FQDN without DNS suffix")
+	}
+	if err := p.ValidateKubeletCSR(csr, m, []string{"example.com"}); err == nil {
+		t.Error("expected error for FQDN with different DNS suffix")
+	}
+	if err := p.ValidateKubeletCSR(csr, m, []string{"example.com", ".nodes.example.com"}); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+}
+
+func TestValidateKubeletServingUsages(t *testing.T) {
+	for _, tc := range []struct {
+		usages []string
+		valid  bool
+	}{
+		{usages: []string{"digital signature", "key encipherment", "server auth"}, valid: true},
+		{usages: []string{"server auth", "digital signature"}, valid: true},
+		{usages: []string{"digital signature", "key encipherment", "client auth"}},
+		{usages: []string{"digital signature", "server auth", "client auth"}},
+		{usages: []string{"digital signature", "server auth", "server auth"}},
+		{usages: []string{"server auth"}},
+	} {
+		err := p.ValidateKubeletServingUsages(tc.usages)
+		if tc.valid && err != nil {
+			t.Errorf("unexpected error for %v: %s", tc.usages, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected error for %v", tc.usages)
+		}
+	}
+}
+
+func TestIsKubeletServingRequest(t *testing.T) {
+	for _, tc := range []struct {
+		username   string
+		signerName string
+		usages     []string
+		expected   bool
+	}{
+		{username: "system:node:node1", signerName: p.KubeletServingSignerName, expected: true},
+		{username: "system:node:node1", signerName: "kubernetes.io/kube-apiserver-client-kubelet"},
+		{username: "system:bootstrap:abcdef", signerName: p.KubeletServingSignerName},
+		{username: "system:node:node1", usages: []string{"digital signature", "server auth"}, expected: true},
+		{username: "system:node:node1", usages: []string{"digital signature", "client auth"}},
+	} {
+		if act := p.IsKubeletServingRequest(tc.username, tc.signerName, tc.usages); act != tc.expected {
+			t.Errorf("unexpected result for %s/%s: %t (expected %t)", tc.username, tc.signerName, act, tc.expected)
+		}
+	}
+}
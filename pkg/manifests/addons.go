@@ -0,0 +1,159 @@
+package manifests
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/parameters"
+)
+
+// The addons catalog contains a directory per addon, which holds a jsonnet
+// file per version: <catalog>/<name>/<version>.jsonnet
+const addonFileExtension = ".jsonnet"
+
+var addonNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+func (m *Manifests) initializeAddons() error {
+	entries, err := ioutil.ReadDir(m.addonsCatalogPath)
+	if err != nil {
+		return errors.Wrap(err, "error reading addons catalog")
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		versions, err := addonVersions(filepath.Join(m.addonsCatalogPath, e.Name()))
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			addon := api.Addon{Name: e.Name(), Version: v}
+			if err := evaluateJsonnet(ioutil.Discard, m.addonPath(addon), sampleParameters()); err != nil {
+				return errors.Wrapf(err, "error evaluating addon %s", addon)
+			}
+			m.log.V(1).Info("addon successfully validated", "addon", addon.String())
+		}
+	}
+
+	return nil
+}
+
+func (m *Manifests) addonPath(addon api.Addon) string {
+	return filepath.Join(m.addonsCatalogPath, addon.Name, addon.Version+addonFileExtension)
+}
+
+// addonVersions returns the versions of an addon in ascending order
+func addonVersions(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading addon versions")
+	}
+
+	type addonVersion struct {
+		name    string
+		version *version.Version
+	}
+	var versions []addonVersion
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), addonFileExtension) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), addonFileExtension)
+		v, err := version.ParseGeneric(name)
+		if err != nil {
+			// not a version, e.g. a shared library of the addon
+			continue
+		}
+		versions = append(versions, addonVersion{name: name, version: v})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].version.LessThan(versions[j].version)
+	})
+
+	result := make([]string, len(versions))
+	for pos := range versions {
+		result[pos] = versions[pos].name
+	}
+	return result, nil
+}
+
+// resolveAddon validates the addon against the catalog and sets the latest
+// version, if no version is selected
+func (m *Manifests) resolveAddon(addon api.Addon) (api.Addon, error) {
+	if m.addonsCatalogPath == "" {
+		return addon, errors.New("no addons catalog configured")
+	}
+	if !addonNameRegexp.MatchString(addon.Name) {
+		return addon, errors.Errorf("invalid addon name '%s'", addon.Name)
+	}
+	if addon.Version != "" && !addonNameRegexp.MatchString(addon.Version) {
+		return addon, errors.Errorf("invalid addon version '%s'", addon.Version)
+	}
+
+	versions, err := addonVersions(filepath.Join(m.addonsCatalogPath, addon.Name))
+	if err != nil {
+		return addon, errors.Wrapf(err, "addon %s not found in catalog", addon)
+	}
+	if len(versions) == 0 {
+		return addon, errors.Errorf("addon %s has no versions in catalog", addon)
+	}
+
+	if addon.Version == "" {
+		addon.Version = versions[len(versions)-1]
+		return addon, nil
+	}
+	for _, v := range versions {
+		if v == addon.Version {
+			return addon, nil
+		}
+	}
+	return addon, errors.Errorf("addon %s not found in catalog, available versions: %s", addon, strings.Join(versions, ", "))
+}
+
+// addonParameters returns the parameters to evaluate an addon with, the per
+// cluster snippet only applies to the manifests
+func addonParameters(p *parameters.ManifestParameters) *parameters.ManifestParameters {
+	addonParameters := *p
+	addonParameters.Snippet = nil
+	return &addonParameters
+}
+
+// AddonHash builds a sha256 hash over the rendered addon
+func (m *Manifests) AddonHash(addon api.Addon, p *parameters.ManifestParameters) (string, error) {
+	addon, err := m.resolveAddon(addon)
+	if err != nil {
+		return "", err
+	}
+	return hash(m.addonPath(addon), addonParameters(p))
+}
+
+// ApplyAddon renders an addon of the catalog and server-side applies it
+// object by object like Apply.
+func (m *Manifests) ApplyAddon(ctx context.Context, client clientcmd.ClientConfig, addon api.Addon, p *parameters.ManifestParameters) ([]api.ApplyResult, error) {
+	addon, err := m.resolveAddon(addon)
+	if err != nil {
+		return nil, err
+	}
+	return m.apply(ctx, client, m.addonPath(addon), addonParameters(p))
+}
+
+// RenderAddon returns the objects of an addon as they are applied
+func (m *Manifests) RenderAddon(addon api.Addon, p *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	addon, err := m.resolveAddon(addon)
+	if err != nil {
+		return nil, err
+	}
+	return render(m.addonPath(addon), addonParameters(p))
+}
@@ -0,0 +1,111 @@
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/klogr"
+
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+)
+
+const testAddonsCatalogPath = "../../manifests/addons"
+
+func TestInitializeAddons(t *testing.T) {
+	m := New(klogr.New(), "", testAddonsCatalogPath)
+	if err := m.Initialize(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestResolveAddon(t *testing.T) {
+	m := New(klogr.New(), "", testAddonsCatalogPath)
+
+	addon, err := m.resolveAddon(api.Addon{Name: "hcloud-ccm"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if addon.Version == "" {
+		t.Error("expected latest version to be resolved")
+	}
+
+	for _, invalid := range []api.Addon{
+		{Name: ".."},
+		{Name: "hcloud-ccm", Version: "../../config"},
+		{Name: "not-existing"},
+		{Name: "hcloud-ccm", Version: "v0.0.0"},
+	} {
+		if _, err := m.resolveAddon(invalid); err == nil {
+			t.Errorf("expected error for addon %s", invalid)
+		}
+	}
+}
+
+func TestAddonHash(t *testing.T) {
+	m := New(klogr.New(), "", testAddonsCatalogPath)
+
+	p := sampleParameters()
+	hash, err := m.AddonHash(api.Addon{Name: "hcloud-ccm"}, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the per cluster snippet must not change addons
+	snippet := "{ extra: {} }"
+	p.Snippet = &snippet
+	hashWithSnippet, err := m.AddonHash(api.Addon{Name: "hcloud-ccm"}, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if hash != hashWithSnippet {
+		t.Errorf("unexpected hash: %s (expected %s)", hashWithSnippet, hash)
+	}
+}
+
+func TestRenderAddonsPodCIDR(t *testing.T) {
+	m := New(klogr.New(), "", testAddonsCatalogPath)
+	p := sampleParameters()
+
+	// the CNI bundles allocate pod addresses from the pod CIDR of the cluster
+	objects, err := m.RenderAddon(api.Addon{Name: "cilium"}, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var found bool
+	for _, obj := range objects {
+		if obj.GetKind() != "ConfigMap" || obj.GetName() != "cilium-config" {
+			continue
+		}
+		found = true
+		if act, exp := obj.Object["data"].(map[string]interface{})["cluster-pool-ipv4-cidr"], p.PodCIDRBlocks[0]; act != exp {
+			t.Errorf("unexpected cilium pod CIDR: %s (expected %s)", act, exp)
+		}
+	}
+	if !found {
+		t.Error("cilium config not found in rendered addon")
+	}
+
+	objects, err = m.RenderAddon(api.Addon{Name: "calico"}, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	found = false
+	for _, obj := range objects {
+		if obj.GetKind() != "DaemonSet" || obj.GetName() != "calico-node" {
+			continue
+		}
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		for _, env := range containers[0].(map[string]interface{})["env"].([]interface{}) {
+			if env.(map[string]interface{})["name"] != "CALICO_IPV4POOL_CIDR" {
+				continue
+			}
+			found = true
+			if act, exp := env.(map[string]interface{})["value"], p.PodCIDRBlocks[0]; act != exp {
+				t.Errorf("unexpected calico pod CIDR: %s (expected %s)", act, exp)
+			}
+		}
+	}
+	if !found {
+		t.Error("calico pod CIDR not found in rendered addon")
+	}
+}
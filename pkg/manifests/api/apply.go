@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectReference identifies a single object rendered from the manifests
+type ObjectReference struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (o ObjectReference) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s %s", o.APIVersion, o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s %s/%s", o.APIVersion, o.Kind, o.Namespace, o.Name)
+}
+
+// objectKey identifies an object independent of the version it is served in
+type objectKey struct {
+	GroupKind schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+func (o ObjectReference) key() objectKey {
+	return objectKey{
+		GroupKind: schema.FromAPIVersionAndKind(o.APIVersion, o.Kind).GroupKind(),
+		Namespace: o.Namespace,
+		Name:      o.Name,
+	}
+}
+
+// StaleObjects returns the previously applied objects, which are no longer
+// rendered. Objects are compared by group, kind, namespace and name, so an
+// object rendered in another version of its group is not stale.
+func StaleObjects(previous, rendered []ObjectReference) []ObjectReference {
+	keys := make(map[objectKey]struct{}, len(rendered))
+	for _, o := range rendered {
+		keys[o.key()] = struct{}{}
+	}
+
+	var stale []ObjectReference
+	for _, o := range previous {
+		if _, ok := keys[o.key()]; !ok {
+			stale = append(stale, o)
+		}
+	}
+	return stale
+}
+
+// ApplyResult is the outcome of applying a single object
+type ApplyResult struct {
+	Object ObjectReference
+	// Err is set to an *ApplyError if the object failed to apply
+	Err error
+}
+
+// ApplyError describes why a single object failed to be applied or pruned. Reason is the
+// machine readable reason of the API server (e.g. Invalid, Forbidden,
+// Conflict) or NoKindMatch if the kind is not served by the cluster.
+type ApplyError struct {
+	Object ObjectReference
+	Reason string
+	Err    error
+}
+
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Object, e.Reason, e.Err)
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyErrors is returned by Apply and Prune if one or more objects failed
+type ApplyErrors []*ApplyError
+
+func (e ApplyErrors) Error() string {
+	msgs := make([]string, len(e))
+	for pos := range e {
+		msgs[pos] = e[pos].Error()
+	}
+	return fmt.Sprintf("%d manifest object(s) failed: %s", len(e), strings.Join(msgs, ", "))
+}
+
+// PruneResult is the outcome of pruning a single object
+type PruneResult struct {
+	Object ObjectReference
+	// Kept is true if the object has not been deleted, as it opted out of
+	// pruning or is not owned by the manifests
+	Kept bool
+	// Err is set to an *ApplyError if the object failed to be deleted
+	Err error
+}
+
+// Addon selects a bundle of the addon catalog
+type Addon struct {
+	Name    string
+	Version string
+}
+
+func (a Addon) String() string {
+	if a.Version == "" {
+		return a.Name
+	}
+	return fmt.Sprintf("%s@%s", a.Name, a.Version)
+}
+
+// DiffResult compares a single rendered object with the live object in the
+// workload cluster
+type DiffResult struct {
+	Object ObjectReference
+	// Missing is set if the object does not exist in the workload cluster
+	Missing bool
+	// Diff is empty if the live object matches the rendered object
+	Diff string
+}
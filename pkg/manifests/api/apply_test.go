@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStaleObjects(t *testing.T) {
+	configMap := ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "config"}
+	roleV1beta1 := ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRole", Name: "role"}
+	roleV1 := ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "role"}
+	otherRole := ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "other"}
+	crdKind := ObjectReference{APIVersion: "example.com/v1", Kind: "ClusterRole", Name: "role"}
+
+	tests := []struct {
+		name     string
+		previous []ObjectReference
+		rendered []ObjectReference
+		want     []ObjectReference
+	}{
+		{
+			name:     "unchanged objects",
+			previous: []ObjectReference{configMap, roleV1},
+			rendered: []ObjectReference{configMap, roleV1},
+		},
+		{
+			name:     "removed object",
+			previous: []ObjectReference{configMap, roleV1},
+			rendered: []ObjectReference{roleV1},
+			want:     []ObjectReference{configMap},
+		},
+		{
+			name:     "object moved to another version",
+			previous: []ObjectReference{roleV1beta1},
+			rendered: []ObjectReference{roleV1},
+		},
+		{
+			name:     "renamed object",
+			previous: []ObjectReference{roleV1},
+			rendered: []ObjectReference{otherRole},
+			want:     []ObjectReference{roleV1},
+		},
+		{
+			name:     "kind of another group",
+			previous: []ObjectReference{roleV1},
+			rendered: []ObjectReference{crdKind},
+			want:     []ObjectReference{roleV1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StaleObjects(tt.previous, tt.rendered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StaleObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	cause := errors.New("denied")
+	errs := ApplyErrors{
+		{Object: ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "config"}, Reason: "Forbidden", Err: cause},
+		{Object: ObjectReference{APIVersion: "example.com/v1", Kind: "Widget", Name: "widget"}, Reason: "NoKindMatch", Err: errors.New("no matches")},
+	}
+
+	want := "2 manifest object(s) failed: v1/ConfigMap kube-system/config: Forbidden: denied, example.com/v1/Widget widget: NoKindMatch: no matches"
+	if got := errs.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(errs[0], cause) {
+		t.Errorf("ApplyError does not unwrap to its cause")
+	}
+}
@@ -0,0 +1,287 @@
+package manifests
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/parameters"
+)
+
+// FieldManager is the field manager used to server-side apply the manifests
+const FieldManager = "cluster-api-provider-hcloud"
+
+// ApplyReasonNoKindMatch is the reason of an ApplyError, if the kind of the
+// object is not served by the API server
+const ApplyReasonNoKindMatch = "NoKindMatch"
+
+// Hash builds a sha256 hash over the applied manifests
+func (m *Manifests) Hash(p *parameters.ManifestParameters) (string, error) {
+	return hash(m.manifestConfigPath, p)
+}
+
+func hash(path string, p *parameters.ManifestParameters) (string, error) {
+	h := sha256.New()
+	if err := evaluateJsonnet(h, path, p); err != nil {
+		return "", errors.Wrap(err, "error generating manifests")
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Apply renders the manifests and server-side applies them object by object.
+// Failing objects do not stop the remaining objects from being applied, they
+// are reported in the results and returned as api.ApplyErrors.
+func (m *Manifests) Apply(ctx context.Context, client clientcmd.ClientConfig, p *parameters.ManifestParameters) ([]api.ApplyResult, error) {
+	return m.apply(ctx, client, m.manifestConfigPath, p)
+}
+
+func (m *Manifests) apply(ctx context.Context, client clientcmd.ClientConfig, path string, p *parameters.ManifestParameters) ([]api.ApplyResult, error) {
+	objects, err := render(path, p)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, mapper, err := newClients(client)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]api.ApplyResult, 0, len(objects))
+	var applyErrs api.ApplyErrors
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		ref := objectReference(obj)
+		if err := applyObject(dynamicClient, mapper, obj); err != nil {
+			applyErr := &api.ApplyError{
+				Object: ref,
+				Reason: applyErrorReason(err),
+				Err:    err,
+			}
+			m.log.V(0).Info("failed to apply object", "object", ref.String(), "reason", applyErr.Reason, "error", err.Error())
+			results = append(results, api.ApplyResult{Object: ref, Err: applyErr})
+			applyErrs = append(applyErrs, applyErr)
+			continue
+		}
+		m.log.V(1).Info("object applied", "object", ref.String())
+		results = append(results, api.ApplyResult{Object: ref})
+	}
+
+	if len(applyErrs) > 0 {
+		return results, applyErrs
+	}
+	return results, nil
+}
+
+// Render returns the objects of the manifests as they are applied
+func (m *Manifests) Render(p *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	return render(m.manifestConfigPath, p)
+}
+
+// render evaluates the jsonnet at path and returns its objects labeled as
+// owned and in the order they are applied
+func render(path string, p *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	objects, err := renderObjects(path, p)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating manifests")
+	}
+
+	for _, obj := range objects {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[infrav1.ManifestsOwnedLabelKey] = string(infrav1.ResourceLifecycleOwned)
+		obj.SetLabels(labels)
+	}
+
+	sortObjects(objects)
+	return objects, nil
+}
+
+// Prune deletes the referenced objects from the workload cluster, unless they
+// have not been applied from the manifests or are annotated to be kept.
+func (m *Manifests) Prune(ctx context.Context, client clientcmd.ClientConfig, objects []api.ObjectReference) ([]api.PruneResult, error) {
+	dynamicClient, mapper, err := newClients(client)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]api.PruneResult, 0, len(objects))
+	var pruneErrs api.ApplyErrors
+	for _, ref := range objects {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		kept, err := pruneObject(dynamicClient, mapper, ref)
+		if err != nil {
+			pruneErr := &api.ApplyError{
+				Object: ref,
+				Reason: applyErrorReason(err),
+				Err:    err,
+			}
+			m.log.V(0).Info("failed to prune object", "object", ref.String(), "reason", pruneErr.Reason, "error", err.Error())
+			results = append(results, api.PruneResult{Object: ref, Err: pruneErr})
+			pruneErrs = append(pruneErrs, pruneErr)
+			continue
+		}
+		if kept {
+			m.log.V(1).Info("object kept", "object", ref.String())
+		} else {
+			m.log.V(1).Info("object pruned", "object", ref.String())
+		}
+		results = append(results, api.PruneResult{Object: ref, Kept: kept})
+	}
+
+	if len(pruneErrs) > 0 {
+		return results, pruneErrs
+	}
+	return results, nil
+}
+
+func newClients(client clientcmd.ClientConfig) (dynamic.Interface, *restmapper.DeferredDiscoveryRESTMapper, error) {
+	restConfig, err := client.ClientConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating rest config")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating dynamic client")
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating discovery client")
+	}
+
+	return dynamicClient, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), nil
+}
+
+func resourceFor(c dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// the kind might be served by a CRD applied earlier in this run
+		mapper.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.Resource(mapping.Resource), nil
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	return c.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+func applyObject(c dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, obj *unstructured.Unstructured) error {
+	resource, err := resourceFor(c, mapper, obj.GroupVersionKind(), obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	// force ownership, so fields previously set by kubectl apply are taken
+	// over by the field manager
+	force := true
+	_, err = resource.Patch(obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	return err
+}
+
+func pruneObject(c dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, ref api.ObjectReference) (kept bool, err error) {
+	resource, err := resourceFor(c, mapper, schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind), ref.Namespace)
+	if meta.IsNoMatchError(err) {
+		// the kind is no longer served, so the object is gone as well
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	obj, err := resource.Get(ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if obj.GetLabels()[infrav1.ManifestsOwnedLabelKey] != string(infrav1.ResourceLifecycleOwned) {
+		return true, nil
+	}
+	if obj.GetAnnotations()[infrav1.ManifestsPruneAnnotationKey] == "false" {
+		return true, nil
+	}
+
+	propagationPolicy := metav1.DeletePropagationBackground
+	err = resource.Delete(ref.Name, &metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func applyErrorReason(err error) string {
+	if meta.IsNoMatchError(err) {
+		return ApplyReasonNoKindMatch
+	}
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return "Unknown"
+}
+
+func objectReference(obj *unstructured.Unstructured) api.ObjectReference {
+	return api.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+// sortObjects moves namespaces and CRDs to the front, so objects depending
+// on them can be applied in the same run
+func sortObjects(objects []*unstructured.Unstructured) {
+	priority := func(obj *unstructured.Unstructured) int {
+		switch obj.GroupVersionKind().GroupKind().String() {
+		case "Namespace":
+			return 0
+		case "CustomResourceDefinition.apiextensions.k8s.io":
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		return priority(objects[i]) < priority(objects[j])
+	})
+}
@@ -0,0 +1,187 @@
+package manifests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/klogr"
+
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+)
+
+// testManifests are applied to the test API server, which rejects the
+// ConfigMap invalid and does not serve the kind Widget
+const testManifests = `{
+  configMaps: [
+    { apiVersion: 'v1', kind: 'ConfigMap', metadata: { namespace: 'test', name: 'valid' } },
+    { apiVersion: 'v1', kind: 'ConfigMap', metadata: { namespace: 'test', name: 'invalid' } },
+  ],
+  namespace: { apiVersion: 'v1', kind: 'Namespace', metadata: { name: 'test' } },
+  widget: { apiVersion: 'example.com/v1', kind: 'Widget', metadata: { name: 'widget' } },
+}
+`
+
+// newTestAPIServer serves the discovery of namespaces and config maps and
+// answers server-side applies
+func newTestAPIServer(t *testing.T) *httptest.Server {
+	writeJSON := func(w http.ResponseWriter, code int, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(obj); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, metav1.APIVersions{Versions: []string{"v1"}})
+	})
+	mux.HandleFunc("/apis", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, metav1.APIGroupList{})
+	})
+	mux.HandleFunc("/api/v1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "namespaces", Kind: "Namespace", Verbs: metav1.Verbs{"get", "patch"}},
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: metav1.Verbs{"get", "patch"}},
+			},
+		})
+	})
+	apply := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Query().Get("fieldManager") != FieldManager || r.URL.Query().Get("force") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if r.URL.Path == "/api/v1/namespaces/test/configmaps/invalid" {
+			writeJSON(w, http.StatusUnprocessableEntity, metav1.Status{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+				Status:   metav1.StatusFailure,
+				Message:  "ConfigMap is invalid",
+				Reason:   metav1.StatusReasonInvalid,
+				Code:     http.StatusUnprocessableEntity,
+			})
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+	mux.HandleFunc("/api/v1/namespaces/test", apply)
+	mux.HandleFunc("/api/v1/namespaces/test/configmaps/", apply)
+	return httptest.NewServer(mux)
+}
+
+func TestApply(t *testing.T) {
+	server := newTestAPIServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifests.jsonnet")
+	if err := ioutil.WriteFile(path, []byte(testManifests), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: server.URL}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {}},
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		CurrentContext: "test",
+	}, &clientcmd.ConfigOverrides{})
+
+	m := New(klogr.New(), path, "")
+	results, err := m.apply(context.TODO(), client, path, sampleParameters())
+
+	// the failed objects do not stop the remaining ones from being applied
+	expected := []struct {
+		name   string
+		reason string
+	}{
+		{name: "test"},
+		{name: "valid"},
+		{name: "invalid", reason: string(metav1.StatusReasonInvalid)},
+		{name: "widget", reason: ApplyReasonNoKindMatch},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d: %v", len(expected), len(results), results)
+	}
+	var expectedErrs int
+	for pos, exp := range expected {
+		result := results[pos]
+		if result.Object.Name != exp.name {
+			t.Errorf("expected result %d for object %s, got %s", pos, exp.name, result.Object)
+		}
+		if exp.reason == "" {
+			if result.Err != nil {
+				t.Errorf("expected object %s to be applied, got %s", exp.name, result.Err)
+			}
+			continue
+		}
+		expectedErrs++
+		var applyErr *api.ApplyError
+		if !errors.As(result.Err, &applyErr) {
+			t.Errorf("expected an ApplyError for object %s, got %v", exp.name, result.Err)
+			continue
+		}
+		if applyErr.Reason != exp.reason || applyErr.Object != result.Object {
+			t.Errorf("expected object %s to fail with reason %s, got %s for %s", exp.name, exp.reason, applyErr.Reason, applyErr.Object)
+		}
+	}
+
+	// the failed objects are aggregated
+	var applyErrs api.ApplyErrors
+	if !errors.As(err, &applyErrs) {
+		t.Fatalf("expected ApplyErrors, got %v", err)
+	}
+	if len(applyErrs) != expectedErrs {
+		t.Errorf("expected %d errors, got %d: %s", expectedErrs, len(applyErrs), applyErrs)
+	}
+}
+
+func TestApplyErrorReason(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	for _, tc := range []struct {
+		err      error
+		expected string
+	}{
+		{
+			err:      &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Widget"}},
+			expected: ApplyReasonNoKindMatch,
+		},
+		{
+			err:      apierrors.NewForbidden(configMaps, "test", errors.New("denied")),
+			expected: string(metav1.StatusReasonForbidden),
+		},
+		{
+			err:      apierrors.NewConflict(configMaps, "test", errors.New("conflict")),
+			expected: string(metav1.StatusReasonConflict),
+		},
+		{
+			err:      errors.New("connection refused"),
+			expected: "Unknown",
+		},
+	} {
+		if reason := applyErrorReason(tc.err); reason != tc.expected {
+			t.Errorf("expected reason %s for %q, got %s", tc.expected, tc.err, reason)
+		}
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/fatih/color"
 	jsonnet "github.com/google/go-jsonnet"
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/parameters"
@@ -25,6 +27,9 @@ func sampleParameters() *parameters.ManifestParameters {
 	robotPassword := "invalid-password"
 	kubeAPIServerDomain := ""
 	port := "6443"
+	clusterName := "cluster-dev"
+	clusterNamespace := "default"
+	networkZone := "eu-central"
 
 	return &parameters.ManifestParameters{
 		HcloudToken:         &hcloudToken,
@@ -35,12 +40,18 @@ func sampleParameters() *parameters.ManifestParameters {
 		Port:                &port,
 		CAcrt:               &caCrt,
 		CAkey:               &caKey,
+		ClusterName:         &clusterName,
+		ClusterNamespace:    &clusterNamespace,
+		PodCIDRBlocks:       []string{"192.168.0.0/16"},
+		ServiceCIDRBlocks:   []string{"10.96.0.0/12"},
+		Locations:           []string{"fsn1", "nbg1"},
+		NetworkZone:         &networkZone,
 	}
 }
 
 func (m *Manifests) initializeConfig() (err error) {
 
-	if err := evaluateJsonnet(ioutil.Discard, m.manifestConfigPath, sampleParameters().ExtVar()); err != nil {
+	if err := evaluateJsonnet(ioutil.Discard, m.manifestConfigPath, sampleParameters()); err != nil {
 		return err
 	}
 	m.log.V(1).Info("manifests config successfully validated", "path", m.manifestConfigPath)
@@ -83,7 +94,19 @@ func dumpYAML(enc *yaml.Encoder, i interface{}) error {
 	return nil
 }
 
-func evaluateJsonnet(out io.Writer, path string, extVars map[string]string) error {
+// snippetFilename is used to report errors in the per cluster snippet
+const snippetFilename = "<cluster-snippet>"
+
+// snippetImporter rejects the imports of the per cluster snippet. The snippet
+// is written by the owner of the cluster, so it must not read the files of
+// the controller, e.g. its service account token.
+type snippetImporter struct{}
+
+func (snippetImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	return jsonnet.Contents{}, "", fmt.Errorf("imports are not allowed in the cluster snippet: %s", importedPath)
+}
+
+func renderJsonnet(path string, p *parameters.ManifestParameters) (interface{}, error) {
 	vm := jsonnet.MakeVM()
 	vm.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
 
@@ -95,20 +118,45 @@ func evaluateJsonnet(out io.Writer, path string, extVars map[string]string) erro
 
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for k, v := range extVars {
+	for k, v := range p.ExtVar() {
 		vm.ExtVar(k, v)
 	}
 
 	output, err := vm.EvaluateSnippet(path, string(bytes))
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if p.Snippet != nil && strings.TrimSpace(*p.Snippet) != "" {
+		// merge the snippet into the rendered config, so super can be used
+		// to refer to the rendered objects
+		snippetVM := jsonnet.MakeVM()
+		snippetVM.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
+		snippetVM.Importer(snippetImporter{})
+		for k, v := range p.ExtVar() {
+			snippetVM.ExtVar(k, v)
+		}
+		snippetVM.ExtCode("manifests", output)
+		output, err = snippetVM.EvaluateSnippet(snippetFilename, fmt.Sprintf("std.extVar('manifests') + (\n%s\n)", *p.Snippet))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var object interface{}
 	if err := json.Unmarshal([]byte(output), &object); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func evaluateJsonnet(out io.Writer, path string, p *parameters.ManifestParameters) error {
+	object, err := renderJsonnet(path, p)
+	if err != nil {
 		return err
 	}
 
@@ -119,3 +167,48 @@ func evaluateJsonnet(out io.Writer, path string, extVars map[string]string) erro
 
 	return nil
 }
+
+// collectObjects walks a parsed json object in the same order as dumpYAML and
+// returns all kubernetes objects found
+func collectObjects(i interface{}) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	switch v := i.(type) {
+	case map[string]interface{}:
+		_, apiVersionExists := v["apiVersion"]
+		_, kindExists := v["kind"]
+		if kindExists && apiVersionExists {
+			return append(objects, &unstructured.Unstructured{Object: v}), nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o, err := collectObjects(v[k])
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, o...)
+		}
+	case []interface{}:
+		for _, e := range v {
+			o, err := collectObjects(e)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, o...)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected type %T", v)
+	}
+	return objects, nil
+}
+
+func renderObjects(path string, p *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	object, err := renderJsonnet(path, p)
+	if err != nil {
+		return nil, err
+	}
+	return collectObjects(object)
+}
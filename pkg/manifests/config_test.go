@@ -3,6 +3,7 @@ package manifests
 import (
 	"bufio"
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -12,7 +13,7 @@ func TestEvaluateJsonnet(t *testing.T) {
 	err := evaluateJsonnet(
 		bufio.NewWriter(&buf),
 		path,
-		sampleParameters().ExtVar(),
+		sampleParameters(),
 	)
 
 	if err != nil {
@@ -24,3 +25,81 @@ func TestEvaluateJsonnet(t *testing.T) {
 		}
 	*/
 }
+
+func TestRenderObjects(t *testing.T) {
+	path := "../../manifests/config-extvar.jsonnet"
+	objects, err := renderObjects(path, sampleParameters())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, obj := range objects {
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			t.Errorf("unexpected object without kind or apiVersion: %+v", obj)
+		}
+	}
+
+	sortObjects(objects)
+	seenOther := false
+	for _, obj := range objects {
+		if obj.GetKind() == "Namespace" && seenOther {
+			t.Errorf("unexpected namespace %s after other objects", obj.GetName())
+		}
+		if obj.GetKind() != "Namespace" && obj.GetKind() != "CustomResourceDefinition" {
+			seenOther = true
+		}
+	}
+}
+
+func TestRenderSnippet(t *testing.T) {
+	path := "../../manifests/config-extvar.jsonnet"
+	p := sampleParameters()
+	snippet := `{
+  extra: {
+    apiVersion: 'v1',
+    kind: 'ConfigMap',
+    metadata: {
+      name: std.extVar('cluster-name'),
+      namespace: 'kube-system',
+    },
+    data: {
+      locations: std.extVar('locations'),
+    },
+  },
+}`
+	p.Snippet = &snippet
+
+	objects, err := renderObjects(path, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var found bool
+	for _, obj := range objects {
+		if obj.GetKind() != "ConfigMap" || obj.GetName() != *p.ClusterName {
+			continue
+		}
+		found = true
+		if act, exp := obj.Object["data"].(map[string]interface{})["locations"], "fsn1,nbg1"; act != exp {
+			t.Errorf("unexpected locations: %s (expected %s)", act, exp)
+		}
+	}
+	if !found {
+		t.Error("object from snippet not found in rendered manifests")
+	}
+}
+
+func TestRenderSnippetImports(t *testing.T) {
+	path := "../../manifests/config-extvar.jsonnet"
+	for _, snippet := range []string{
+		`{ token: importstr '/var/run/secrets/kubernetes.io/serviceaccount/token' }`,
+		`{ config: importstr 'config-extvar.jsonnet' }`,
+		`(import 'addons/params.libsonnet') { }`,
+	} {
+		p := sampleParameters()
+		p.Snippet = &snippet
+		if _, err := renderObjects(path, p); err == nil || !strings.Contains(err.Error(), "imports are not allowed") {
+			t.Errorf("unexpected error for snippet %s: %v", snippet, err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package manifests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+)
+
+// redactedPrefix replaces the values of secrets. Values are replaced by a
+// keyed hash, so equal values can still be compared within a single run.
+const redactedPrefix = "REDACTED-"
+
+// RedactSecrets replaces the values of all Secrets in place
+func RedactSecrets(objects []*unstructured.Unstructured) error {
+	key, err := redactionKey()
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := redactSecret(obj, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func redactionKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "error generating redaction key")
+	}
+	return key, nil
+}
+
+// redactSecret merges stringData into data, as the API server does, and
+// replaces every value by its HMAC
+func redactSecret(obj *unstructured.Unstructured, key []byte) error {
+	if obj.GroupVersionKind().GroupKind().String() != "Secret" {
+		return nil
+	}
+
+	data, _, err := unstructured.NestedStringMap(obj.Object, "data")
+	if err != nil {
+		return errors.Wrapf(err, "invalid data of %s", objectReference(obj))
+	}
+	if data == nil {
+		data = make(map[string]string)
+	}
+	stringData, _, err := unstructured.NestedStringMap(obj.Object, "stringData")
+	if err != nil {
+		return errors.Wrapf(err, "invalid stringData of %s", objectReference(obj))
+	}
+	for k, v := range stringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	unstructured.RemoveNestedField(obj.Object, "stringData")
+	if len(data) == 0 {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(v))
+		redacted[k] = fmt.Sprintf("%s%x", redactedPrefix, mac.Sum(nil)[:8])
+	}
+	return unstructured.SetNestedField(obj.Object, redacted, "data")
+}
+
+// Diff compares the rendered objects with the live objects in the workload
+// cluster. Only the fields set by the rendered objects are compared, fields
+// defaulted by the API server or managed by others are ignored. The values of
+// Secrets are redacted in the diffs.
+func (m *Manifests) Diff(ctx context.Context, client clientcmd.ClientConfig, objects []*unstructured.Unstructured) ([]api.DiffResult, error) {
+	dynamicClient, mapper, err := newClients(client)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := redactionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]api.DiffResult, 0, len(objects))
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		ref := objectReference(obj)
+		resource, err := resourceFor(dynamicClient, mapper, obj.GroupVersionKind(), obj.GetNamespace())
+		if err != nil {
+			return results, errors.Wrapf(err, "error mapping %s", ref)
+		}
+
+		live, err := resource.Get(obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			results = append(results, api.DiffResult{Object: ref, Missing: true})
+			continue
+		} else if err != nil {
+			return results, errors.Wrapf(err, "error getting %s", ref)
+		}
+
+		rendered := obj.DeepCopy()
+		if err := redactSecret(rendered, key); err != nil {
+			return results, err
+		}
+		if err := redactSecret(live, key); err != nil {
+			return results, err
+		}
+
+		diff, err := diffObjects(live.Object, rendered.Object)
+		if err != nil {
+			return results, errors.Wrapf(err, "error comparing %s", ref)
+		}
+		results = append(results, api.DiffResult{Object: ref, Diff: diff})
+	}
+	return results, nil
+}
+
+// diffObjects returns the difference from the live to the rendered object
+func diffObjects(live, rendered map[string]interface{}) (string, error) {
+	// normalize the numbers, the live object holds integers while the
+	// rendered object holds floats
+	var normalizedLive, normalizedRendered interface{}
+	if err := normalize(live, &normalizedLive); err != nil {
+		return "", err
+	}
+	if err := normalize(rendered, &normalizedRendered); err != nil {
+		return "", err
+	}
+	return cmp.Diff(subset(normalizedLive, normalizedRendered), normalizedRendered), nil
+}
+
+func normalize(in interface{}, out *interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// subset removes all fields of the live object, which are not set in the
+// rendered object
+func subset(live, rendered interface{}) interface{} {
+	switch r := rendered.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		result := make(map[string]interface{}, len(r))
+		for k, v := range r {
+			if lv, ok := l[k]; ok {
+				result[k] = subset(lv, v)
+			}
+		}
+		return result
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return live
+		}
+		// additional live elements are kept, as they would be removed by
+		// applying the rendered list
+		result := make([]interface{}, len(l))
+		for pos := range l {
+			if pos < len(r) {
+				result[pos] = subset(l[pos], r[pos])
+			} else {
+				result[pos] = l[pos]
+			}
+		}
+		return result
+	default:
+		return live
+	}
+}
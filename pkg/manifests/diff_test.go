@@ -0,0 +1,99 @@
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffObjects(t *testing.T) {
+	rendered := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "test"},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"args":     []interface{}{"--a"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		live    map[string]interface{}
+		changed bool
+	}{
+		{
+			name: "defaulted fields are ignored",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "test", "uid": "1234"},
+				"spec": map[string]interface{}{
+					"replicas": int64(2),
+					"args":     []interface{}{"--a"},
+					"paused":   false,
+				},
+			},
+		},
+		{
+			name: "changed value",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "test"},
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+					"args":     []interface{}{"--a"},
+				},
+			},
+			changed: true,
+		},
+		{
+			name: "additional list element",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "test"},
+				"spec": map[string]interface{}{
+					"replicas": int64(2),
+					"args":     []interface{}{"--a", "--b"},
+				},
+			},
+			changed: true,
+		},
+	} {
+		diff, err := diffObjects(tc.live, rendered)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+		if changed := diff != ""; changed != tc.changed {
+			t.Errorf("%s: unexpected change: %t (expected %t): %s", tc.name, changed, tc.changed, diff)
+		}
+	}
+}
+
+func TestRedactSecret(t *testing.T) {
+	key := []byte("test")
+	newSecret := func(field, value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			field:        map[string]interface{}{"token": value},
+		}}
+	}
+
+	rendered := newSecret("stringData", "secret-token")
+	live := newSecret("data", "c2VjcmV0LXRva2Vu")
+	for _, obj := range []*unstructured.Unstructured{rendered, live} {
+		if err := redactSecret(obj, key); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	token, _, _ := unstructured.NestedString(rendered.Object, "data", "token")
+	if !strings.HasPrefix(token, redactedPrefix) {
+		t.Errorf("unexpected token: %s (expected to be redacted)", token)
+	}
+	if _, exists := rendered.Object["stringData"]; exists {
+		t.Error("expected stringData to be removed")
+	}
+
+	if diff, err := diffObjects(live.Object, rendered.Object); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if diff != "" {
+		t.Errorf("unexpected diff of equal secrets: %s", diff)
+	}
+}
@@ -7,27 +7,33 @@ import (
 type Manifests struct {
 	log                logr.Logger
 	manifestConfigPath string
-
-	kubectlPath string
+	addonsCatalogPath  string
 }
 
-func New(log logr.Logger, manifestConfigPath string) *Manifests {
+func New(log logr.Logger, manifestConfigPath, addonsCatalogPath string) *Manifests {
 	return &Manifests{
 		log:                log,
 		manifestConfigPath: manifestConfigPath,
+		addonsCatalogPath:  addonsCatalogPath,
 	}
 }
 
+// Enabled returns true, if a manifests config has been set
+func (m *Manifests) Enabled() bool {
+	return m.manifestConfigPath != ""
+}
+
 func (m *Manifests) Initialize() error {
 
-	if m.manifestConfigPath == "" {
-		return nil
-	}
-	if err := m.initializeKubectl(); err != nil {
-		return err
+	if m.manifestConfigPath != "" {
+		if err := m.initializeConfig(); err != nil {
+			return err
+		}
 	}
-	if err := m.initializeConfig(); err != nil {
-		return err
+	if m.addonsCatalogPath != "" {
+		if err := m.initializeAddons(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
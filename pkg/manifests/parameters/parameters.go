@@ -1,6 +1,8 @@
 package parameters
 
 import (
+	"strings"
+
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -14,11 +16,61 @@ type ManifestParameters struct {
 	Port                *string
 	CAcrt               *string
 	CAkey               *string
+	ClusterName         *string
+	ClusterNamespace    *string
+	PodCIDRBlocks       []string
+	ServiceCIDRBlocks   []string
+	Locations           []string
+	NetworkZone         *string
+
+	// ExtraExtVar are additional ext-vars configured per cluster, they can not
+	// override the built-in ext-vars
+	ExtraExtVar map[string]string
+
+	// Snippet is a jsonnet snippet merged into the rendered manifests
+	Snippet *string
+}
+
+// builtinExtVars are set from the parameters, even if they are not passed to
+// the manifests, e.g. the robot credentials of a cluster without them
+var builtinExtVars = []string{
+	"kube-apiserver-ip",
+	"kube-apiserver-domain",
+	"port",
+	"hcloud-token",
+	"robot-username",
+	"robot-password",
+	"ca-crt",
+	"ca-key",
+	"hcloud-network",
+	"cluster-name",
+	"cluster-namespace",
+	"network-zone",
+	"pod-cidr-blocks",
+	"service-cidr-blocks",
+	"locations",
+}
+
+// IsBuiltinExtVar returns true if the ext-var is set from the parameters and
+// can not be set per cluster
+func IsBuiltinExtVar(key string) bool {
+	for _, builtin := range builtinExtVars {
+		if key == builtin {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *ManifestParameters) ExtVar() map[string]string {
 	extVar := make(map[string]string)
 
+	for key, val := range m.ExtraExtVar {
+		if !IsBuiltinExtVar(key) {
+			extVar[key] = val
+		}
+	}
+
 	if key, val := "kube-apiserver-ip", m.KubeAPIServerIPv4; val != nil {
 		extVar[key] = *val
 	} else {
@@ -63,5 +115,28 @@ func (m *ManifestParameters) ExtVar() map[string]string {
 		extVar[key] = ""
 	}
 
+	if key, val := "cluster-name", m.ClusterName; val != nil {
+		extVar[key] = *val
+	} else {
+		extVar[key] = ""
+	}
+
+	if key, val := "cluster-namespace", m.ClusterNamespace; val != nil {
+		extVar[key] = *val
+	} else {
+		extVar[key] = ""
+	}
+
+	if key, val := "network-zone", m.NetworkZone; val != nil {
+		extVar[key] = *val
+	} else {
+		extVar[key] = ""
+	}
+
+	// lists are passed comma separated
+	extVar["pod-cidr-blocks"] = strings.Join(m.PodCIDRBlocks, ",")
+	extVar["service-cidr-blocks"] = strings.Join(m.ServiceCIDRBlocks, ",")
+	extVar["locations"] = strings.Join(m.Locations, ",")
+
 	return extVar
 }
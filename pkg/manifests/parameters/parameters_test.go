@@ -0,0 +1,37 @@
+package parameters
+
+import (
+	"testing"
+)
+
+func TestManifestParameters_ExtVar(t *testing.T) {
+	token := "my-token"
+	clusterName := "cluster-dev"
+	p := &ManifestParameters{
+		HcloudToken: &token,
+		ClusterName: &clusterName,
+		ExtraExtVar: map[string]string{
+			"hcloud-token":   "other-token",
+			"robot-password": "other-password",
+			"ca-key":         "other-key",
+			"cluster-name":   "other-cluster",
+			"custom":         "value",
+		},
+	}
+
+	extVar := p.ExtVar()
+	if act, exp := extVar["hcloud-token"], token; act != exp {
+		t.Errorf("unexpected hcloud-token: %s (expected %s)", act, exp)
+	}
+	if act, exp := extVar["cluster-name"], clusterName; act != exp {
+		t.Errorf("unexpected cluster-name: %s (expected %s)", act, exp)
+	}
+	for _, key := range []string{"robot-password", "ca-key"} {
+		if act, ok := extVar[key]; ok {
+			t.Errorf("unexpected %s: %s (expected it to be unset)", key, act)
+		}
+	}
+	if act, exp := extVar["custom"], "value"; act != exp {
+		t.Errorf("unexpected custom: %s (expected %s)", act, exp)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// BuildPhase describes the state of the packer build for a template hash
+type BuildPhase string
+
+const (
+	BuildPhaseRunning   = BuildPhase("Running")
+	BuildPhaseFailed    = BuildPhase("Failed")
+	BuildPhaseSucceeded = BuildPhase("Succeeded")
+)
+
+// MaxBuildLogSize limits the output kept per build, so it fits into a
+// ConfigMap
+const MaxBuildLogSize = 256 * 1024
+
+// BuildLog is a snapshot of the output of the latest packer build for a
+// template hash. Output keeps at most the last MaxBuildLogSize bytes, prefixed
+// with a marker if the beginning had to be dropped.
+type BuildLog struct {
+	Hash     string
+	Phase    BuildPhase
+	Attempts int
+	Output   []byte
+}
+
+// BuildError is returned by EnsureImage while the latest packer build for a
+// template hash has failed and the retry backoff has not expired yet
+type BuildError struct {
+	Hash       string
+	Attempts   int
+	RetryAfter time.Duration
+	Summary    string
+	Err        error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("packer build for template hash %s failed (attempt %d): %v: %s", e.Hash, e.Attempts, e.Err, e.Summary)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
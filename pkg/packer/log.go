@@ -0,0 +1,43 @@
+package packer
+
+import (
+	"sync"
+)
+
+// logBuffer is an io.Writer that keeps only the tail of the written data, so
+// a long running or failing build cannot grow without limits
+type logBuffer struct {
+	lock      sync.Mutex
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func newLogBuffer(max int) *logBuffer {
+	return &logBuffer{max: max}
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if overflow := len(b.buf) - b.max; overflow > 0 {
+		b.buf = append([]byte(nil), b.buf[overflow:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the kept output, prefixed with a marker if the
+// beginning had to be dropped
+func (b *logBuffer) Bytes() []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var out []byte
+	if b.truncated {
+		out = append(out, []byte("[...truncated...]\n")...)
+	}
+	return append(out, b.buf...)
+}
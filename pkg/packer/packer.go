@@ -13,6 +13,7 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/hetznercloud/hcloud-go/hcloud"
@@ -23,6 +24,14 @@ import (
 
 const envHcloudToken = "HCLOUD_TOKEN"
 
+const (
+	// maxBuildLogSummary limits the length of the summary used in events
+	maxBuildLogSummary = 256
+
+	buildBackoffBase = time.Minute
+	buildBackoffMax  = 30 * time.Minute
+)
+
 type Packer struct {
 	log              logr.Logger
 	packerConfigPath string
@@ -31,34 +40,57 @@ type Packer struct {
 
 	buildsLock sync.Mutex
 	builds     map[string]*build
+	failures   map[string]*buildFailure
+	logs       map[string]*api.BuildLog
 }
 
 type build struct {
 	*exec.Cmd
-	terminated bool
-	result     error
-	stdout     bytes.Buffer
-	stderr     bytes.Buffer
+	done   chan struct{}
+	result error
+	output *logBuffer
+}
+
+// buildFailure tracks failed builds of a template hash, to retry them with
+// an exponential backoff
+type buildFailure struct {
+	attempts int
+	err      error
+	summary  string
+	retryAt  time.Time
 }
 
 func (b *build) Start() error {
-	b.Cmd.Stdout = &b.stdout
-	b.Cmd.Stderr = &b.stderr
+	b.output = newLogBuffer(api.MaxBuildLogSize)
+	b.done = make(chan struct{})
+	b.Cmd.Stdout = b.output
+	b.Cmd.Stderr = b.output
 	err := b.Cmd.Start()
 	if err != nil {
 		return err
 	}
 	go func() {
 		b.result = b.Cmd.Wait()
-		b.terminated = true
+		close(b.done)
 	}()
 	return err
 }
 
+func (b *build) terminated() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func New(log logr.Logger) *Packer {
 	return &Packer{
-		log:    log,
-		builds: make(map[string]*build),
+		log:      log,
+		builds:   make(map[string]*build),
+		failures: make(map[string]*buildFailure),
+		logs:     make(map[string]*api.BuildLog),
 	}
 }
 
@@ -134,7 +166,8 @@ func (m *Packer) packerCmd(ctx context.Context, args ...string) *exec.Cmd {
 }
 
 // EnsureImage checks if the API has an image already build and if not, it will
-// run packer build to create one
+// run packer build to create one. Failed builds are retried with an
+// exponential backoff, until then a *api.BuildError is returned.
 func (m *Packer) EnsureImage(ctx context.Context, log logr.Logger, hc api.HcloudClient, parameters *api.PackerParameters) (*infrav1.HcloudImageID, error) {
 
 	hash := parameters.Hash()
@@ -145,20 +178,46 @@ func (m *Packer) EnsureImage(ctx context.Context, log logr.Logger, hc api.Hcloud
 	defer m.buildsLock.Unlock()
 	if b, ok := m.builds[hash]; ok {
 		// build still running
-		if !b.terminated {
+		if !b.terminated() {
 			log.V(1).Info("packer image build still running", "parameters", parameters)
 			return nil, nil
 		}
+		delete(m.builds, hash)
 
 		// check if build has been finished with error
 		if err := b.result; err != nil {
-			delete(m.builds, hash)
-			return nil, fmt.Errorf("%v stdout=%s stderr=%s", err, b.stdout.String(), b.stderr.String())
+			f := m.failures[hash]
+			if f == nil {
+				f = &buildFailure{}
+				m.failures[hash] = f
+			}
+			f.attempts++
+			f.err = err
+			f.summary = summarizeBuildLog(b.output.Bytes())
+			f.retryAt = time.Now().Add(buildBackoff(f.attempts))
+			m.logs[hash].Phase = api.BuildPhaseFailed
+			m.logs[hash].Output = b.output.Bytes()
+			log.Info("packer image build failed", "parameters", parameters, "attempts", f.attempts, "error", err.Error(), "summary", f.summary)
+		} else {
+			// remove failures as the build had been successful
+			log.Info("packer image successfully built", "parameters", parameters)
+			delete(m.failures, hash)
+			m.logs[hash].Phase = api.BuildPhaseSucceeded
+			m.logs[hash].Output = b.output.Bytes()
 		}
+	}
 
-		// remove build as it had been successful
-		log.Info("packer image successfully built", "parameters", parameters)
-		delete(m.builds, hash)
+	// wait for the backoff of a failed build to expire
+	if f, ok := m.failures[hash]; ok {
+		if retryAfter := time.Until(f.retryAt); retryAfter > 0 {
+			return nil, &api.BuildError{
+				Hash:       hash,
+				Attempts:   f.attempts,
+				RetryAfter: retryAfter,
+				Summary:    f.summary,
+				Err:        f.err,
+			}
+		}
 	}
 
 	// query for an existing image
@@ -199,9 +258,59 @@ func (m *Packer) EnsureImage(ctx context.Context, log logr.Logger, hc api.Hcloud
 	}
 
 	m.builds[hash] = b
+	attempts := 1
+	if f, ok := m.failures[hash]; ok {
+		attempts += f.attempts
+	}
+	m.logs[hash] = &api.BuildLog{
+		Hash:     hash,
+		Phase:    api.BuildPhaseRunning,
+		Attempts: attempts,
+	}
 	return nil, nil
 }
 
+// BuildLog returns a snapshot of the output of the latest build for the
+// template hash, it returns nil if no build has been started by this
+// controller
+func (m *Packer) BuildLog(hash string) *api.BuildLog {
+	m.buildsLock.Lock()
+	defer m.buildsLock.Unlock()
+
+	l, ok := m.logs[hash]
+	if !ok {
+		return nil
+	}
+	snapshot := *l
+	if b, ok := m.builds[hash]; ok {
+		snapshot.Output = b.output.Bytes()
+	}
+	return &snapshot
+}
+
+// buildBackoff returns the time to wait before retrying a build that failed
+// the given number of times
+func buildBackoff(attempts int) time.Duration {
+	backoff := buildBackoffBase
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= buildBackoffMax {
+			return buildBackoffMax
+		}
+	}
+	return backoff
+}
+
+// summarizeBuildLog returns the last non-empty line of the build output
+func summarizeBuildLog(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	summary := strings.TrimSpace(lines[len(lines)-1])
+	if len(summary) > maxBuildLogSummary {
+		summary = summary[:maxBuildLogSummary] + "..."
+	}
+	return summary
+}
+
 func ExtractTarGz(gzipStream io.Reader) error {
 	uncompressedStream, err := gzip.NewReader(gzipStream)
 	if err != nil {
@@ -238,7 +347,7 @@ func ExtractTarGz(gzipStream io.Reader) error {
 
 		default:
 			return fmt.Errorf(
-				"ExtractTarGz: unknown type: %s in %s",
+				"ExtractTarGz: unknown type: %v in %s",
 				header.Typeflag,
 				header.Name)
 		}
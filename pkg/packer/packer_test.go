@@ -0,0 +1,44 @@
+package packer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := newLogBuffer(10)
+	fmt.Fprint(b, "01234")
+	if exp, act := "01234", string(b.Bytes()); exp != act {
+		t.Errorf("unexpected output: %q (expected %q)", act, exp)
+	}
+
+	fmt.Fprint(b, "56789abc")
+	if exp, act := "[...truncated...]\n3456789abc", string(b.Bytes()); exp != act {
+		t.Errorf("unexpected output: %q (expected %q)", act, exp)
+	}
+}
+
+func TestBuildBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{6, 30 * time.Minute},
+		{100, 30 * time.Minute},
+	} {
+		if act := buildBackoff(tc.attempts); act != tc.expected {
+			t.Errorf("unexpected backoff for %d attempts: %s (expected %s)", tc.attempts, act, tc.expected)
+		}
+	}
+}
+
+func TestSummarizeBuildLog(t *testing.T) {
+	output := []byte("==> hcloud: Creating server...\n==> hcloud: Error: server type not found\n\n")
+	if exp, act := "==> hcloud: Error: server type not found", summarizeBuildLog(output); exp != act {
+		t.Errorf("unexpected summary: %q (expected %q)", act, exp)
+	}
+}
@@ -0,0 +1,66 @@
+package rollout
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// DeploymentStatus returns an empty message if the deployment has been rolled
+// out completely, otherwise the message describes what it is waiting for.
+func DeploymentStatus(d *appsv1.Deployment) string {
+	if d.Generation > d.Status.ObservedGeneration {
+		return fmt.Sprintf("deployment %s/%s: waiting for spec update to be observed", d.Namespace, d.Name)
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas < replicas {
+		return fmt.Sprintf("deployment %s/%s: %d out of %d new replicas have been updated", d.Namespace, d.Name, d.Status.UpdatedReplicas, replicas)
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		return fmt.Sprintf("deployment %s/%s: %d old replicas are pending termination", d.Namespace, d.Name, d.Status.Replicas-d.Status.UpdatedReplicas)
+	}
+	if d.Status.AvailableReplicas < d.Status.UpdatedReplicas {
+		return fmt.Sprintf("deployment %s/%s: %d of %d updated replicas are available", d.Namespace, d.Name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return ""
+}
+
+// DaemonSetStatus returns an empty message if the daemon set has been rolled
+// out completely, otherwise the message describes what it is waiting for.
+func DaemonSetStatus(ds *appsv1.DaemonSet) string {
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return fmt.Sprintf("daemon set %s/%s: waiting for spec update to be observed", ds.Namespace, ds.Name)
+	}
+	if ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("daemon set %s/%s: %d out of %d new pods have been updated", ds.Namespace, ds.Name, ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	}
+	if ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("daemon set %s/%s: %d of %d updated pods are available", ds.Namespace, ds.Name, ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+	}
+	return ""
+}
+
+// DeploymentUnavailable returns true, if the deployment is not rolling out a
+// new generation, but not all of its replicas are available.
+func DeploymentUnavailable(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Generation <= d.Status.ObservedGeneration &&
+		d.Status.UpdatedReplicas >= replicas &&
+		d.Status.Replicas <= d.Status.UpdatedReplicas &&
+		d.Status.AvailableReplicas < d.Status.UpdatedReplicas
+}
+
+// DaemonSetUnavailable returns true, if the daemon set is not rolling out a
+// new generation, but not all of its pods are available.
+func DaemonSetUnavailable(ds *appsv1.DaemonSet) bool {
+	return ds.Generation <= ds.Status.ObservedGeneration &&
+		ds.Status.UpdatedNumberScheduled >= ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled
+}
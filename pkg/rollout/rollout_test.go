@@ -0,0 +1,92 @@
+package rollout
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentStatus(t *testing.T) {
+	replicas := int32(2)
+	for _, tc := range []struct {
+		name        string
+		status      appsv1.DeploymentStatus
+		done        bool
+		unavailable bool
+	}{
+		{
+			name:   "spec update not observed",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:   "replicas not updated",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+		},
+		{
+			name:   "old replicas pending termination",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:        "replicas not available",
+			status:      appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			unavailable: true,
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			done:   true,
+		},
+	} {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kube-system", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     tc.status,
+		}
+		if msg := DeploymentStatus(d); (msg == "") != tc.done {
+			t.Errorf("%s: unexpected status message: '%s'", tc.name, msg)
+		}
+		if unavailable := DeploymentUnavailable(d); unavailable != tc.unavailable {
+			t.Errorf("%s: expected unavailable %v, got %v", tc.name, tc.unavailable, unavailable)
+		}
+	}
+}
+
+func TestDaemonSetStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		status      appsv1.DaemonSetStatus
+		done        bool
+		unavailable bool
+	}{
+		{
+			name:   "spec update not observed",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+		},
+		{
+			name:   "pods not updated",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 2, NumberAvailable: 3},
+		},
+		{
+			name:        "pods not available",
+			status:      appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+			unavailable: true,
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			done:   true,
+		},
+	} {
+		ds := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kube-system", Generation: 2},
+			Status:     tc.status,
+		}
+		if msg := DaemonSetStatus(ds); (msg == "") != tc.done {
+			t.Errorf("%s: unexpected status message: '%s'", tc.name, msg)
+		}
+		if unavailable := DaemonSetUnavailable(ds); unavailable != tc.unavailable {
+			t.Errorf("%s: expected unavailable %v, got %v", tc.name, tc.unavailable, unavailable)
+		}
+	}
+}
@@ -6,13 +6,14 @@ import (
 	"strconv"
 
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	manifestsapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
 	"github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/parameters"
 	packerapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer/api"
 	"github.com/go-logr/logr"
-	"github.com/hetznercloud/hcloud-go/hcloud"
 	hrobot "github.com/nl2go/hrobot-go"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
@@ -29,11 +30,18 @@ const defaultControlPlaneAPIEndpointPort = 6443
 
 type Packer interface {
 	EnsureImage(ctx context.Context, log logr.Logger, hc packerapi.HcloudClient, parameters *packerapi.PackerParameters) (*infrav1.HcloudImageID, error)
+	BuildLog(hash string) *packerapi.BuildLog
 }
 
 type Manifests interface {
-	Apply(ctx context.Context, client clientcmd.ClientConfig, extVar map[string]string) error
-	Hash(extVar map[string]string) (string, error)
+	Enabled() bool
+	Apply(ctx context.Context, client clientcmd.ClientConfig, parameters *parameters.ManifestParameters) ([]manifestsapi.ApplyResult, error)
+	Prune(ctx context.Context, client clientcmd.ClientConfig, objects []manifestsapi.ObjectReference) ([]manifestsapi.PruneResult, error)
+	Hash(parameters *parameters.ManifestParameters) (string, error)
+	ApplyAddon(ctx context.Context, client clientcmd.ClientConfig, addon manifestsapi.Addon, parameters *parameters.ManifestParameters) ([]manifestsapi.ApplyResult, error)
+	AddonHash(addon manifestsapi.Addon, parameters *parameters.ManifestParameters) (string, error)
+	Render(parameters *parameters.ManifestParameters) ([]*unstructured.Unstructured, error)
+	RenderAddon(addon manifestsapi.Addon, parameters *parameters.ManifestParameters) ([]*unstructured.Unstructured, error)
 }
 
 // ClusterScopeParams defines the input parameters used to create a new Scope.
@@ -50,6 +58,10 @@ type ClusterScopeParams struct {
 	HcloudCluster       *infrav1.HcloudCluster
 	Packer              Packer
 	Manifests           Manifests
+
+	// RequireSeparateWorkloadCredentials refuses to pass the credentials of
+	// the controller to the manifests
+	RequireSeparateWorkloadCredentials bool
 }
 
 // NewClusterScope creates a new Scope from the supplied parameters.
@@ -93,7 +105,7 @@ func NewClusterScope(params ClusterScopeParams) (*ClusterScope, error) {
 			}
 			hcloudToken = string(tokenBytes)
 
-			return &realHcloudClient{client: hcloud.NewClient(hcloud.WithToken(hcloudToken)), token: hcloudToken}, nil
+			return DefaultHcloudClientCache.Get(hcloudToken), nil
 		}
 	}
 
@@ -155,6 +167,8 @@ func NewClusterScope(params ClusterScopeParams) (*ClusterScope, error) {
 		patchHelper:   helper,
 		packer:        params.Packer,
 		manifests:     params.Manifests,
+
+		requireSeparateWorkloadCredentials: params.RequireSeparateWorkloadCredentials,
 	}, nil
 }
 
@@ -173,6 +187,8 @@ type ClusterScope struct {
 	packer        Packer
 	manifests     Manifests
 
+	requireSeparateWorkloadCredentials bool
+
 	Cluster       *clusterv1.Cluster
 	HcloudCluster *infrav1.HcloudCluster
 }
@@ -263,9 +279,13 @@ func (s *ClusterScope) manifestParameters() (*parameters.ManifestParameters, err
 		p.KubeAPIServerDomain = &emptyString
 	}
 
-	p.HcloudToken = &s.hcloudToken
-	p.RobotUserName = &s.robotUserName
-	p.RobotPassword = &s.robotPassword
+	hcloudToken, robotUserName, robotPassword, err := s.workloadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	p.HcloudToken = &hcloudToken
+	p.RobotUserName = &robotUserName
+	p.RobotPassword = &robotPassword
 
 	if s.HcloudCluster.Status.Network != nil {
 		hcloudNetwork := intstr.FromInt(s.HcloudCluster.Status.Network.ID)
@@ -301,9 +321,118 @@ func (s *ClusterScope) manifestParameters() (*parameters.ManifestParameters, err
 
 	p.CAcrt = &CaCrtString
 	p.CAkey = &CaKeyString
+
+	clusterName := s.Cluster.Name
+	clusterNamespace := s.Cluster.Namespace
+	p.ClusterName = &clusterName
+	p.ClusterNamespace = &clusterNamespace
+	if n := s.Cluster.Spec.ClusterNetwork; n != nil {
+		if n.Pods != nil {
+			p.PodCIDRBlocks = n.Pods.CIDRBlocks
+		}
+		if n.Services != nil {
+			p.ServiceCIDRBlocks = n.Services.CIDRBlocks
+		}
+	}
+	for _, l := range s.HcloudCluster.Status.Locations {
+		p.Locations = append(p.Locations, string(l))
+	}
+	networkZone := string(s.HcloudCluster.Status.NetworkZone)
+	p.NetworkZone = &networkZone
+
+	if m := s.HcloudCluster.Spec.Manifests; m != nil {
+		if m.Snippet != nil {
+			p.Snippet = m.Snippet
+		} else if ref := m.SnippetConfigMapRef; ref != nil {
+			configMap := &corev1.ConfigMap{}
+			key := types.NamespacedName{Namespace: s.Namespace(), Name: ref.Name}
+			if err := s.Client.Get(s.Ctx, key, configMap); err != nil {
+				return nil, errors.Wrapf(err, "failed to retrieve manifests snippet configmap %s/%s", key.Namespace, key.Name)
+			}
+			snippet, ok := configMap.Data[ref.Key]
+			if !ok {
+				return nil, errors.Errorf("manifests snippet configmap %s/%s is missing key %s", key.Namespace, key.Name, ref.Key)
+			}
+			p.Snippet = &snippet
+		}
+
+		if ref := m.ExtVarsConfigMapRef; ref != nil {
+			configMap := &corev1.ConfigMap{}
+			key := types.NamespacedName{Namespace: s.Namespace(), Name: ref.Name}
+			if err := s.Client.Get(s.Ctx, key, configMap); err != nil {
+				return nil, errors.Wrapf(err, "failed to retrieve manifests ext-vars configmap %s/%s", key.Namespace, key.Name)
+			}
+			for extVar := range configMap.Data {
+				if parameters.IsBuiltinExtVar(extVar) {
+					return nil, errors.Errorf("manifests ext-vars configmap %s/%s must not set the built-in ext-var %s", key.Namespace, key.Name, extVar)
+				}
+			}
+			p.ExtraExtVar = configMap.Data
+		}
+	}
+
 	return &p, nil
 }
 
+// workloadCredentials returns the credentials passed to the manifests. Without
+// separate workload credentials the credentials of the controller are used,
+// unless separation is required.
+func (s *ClusterScope) workloadCredentials() (hcloudToken, robotUserName, robotPassword string, err error) {
+	creds := s.HcloudCluster.Spec.WorkloadCredentials
+	if creds == nil {
+		creds = &infrav1.HcloudClusterWorkloadCredentials{}
+	}
+
+	if ref := creds.HcloudTokenRef; ref != nil {
+		hcloudToken, err = s.secretValue(ref.Name, ref.Key)
+		if err != nil {
+			return "", "", "", errors.Wrap(err, "failed to retrieve workload hcloud token")
+		}
+	} else if s.requireSeparateWorkloadCredentials {
+		return "", "", "", errors.New("separate workload credentials are required, but no workload hcloud token is referenced")
+	} else {
+		hcloudToken = s.hcloudToken
+	}
+
+	if ref := creds.HrobotTokenRef; ref != nil {
+		robotUserName, err = s.secretValue(ref.TokenName, ref.UserNameKey)
+		if err != nil {
+			return "", "", "", errors.Wrap(err, "failed to retrieve workload robot user name")
+		}
+		robotPassword, err = s.secretValue(ref.TokenName, ref.PasswordKey)
+		if err != nil {
+			return "", "", "", errors.Wrap(err, "failed to retrieve workload robot password")
+		}
+	} else if !s.requireSeparateWorkloadCredentials {
+		robotUserName = s.robotUserName
+		robotPassword = s.robotPassword
+	}
+
+	if s.requireSeparateWorkloadCredentials {
+		if s.hcloudToken != "" && hcloudToken == s.hcloudToken {
+			return "", "", "", errors.New("separate workload credentials are required, but the workload hcloud token is the token of the controller")
+		}
+		if s.robotPassword != "" && robotUserName == s.robotUserName && robotPassword == s.robotPassword {
+			return "", "", "", errors.New("separate workload credentials are required, but the workload robot credentials are the credentials of the controller")
+		}
+	}
+
+	return hcloudToken, robotUserName, robotPassword, nil
+}
+
+func (s *ClusterScope) secretValue(name, key string) (string, error) {
+	var secret corev1.Secret
+	secretName := types.NamespacedName{Namespace: s.Namespace(), Name: name}
+	if err := s.Client.Get(s.Ctx, secretName, &secret); err != nil {
+		return "", errors.Errorf("error getting referenced secret/%s: %s", secretName, err)
+	}
+	value, keyExists := secret.Data[key]
+	if !keyExists {
+		return "", errors.Errorf("error key %s does not exist in secret/%s", key, secretName)
+	}
+	return string(value), nil
+}
+
 func (s *ClusterScope) ListMachines(ctx context.Context) ([]*clusterv1.Machine, []*infrav1.HcloudMachine, error) {
 	// get and index Machines by HcloudMachine name
 	var machineListRaw clusterv1.MachineList
@@ -358,18 +487,59 @@ func IsControlPlaneReady(ctx context.Context, c clientcmd.ClientConfig) error {
 	return err
 }
 
+// ManifestsEnabled returns true, if the controller has a manifests config
+func (s *ClusterScope) ManifestsEnabled() bool {
+	return s.manifests.Enabled()
+}
+
 func (s *ClusterScope) ManifestsHash() (string, error) {
 	manifestParameters, err := s.manifestParameters()
 	if err != nil {
 		return "", err
 	}
-	return s.manifests.Hash(manifestParameters.ExtVar())
+	return s.manifests.Hash(manifestParameters)
 }
 
-func (s *ClusterScope) ApplyManifestsWithClientConfig(ctx context.Context, c clientcmd.ClientConfig) error {
+func (s *ClusterScope) ApplyManifestsWithClientConfig(ctx context.Context, c clientcmd.ClientConfig) ([]manifestsapi.ApplyResult, error) {
 	manifestParameters, err := s.manifestParameters()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return s.manifests.Apply(ctx, c, manifestParameters)
+}
+
+func (s *ClusterScope) PruneManifestsWithClientConfig(ctx context.Context, c clientcmd.ClientConfig, objects []manifestsapi.ObjectReference) ([]manifestsapi.PruneResult, error) {
+	return s.manifests.Prune(ctx, c, objects)
+}
+
+func (s *ClusterScope) AddonHash(addon manifestsapi.Addon) (string, error) {
+	manifestParameters, err := s.manifestParameters()
+	if err != nil {
+		return "", err
+	}
+	return s.manifests.AddonHash(addon, manifestParameters)
+}
+
+func (s *ClusterScope) ApplyAddonWithClientConfig(ctx context.Context, c clientcmd.ClientConfig, addon manifestsapi.Addon) ([]manifestsapi.ApplyResult, error) {
+	manifestParameters, err := s.manifestParameters()
+	if err != nil {
+		return nil, err
+	}
+	return s.manifests.ApplyAddon(ctx, c, addon, manifestParameters)
+}
+
+func (s *ClusterScope) RenderManifests() ([]*unstructured.Unstructured, error) {
+	manifestParameters, err := s.manifestParameters()
+	if err != nil {
+		return nil, err
+	}
+	return s.manifests.Render(manifestParameters)
+}
+
+func (s *ClusterScope) RenderAddon(addon manifestsapi.Addon) ([]*unstructured.Unstructured, error) {
+	manifestParameters, err := s.manifestParameters()
+	if err != nil {
+		return nil, err
 	}
-	return s.manifests.Apply(ctx, c, manifestParameters.ExtVar())
+	return s.manifests.RenderAddon(addon, manifestParameters)
 }
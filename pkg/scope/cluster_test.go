@@ -0,0 +1,100 @@
+package scope
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+func TestClusterScope_WorkloadCredentials(t *testing.T) {
+	newScope := func(creds *infrav1.HcloudClusterWorkloadCredentials, require bool) *ClusterScope {
+		s := newFakeClusterScope()
+		s.Ctx = context.TODO()
+		s.HcloudCluster.Namespace = "default"
+		s.HcloudCluster.Spec.WorkloadCredentials = creds
+		s.hcloudToken = "controller-token"
+		s.robotUserName = "controller-user"
+		s.robotPassword = "controller-password"
+		s.requireSeparateWorkloadCredentials = require
+		s.Client = fake.NewFakeClientWithScheme(scheme.Scheme,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "workload"},
+				Data: map[string][]byte{
+					"token":    []byte("workload-token"),
+					"user":     []byte("workload-user"),
+					"password": []byte("workload-password"),
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "controller"},
+				Data:       map[string][]byte{"token": []byte("controller-token")},
+			},
+		)
+		return s
+	}
+	tokenRef := func(name string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "token"}
+	}
+
+	for _, tc := range []struct {
+		name          string
+		creds         *infrav1.HcloudClusterWorkloadCredentials
+		require       bool
+		expectedToken string
+		expectedUser  string
+		expectedErr   bool
+	}{
+		{
+			name:          "controller credentials by default",
+			expectedToken: "controller-token",
+			expectedUser:  "controller-user",
+		},
+		{
+			name:          "separate token with controller robot credentials",
+			creds:         &infrav1.HcloudClusterWorkloadCredentials{HcloudTokenRef: tokenRef("workload")},
+			expectedToken: "workload-token",
+			expectedUser:  "controller-user",
+		},
+		{
+			name:        "required but missing",
+			require:     true,
+			expectedErr: true,
+		},
+		{
+			name:        "required but controller token",
+			creds:       &infrav1.HcloudClusterWorkloadCredentials{HcloudTokenRef: tokenRef("controller")},
+			require:     true,
+			expectedErr: true,
+		},
+		{
+			name:          "required without robot credentials",
+			creds:         &infrav1.HcloudClusterWorkloadCredentials{HcloudTokenRef: tokenRef("workload")},
+			require:       true,
+			expectedToken: "workload-token",
+		},
+	} {
+		token, user, _, err := newScope(tc.creds, tc.require).workloadCredentials()
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+		if token != tc.expectedToken {
+			t.Errorf("%s: unexpected token: %s (expected %s)", tc.name, token, tc.expectedToken)
+		}
+		if user != tc.expectedUser {
+			t.Errorf("%s: unexpected robot user: %s (expected %s)", tc.name, user, tc.expectedUser)
+		}
+	}
+}
@@ -13,6 +13,7 @@ type HcloudClient interface {
 	CreateLoadBalancer(context.Context, hcloud.LoadBalancerCreateOpts) (hcloud.LoadBalancerCreateResult, *hcloud.Response, error)
 	DeleteLoadBalancer(context.Context, *hcloud.LoadBalancer) (*hcloud.Response, error)
 	ListLoadBalancers(context.Context, hcloud.LoadBalancerListOpts) ([]*hcloud.LoadBalancer, error)
+	UpdateLoadBalancer(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, *hcloud.Response, error)
 	AttachLoadBalancerToNetwork(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error)
 	GetLoadBalancerTypeByName(context.Context, string) (*hcloud.LoadBalancerType, *hcloud.Response, error)
 	AddTargetServerToLoadBalancer(context.Context, hcloud.LoadBalancerAddServerTargetOpts, *hcloud.LoadBalancer) (*hcloud.Action, *hcloud.Response, error)
@@ -22,13 +23,19 @@ type HcloudClient interface {
 	CreateServer(context.Context, hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, *hcloud.Response, error)
 	ListServers(context.Context, hcloud.ServerListOpts) ([]*hcloud.Server, error)
 	GetServerByID(context.Context, int) (*hcloud.Server, *hcloud.Response, error)
+	UpdateServer(context.Context, *hcloud.Server, hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error)
+	AttachServerToNetwork(context.Context, *hcloud.Server, hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error)
 	DeleteServer(context.Context, *hcloud.Server) (*hcloud.Response, error)
 	ShutdownServer(context.Context, *hcloud.Server) (*hcloud.Action, *hcloud.Response, error)
+	RebootServer(context.Context, *hcloud.Server) (*hcloud.Action, *hcloud.Response, error)
+	ResetServer(context.Context, *hcloud.Server) (*hcloud.Action, *hcloud.Response, error)
 	CreateVolume(context.Context, hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, *hcloud.Response, error)
 	ListVolumes(context.Context, hcloud.VolumeListOpts) ([]*hcloud.Volume, error)
+	UpdateVolume(context.Context, *hcloud.Volume, hcloud.VolumeUpdateOpts) (*hcloud.Volume, *hcloud.Response, error)
 	DeleteVolume(context.Context, *hcloud.Volume) (*hcloud.Response, error)
 	CreateNetwork(context.Context, hcloud.NetworkCreateOpts) (*hcloud.Network, *hcloud.Response, error)
 	ListNetworks(context.Context, hcloud.NetworkListOpts) ([]*hcloud.Network, error)
+	UpdateNetwork(context.Context, *hcloud.Network, hcloud.NetworkUpdateOpts) (*hcloud.Network, *hcloud.Response, error)
 	DeleteNetwork(context.Context, *hcloud.Network) (*hcloud.Response, error)
 	ListSSHKeys(ctx context.Context, opts hcloud.SSHKeyListOpts) ([]*hcloud.SSHKey, *hcloud.Response, error)
 }
@@ -62,6 +69,10 @@ func (c *realHcloudClient) ListLoadBalancers(ctx context.Context, opts hcloud.Lo
 	return c.client.LoadBalancer.AllWithOpts(ctx, opts)
 }
 
+func (c *realHcloudClient) UpdateLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, *hcloud.Response, error) {
+	return c.client.LoadBalancer.Update(ctx, lb, opts)
+}
+
 func (c *realHcloudClient) AttachLoadBalancerToNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
 	return c.client.LoadBalancer.AttachToNetwork(ctx, lb, opts)
 }
@@ -98,11 +109,27 @@ func (c *realHcloudClient) GetServerByID(ctx context.Context, id int) (*hcloud.S
 	return c.client.Server.GetByID(ctx, id)
 }
 
+func (c *realHcloudClient) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error) {
+	return c.client.Server.Update(ctx, server, opts)
+}
+
+func (c *realHcloudClient) AttachServerToNetwork(ctx context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
+	return c.client.Server.AttachToNetwork(ctx, server, opts)
+}
+
 func (c *realHcloudClient) ShutdownServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
 
 	return c.client.Server.Shutdown(ctx, server)
 }
 
+func (c *realHcloudClient) RebootServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	return c.client.Server.Reboot(ctx, server)
+}
+
+func (c *realHcloudClient) ResetServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	return c.client.Server.Reset(ctx, server)
+}
+
 func (c *realHcloudClient) DeleteServer(ctx context.Context, server *hcloud.Server) (*hcloud.Response, error) {
 	return c.client.Server.Delete(ctx, server)
 }
@@ -115,6 +142,10 @@ func (c *realHcloudClient) ListVolumes(ctx context.Context, opts hcloud.VolumeLi
 	return c.client.Volume.AllWithOpts(ctx, opts)
 }
 
+func (c *realHcloudClient) UpdateVolume(ctx context.Context, volume *hcloud.Volume, opts hcloud.VolumeUpdateOpts) (*hcloud.Volume, *hcloud.Response, error) {
+	return c.client.Volume.Update(ctx, volume, opts)
+}
+
 func (c *realHcloudClient) DeleteVolume(ctx context.Context, server *hcloud.Volume) (*hcloud.Response, error) {
 	return c.client.Volume.Delete(ctx, server)
 }
@@ -127,6 +158,10 @@ func (c *realHcloudClient) ListNetworks(ctx context.Context, opts hcloud.Network
 	return c.client.Network.AllWithOpts(ctx, opts)
 }
 
+func (c *realHcloudClient) UpdateNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkUpdateOpts) (*hcloud.Network, *hcloud.Response, error) {
+	return c.client.Network.Update(ctx, network, opts)
+}
+
 func (c *realHcloudClient) DeleteNetwork(ctx context.Context, server *hcloud.Network) (*hcloud.Response, error) {
 	return c.client.Network.Delete(ctx, server)
 }
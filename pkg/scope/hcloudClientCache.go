@@ -0,0 +1,325 @@
+package scope
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+// HcloudTransportMiddleware wraps the HTTP transport of the hcloud API clients
+type HcloudTransportMiddleware func(http.RoundTripper) http.RoundTripper
+
+// HcloudClientCacheOptions configure the clients created by the cache
+type HcloudClientCacheOptions struct {
+	// RateLimit is the number of requests per second sent to a project, 0
+	// disables the rate limiting
+	RateLimit float64
+	// Burst is the number of requests sent to a project at once, before the
+	// rate limit applies
+	Burst int
+	// MaxRetries of a request, which has been rate limited or failed with a
+	// server error. These retries are owned by the transport, a request still
+	// rate limited afterwards fails, so it is not retried by hcloud-go
+	// without limit.
+	MaxRetries int
+	// MaxBackoff is the longest time waited before retrying a request
+	MaxBackoff time.Duration
+	// PollInterval of the lists of servers, load balancers, networks and
+	// volumes. The lists of all but the servers are answered from memory.
+	// 0 disables the caching. The cache has to be run by a manager, which
+	// consumes its events.
+	PollInterval time.Duration
+	// Middlewares are added to the transport of the clients, after the
+	// rate limiting, retrying and logging
+	Middlewares []HcloudTransportMiddleware
+	Logger      logr.Logger
+}
+
+// Validate returns an error, if the options do not allow any request
+func (o HcloudClientCacheOptions) Validate() error {
+	if o.RateLimit < 0 {
+		return errors.Errorf("rate limit %v is negative", o.RateLimit)
+	}
+	if o.RateLimit > 0 && o.Burst < 1 {
+		return errors.Errorf("burst %d of rate limit %v does not allow any request", o.Burst, o.RateLimit)
+	}
+	if o.MaxRetries < 0 {
+		return errors.Errorf("max retries %d is negative", o.MaxRetries)
+	}
+	return nil
+}
+
+// DefaultHcloudClientCacheOptions stay below the hourly quota of 3600
+// requests of a project, while allowing short bursts. They do not poll, as
+// nothing consumes the events of the default cache.
+var DefaultHcloudClientCacheOptions = HcloudClientCacheOptions{
+	RateLimit:  1,
+	Burst:      50,
+	MaxRetries: 5,
+	MaxBackoff: time.Minute,
+}
+
+// HcloudClientCache shares the hcloud API clients of a token between the
+// reconciles, so the rate limit of a project applies to all its clusters.
+// With a poll interval, the resources of a project are cached and their
+// external changes are sent to the event channels of their owners. The cache
+// is run by the manager to stop the polling.
+type HcloudClientCache struct {
+	options HcloudClientCacheOptions
+
+	lock    sync.Mutex
+	clients map[string]HcloudClient
+
+	done          chan struct{}
+	clusterEvents chan event.GenericEvent
+	machineEvents chan event.GenericEvent
+	volumeEvents  chan event.GenericEvent
+}
+
+// DefaultHcloudClientCache is used by the scopes, if no HcloudClientFactory
+// is set
+var DefaultHcloudClientCache = NewHcloudClientCache(DefaultHcloudClientCacheOptions)
+
+// NewHcloudClientCache creates an empty client cache
+func NewHcloudClientCache(options HcloudClientCacheOptions) *HcloudClientCache {
+	if options.Logger == nil {
+		options.Logger = klogr.New()
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultHcloudClientCacheOptions.MaxBackoff
+	}
+	return &HcloudClientCache{
+		options:       options,
+		clients:       make(map[string]HcloudClient),
+		done:          make(chan struct{}),
+		clusterEvents: make(chan event.GenericEvent),
+		machineEvents: make(chan event.GenericEvent),
+		volumeEvents:  make(chan event.GenericEvent),
+	}
+}
+
+// Start blocks until the manager stops, then the polling is stopped
+func (c *HcloudClientCache) Start(stop <-chan struct{}) error {
+	<-stop
+	close(c.done)
+	return nil
+}
+
+// ClusterEvents are sent for HcloudClusters, whose load balancer or network
+// has been changed externally
+func (c *HcloudClientCache) ClusterEvents() <-chan event.GenericEvent {
+	return c.clusterEvents
+}
+
+// MachineEvents are sent for HcloudMachines, whose server has been changed
+// externally
+func (c *HcloudClientCache) MachineEvents() <-chan event.GenericEvent {
+	return c.machineEvents
+}
+
+// VolumeEvents are sent for HcloudVolumes, whose volume has been changed
+// externally
+func (c *HcloudClientCache) VolumeEvents() <-chan event.GenericEvent {
+	return c.volumeEvents
+}
+
+// enqueue sends the event of an owner to the channel of its kind
+func (c *HcloudClientCache) enqueue(evt event.GenericEvent) {
+	var events chan event.GenericEvent
+	switch evt.Object.(type) {
+	case *infrav1.HcloudCluster:
+		events = c.clusterEvents
+	case *infrav1.HcloudMachine:
+		events = c.machineEvents
+	case *infrav1.HcloudVolume:
+		events = c.volumeEvents
+	default:
+		return
+	}
+	select {
+	case events <- evt:
+	case <-c.done:
+	}
+}
+
+// Get returns the client of the token, it is created on first use
+func (c *HcloudClientCache) Get(token string) HcloudClient {
+	key := fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if client, ok := c.clients[key]; ok {
+		return client
+	}
+
+	log := c.options.Logger.WithValues("project", key[:8])
+	transport := http.DefaultTransport
+	for pos := len(c.options.Middlewares) - 1; pos >= 0; pos-- {
+		transport = c.options.Middlewares[pos](transport)
+	}
+	transport = loggingTransport(log)(transport)
+	limit := rate.Inf
+	if c.options.RateLimit > 0 {
+		limit = rate.Limit(c.options.RateLimit)
+	}
+	transport = rateLimitTransport(rate.NewLimiter(limit, c.options.Burst))(transport)
+	transport = retryTransport(log, c.options.MaxRetries, c.options.MaxBackoff)(transport)
+
+	// hcloud-go retries conflicts with locked resources itself, its backoff
+	// is capped like the one of the transport
+	maxBackoff := c.options.MaxBackoff
+	var client HcloudClient = &realHcloudClient{
+		client: hcloud.NewClient(
+			hcloud.WithToken(token),
+			hcloud.WithHTTPClient(&http.Client{Transport: transport}),
+			hcloud.WithBackoffFunc(func(retries int) time.Duration {
+				return exponentialBackoff(retries, maxBackoff)
+			}),
+		),
+		token: token,
+	}
+	if c.options.PollInterval > 0 {
+		cached := newCachedHcloudClient(client, log, c)
+		go cached.poll(c.options.PollInterval)
+		client = cached
+	}
+	c.clients[key] = client
+	return client
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// loggingTransport logs every request sent to the API
+func loggingTransport(log logr.Logger) HcloudTransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				log.V(1).Info("hcloud API request failed", "method", req.Method, "path", req.URL.Path, "duration", time.Since(start), "error", err.Error())
+				return resp, err
+			}
+			log.V(3).Info("hcloud API request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", time.Since(start), "rateLimitRemaining", resp.Header.Get("RateLimit-Remaining"))
+			return resp, err
+		})
+	}
+}
+
+// rateLimitTransport delays requests exceeding the rate limit
+func rateLimitTransport(limiter *rate.Limiter) HcloudTransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// retryTransport retries requests rejected by the rate limit of the API and
+// idempotent requests failed with a server error. Rate limited requests are
+// retried once the rate limit has been reset, others with an exponential
+// backoff. A request still rate limited after the last retry fails, as
+// hcloud-go would otherwise retry it without limit.
+func retryTransport(log logr.Logger, maxRetries int, maxBackoff time.Duration) HcloudTransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for attempt := 0; ; attempt++ {
+				attemptReq := req
+				if attempt > 0 && req.Body != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq = req.Clone(req.Context())
+					attemptReq.Body = body
+				}
+
+				resp, err := next.RoundTrip(attemptReq)
+				if err != nil || !retryable(req, resp) {
+					return resp, err
+				}
+				if attempt >= maxRetries {
+					if resp.StatusCode != http.StatusTooManyRequests {
+						return resp, nil
+					}
+					_, _ = io.Copy(ioutil.Discard, resp.Body)
+					resp.Body.Close()
+					return nil, errors.Errorf("hcloud API rate limit exceeded after %d retries", attempt)
+				}
+
+				wait := retryBackoff(resp, attempt, maxBackoff)
+				log.V(1).Info("retrying hcloud API request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "attempt", attempt+1, "wait", wait)
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+}
+
+func retryable(req *http.Request, resp *http.Response) bool {
+	// requests without a replayable body cannot be sent again
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	// a failed POST might have been executed, e.g. created a server
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return resp.StatusCode >= 500
+	}
+	return false
+}
+
+// retryBackoff returns the time to wait before the next attempt, until the
+// reset of a rate limit or an exponential backoff starting with one second
+func retryBackoff(resp *http.Response, attempt int, maxBackoff time.Duration) time.Duration {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64); err == nil {
+			if untilReset := time.Until(time.Unix(reset, 0)); untilReset > 0 {
+				if untilReset > maxBackoff {
+					return maxBackoff
+				}
+				return untilReset
+			}
+		}
+	}
+	return exponentialBackoff(attempt, maxBackoff)
+}
+
+// exponentialBackoff starts with one second and doubles with every attempt
+// up to the maximum
+func exponentialBackoff(attempt int, maxBackoff time.Duration) time.Duration {
+	wait := time.Second << uint(attempt)
+	if wait > maxBackoff || wait <= 0 {
+		wait = maxBackoff
+	}
+	return wait
+}
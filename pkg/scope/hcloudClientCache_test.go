@@ -0,0 +1,203 @@
+package scope
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/klogr"
+)
+
+func TestHcloudClientCache_Get(t *testing.T) {
+	cache := NewHcloudClientCache(DefaultHcloudClientCacheOptions)
+
+	if cache.Get("token-a") != cache.Get("token-a") {
+		t.Errorf("Get() returned different clients for the same token")
+	}
+	if cache.Get("token-a") == cache.Get("token-b") {
+		t.Errorf("Get() returned the same client for different tokens")
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		wantStatus   int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{
+			name:         "rate limited request",
+			method:       http.MethodPost,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusCreated},
+			wantStatus:   http.StatusCreated,
+			wantRequests: 3,
+		},
+		{
+			name:         "idempotent request with server error",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "non-idempotent request with server error",
+			method:       http.MethodPost,
+			statuses:     []int{http.StatusInternalServerError, http.StatusCreated},
+			wantStatus:   http.StatusInternalServerError,
+			wantRequests: 1,
+		},
+		{
+			name:         "client error",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusNotFound, http.StatusOK},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+		{
+			// hcloud-go would retry the rate limited response without limit
+			name:         "retries exhausted",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{
+			name:         "retries of server errors exhausted",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				if r.Method == http.MethodPost {
+					buf := make([]byte, 4)
+					if l, _ := r.Body.Read(buf); string(buf[:l]) != "body" {
+						t.Errorf("request %d has body %q, want %q", n, buf[:l], "body")
+					}
+				}
+				w.Header().Set("RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			transport := retryTransport(klogr.New(), 2, time.Millisecond)(http.DefaultTransport)
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoundTrip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("RoundTrip() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("RoundTrip() sent %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	rateLimited := func(reset time.Time) *http.Response {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		resp.Header.Set("RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		return resp
+	}
+
+	if wait := retryBackoff(&http.Response{StatusCode: http.StatusBadGateway}, 2, time.Minute); wait != 4*time.Second {
+		t.Errorf("retryBackoff() of server error = %s, want 4s", wait)
+	}
+	if wait := retryBackoff(rateLimited(time.Now().Add(20*time.Second)), 0, time.Minute); wait < 18*time.Second || wait > 20*time.Second {
+		t.Errorf("retryBackoff() of rate limited request = %s, want about 20s", wait)
+	}
+	if wait := retryBackoff(rateLimited(time.Now().Add(time.Hour)), 0, time.Minute); wait != time.Minute {
+		t.Errorf("retryBackoff() of rate limited request = %s, want the maximum of 1m", wait)
+	}
+	if wait := exponentialBackoff(100, time.Minute); wait != time.Minute {
+		t.Errorf("exponentialBackoff() = %s, want the maximum of 1m", wait)
+	}
+}
+
+func TestHcloudClientCacheOptions_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options HcloudClientCacheOptions
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			options: DefaultHcloudClientCacheOptions,
+		},
+		{
+			name:    "no rate limit without burst",
+			options: HcloudClientCacheOptions{},
+		},
+		{
+			name:    "rate limit without burst",
+			options: HcloudClientCacheOptions{RateLimit: 1},
+			wantErr: true,
+		},
+		{
+			name:    "negative rate limit",
+			options: HcloudClientCacheOptions{RateLimit: -1, Burst: 1},
+			wantErr: true,
+		},
+		{
+			name:    "negative retries",
+			options: HcloudClientCacheOptions{MaxRetries: -1},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := rateLimitTransport(rate.NewLimiter(rate.Every(time.Hour), 1))(http.DefaultTransport)
+	roundTrip := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := roundTrip(); err != nil {
+		t.Errorf("RoundTrip() within the burst error = %v", err)
+	}
+	if err := roundTrip(); err == nil {
+		t.Errorf("RoundTrip() exceeding the rate limit succeeded")
+	}
+}
@@ -0,0 +1,524 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+// resourceKind is a kind of resource held by the cache
+type resourceKind string
+
+const (
+	kindServer       resourceKind = "server"
+	kindLoadBalancer resourceKind = "loadBalancer"
+	kindNetwork      resourceKind = "network"
+	kindVolume       resourceKind = "volume"
+)
+
+var resourceKinds = []resourceKind{kindServer, kindLoadBalancer, kindNetwork, kindVolume}
+
+// resourceEntry is the state of a resource at the last poll
+type resourceEntry struct {
+	fingerprint string
+	labels      map[string]string
+}
+
+// resourceSnapshot is the state of a kind of resources
+type resourceSnapshot struct {
+	// valid is false, if the resources have to be listed again, as they have
+	// been changed by the controller
+	valid bool
+	// generation is increased by every invalidation, so a list started
+	// before is not stored as valid
+	generation int
+	// entries are the resources of the last poll by ID
+	entries map[int]resourceEntry
+	// mutated are the IDs of the resources changed by the controller since
+	// the last poll, their changes are not external
+	mutated map[int]bool
+}
+
+// update stores the resources of a poll and returns the owners of the
+// resources, which have been created, changed or deleted externally
+func (s *resourceSnapshot) update(kind resourceKind, entries map[int]resourceEntry) []event.GenericEvent {
+	var events []event.GenericEvent
+	// the first poll has nothing to compare with
+	if s.entries != nil {
+		for id, entry := range entries {
+			previous, ok := s.entries[id]
+			if (!ok || previous.fingerprint != entry.fingerprint) && !s.mutated[id] {
+				events = append(events, ownerEvents(kind, entry.labels)...)
+			}
+		}
+		for id, previous := range s.entries {
+			if _, ok := entries[id]; !ok && !s.mutated[id] {
+				events = append(events, ownerEvents(kind, previous.labels)...)
+			}
+		}
+	}
+	s.entries = entries
+	s.mutated = make(map[int]bool)
+	return events
+}
+
+// cachedHcloudClient answers the lists of load balancers, networks and
+// volumes of a project from memory. The lists are refreshed by polling and
+// after they have been invalidated by mutations of the controller. Servers
+// are deleted, stopped and started outside of the controller, so they are
+// always requested from the API and only polled. External changes found by
+// polling trigger reconciles of the objects owning the changed resources.
+type cachedHcloudClient struct {
+	HcloudClient
+	log   logr.Logger
+	cache *HcloudClientCache
+
+	lock          sync.Mutex
+	snapshots     map[resourceKind]*resourceSnapshot
+	loadBalancers []*hcloud.LoadBalancer
+	networks      []*hcloud.Network
+	volumes       []*hcloud.Volume
+}
+
+var _ HcloudClient = &cachedHcloudClient{}
+
+func newCachedHcloudClient(client HcloudClient, log logr.Logger, cache *HcloudClientCache) *cachedHcloudClient {
+	c := &cachedHcloudClient{
+		HcloudClient: client,
+		log:          log,
+		cache:        cache,
+		snapshots:    make(map[resourceKind]*resourceSnapshot),
+	}
+	for _, kind := range resourceKinds {
+		c.snapshots[kind] = &resourceSnapshot{mutated: make(map[int]bool)}
+	}
+	return c
+}
+
+// poll refreshes the lists periodically until the cache is stopped
+func (c *cachedHcloudClient) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.cache.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := c.refresh(ctx); err != nil {
+			c.log.Error(err, "failed to poll hcloud resources")
+		}
+		cancel()
+	}
+}
+
+// refresh lists all resources and enqueues the owners of the resources
+// changed externally since the last refresh
+func (c *cachedHcloudClient) refresh(ctx context.Context) error {
+	entries := make(map[resourceKind]map[int]resourceEntry)
+	for _, kind := range resourceKinds {
+		entries[kind] = make(map[int]resourceEntry)
+	}
+
+	servers, err := c.HcloudClient.ListServers(ctx, hcloud.ServerListOpts{})
+	if err != nil {
+		return err
+	}
+	for _, s := range servers {
+		entries[kindServer][s.ID] = resourceEntry{fingerprint: serverFingerprint(s), labels: s.Labels}
+	}
+	loadBalancers, err := c.allLoadBalancers(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, lb := range loadBalancers {
+		entries[kindLoadBalancer][lb.ID] = resourceEntry{fingerprint: loadBalancerFingerprint(lb), labels: lb.Labels}
+	}
+	networks, err := c.allNetworks(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		entries[kindNetwork][n.ID] = resourceEntry{fingerprint: networkFingerprint(n), labels: n.Labels}
+	}
+	volumes, err := c.allVolumes(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		entries[kindVolume][v.ID] = resourceEntry{fingerprint: volumeFingerprint(v), labels: v.Labels}
+	}
+
+	var events []event.GenericEvent
+	c.lock.Lock()
+	for _, kind := range resourceKinds {
+		events = append(events, c.snapshots[kind].update(kind, entries[kind])...)
+	}
+	c.lock.Unlock()
+
+	for _, evt := range events {
+		c.log.V(2).Info("hcloud resource changed externally", "namespace", evt.Meta.GetNamespace(), "owner", evt.Meta.GetName())
+		c.cache.enqueue(evt)
+	}
+	return nil
+}
+
+// invalidate marks the list of a kind as stale after a mutation of a
+// resource, an ID of 0 invalidates the list only
+func (c *cachedHcloudClient) invalidate(kind resourceKind, id int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	snapshot := c.snapshots[kind]
+	snapshot.valid = false
+	snapshot.generation++
+	if id != 0 {
+		snapshot.mutated[id] = true
+	}
+}
+
+// cached returns the generation of a list and whether it is valid
+func (c *cachedHcloudClient) cached(kind resourceKind) (int, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.snapshots[kind].generation, c.snapshots[kind].valid
+}
+
+// store records a list, unless it has been invalidated while listing
+func (c *cachedHcloudClient) store(kind resourceKind, generation int, set func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.snapshots[kind].generation == generation {
+		set()
+		c.snapshots[kind].valid = true
+	}
+}
+
+func (c *cachedHcloudClient) allLoadBalancers(ctx context.Context, refresh bool) ([]*hcloud.LoadBalancer, error) {
+	generation, valid := c.cached(kindLoadBalancer)
+	if valid && !refresh {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return c.loadBalancers, nil
+	}
+	loadBalancers, err := c.HcloudClient.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	c.store(kindLoadBalancer, generation, func() { c.loadBalancers = loadBalancers })
+	return loadBalancers, nil
+}
+
+func (c *cachedHcloudClient) allNetworks(ctx context.Context, refresh bool) ([]*hcloud.Network, error) {
+	generation, valid := c.cached(kindNetwork)
+	if valid && !refresh {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return c.networks, nil
+	}
+	networks, err := c.HcloudClient.ListNetworks(ctx, hcloud.NetworkListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	c.store(kindNetwork, generation, func() { c.networks = networks })
+	return networks, nil
+}
+
+func (c *cachedHcloudClient) allVolumes(ctx context.Context, refresh bool) ([]*hcloud.Volume, error) {
+	generation, valid := c.cached(kindVolume)
+	if valid && !refresh {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return c.volumes, nil
+	}
+	volumes, err := c.HcloudClient.ListVolumes(ctx, hcloud.VolumeListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	c.store(kindVolume, generation, func() { c.volumes = volumes })
+	return volumes, nil
+}
+
+// cacheableSelector parses the label selector of a list, which is only
+// filtered by labels
+func cacheableSelector(opts hcloud.ListOpts, filtered bool) (labels.Selector, bool) {
+	if filtered || opts.Page != 0 {
+		return nil, false
+	}
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, false
+	}
+	return selector, true
+}
+
+func (c *cachedHcloudClient) ListLoadBalancers(ctx context.Context, opts hcloud.LoadBalancerListOpts) ([]*hcloud.LoadBalancer, error) {
+	selector, ok := cacheableSelector(opts.ListOpts, opts.Name != "")
+	if !ok {
+		return c.HcloudClient.ListLoadBalancers(ctx, opts)
+	}
+	loadBalancers, err := c.allLoadBalancers(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var result []*hcloud.LoadBalancer
+	for _, lb := range loadBalancers {
+		if selector.Matches(labels.Set(lb.Labels)) {
+			result = append(result, lb)
+		}
+	}
+	return result, nil
+}
+
+func (c *cachedHcloudClient) ListNetworks(ctx context.Context, opts hcloud.NetworkListOpts) ([]*hcloud.Network, error) {
+	selector, ok := cacheableSelector(opts.ListOpts, opts.Name != "")
+	if !ok {
+		return c.HcloudClient.ListNetworks(ctx, opts)
+	}
+	networks, err := c.allNetworks(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var result []*hcloud.Network
+	for _, n := range networks {
+		if selector.Matches(labels.Set(n.Labels)) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (c *cachedHcloudClient) ListVolumes(ctx context.Context, opts hcloud.VolumeListOpts) ([]*hcloud.Volume, error) {
+	selector, ok := cacheableSelector(opts.ListOpts, opts.Name != "" || len(opts.Status) > 0)
+	if !ok {
+		return c.HcloudClient.ListVolumes(ctx, opts)
+	}
+	volumes, err := c.allVolumes(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var result []*hcloud.Volume
+	for _, v := range volumes {
+		if selector.Matches(labels.Set(v.Labels)) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// The mutations invalidate the lists of the changed resources, even if they
+// failed, as they might have been executed partially.
+
+func (c *cachedHcloudClient) CreateLoadBalancer(ctx context.Context, opts hcloud.LoadBalancerCreateOpts) (hcloud.LoadBalancerCreateResult, *hcloud.Response, error) {
+	result, resp, err := c.HcloudClient.CreateLoadBalancer(ctx, opts)
+	if result.LoadBalancer != nil {
+		c.invalidate(kindLoadBalancer, result.LoadBalancer.ID)
+	} else {
+		c.invalidate(kindLoadBalancer, 0)
+	}
+	c.invalidate(kindNetwork, 0)
+	return result, resp, err
+}
+
+func (c *cachedHcloudClient) DeleteLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer) (*hcloud.Response, error) {
+	defer c.invalidate(kindNetwork, 0)
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.DeleteLoadBalancer(ctx, lb)
+}
+
+func (c *cachedHcloudClient) UpdateLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, *hcloud.Response, error) {
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.UpdateLoadBalancer(ctx, lb, opts)
+}
+
+func (c *cachedHcloudClient) AttachLoadBalancerToNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindNetwork, 0)
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.AttachLoadBalancerToNetwork(ctx, lb, opts)
+}
+
+func (c *cachedHcloudClient) AddTargetServerToLoadBalancer(ctx context.Context, opts hcloud.LoadBalancerAddServerTargetOpts, lb *hcloud.LoadBalancer) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.AddTargetServerToLoadBalancer(ctx, opts, lb)
+}
+
+func (c *cachedHcloudClient) DeleteTargetServerOfLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.DeleteTargetServerOfLoadBalancer(ctx, lb, server)
+}
+
+func (c *cachedHcloudClient) AddServiceToLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAddServiceOpts) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindLoadBalancer, lb.ID)
+	return c.HcloudClient.AddServiceToLoadBalancer(ctx, lb, opts)
+}
+
+func (c *cachedHcloudClient) CreateServer(ctx context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, *hcloud.Response, error) {
+	// the server is not marked as mutated, so the transitions of its state
+	// until it is running trigger reconciles
+	result, resp, err := c.HcloudClient.CreateServer(ctx, opts)
+	c.invalidate(kindServer, 0)
+	c.invalidate(kindNetwork, 0)
+	c.invalidate(kindVolume, 0)
+	return result, resp, err
+}
+
+func (c *cachedHcloudClient) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error) {
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.UpdateServer(ctx, server, opts)
+}
+
+func (c *cachedHcloudClient) AttachServerToNetwork(ctx context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindNetwork, 0)
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.AttachServerToNetwork(ctx, server, opts)
+}
+
+func (c *cachedHcloudClient) DeleteServer(ctx context.Context, server *hcloud.Server) (*hcloud.Response, error) {
+	defer c.invalidate(kindVolume, 0)
+	defer c.invalidate(kindNetwork, 0)
+	defer c.invalidate(kindLoadBalancer, 0)
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.DeleteServer(ctx, server)
+}
+
+func (c *cachedHcloudClient) ShutdownServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.ShutdownServer(ctx, server)
+}
+
+func (c *cachedHcloudClient) RebootServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.RebootServer(ctx, server)
+}
+
+func (c *cachedHcloudClient) ResetServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	defer c.invalidate(kindServer, server.ID)
+	return c.HcloudClient.ResetServer(ctx, server)
+}
+
+func (c *cachedHcloudClient) CreateVolume(ctx context.Context, opts hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, *hcloud.Response, error) {
+	result, resp, err := c.HcloudClient.CreateVolume(ctx, opts)
+	if result.Volume != nil {
+		c.invalidate(kindVolume, result.Volume.ID)
+	} else {
+		c.invalidate(kindVolume, 0)
+	}
+	return result, resp, err
+}
+
+func (c *cachedHcloudClient) UpdateVolume(ctx context.Context, volume *hcloud.Volume, opts hcloud.VolumeUpdateOpts) (*hcloud.Volume, *hcloud.Response, error) {
+	defer c.invalidate(kindVolume, volume.ID)
+	return c.HcloudClient.UpdateVolume(ctx, volume, opts)
+}
+
+func (c *cachedHcloudClient) DeleteVolume(ctx context.Context, volume *hcloud.Volume) (*hcloud.Response, error) {
+	defer c.invalidate(kindVolume, volume.ID)
+	return c.HcloudClient.DeleteVolume(ctx, volume)
+}
+
+func (c *cachedHcloudClient) CreateNetwork(ctx context.Context, opts hcloud.NetworkCreateOpts) (*hcloud.Network, *hcloud.Response, error) {
+	network, resp, err := c.HcloudClient.CreateNetwork(ctx, opts)
+	if network != nil {
+		c.invalidate(kindNetwork, network.ID)
+	} else {
+		c.invalidate(kindNetwork, 0)
+	}
+	return network, resp, err
+}
+
+func (c *cachedHcloudClient) UpdateNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkUpdateOpts) (*hcloud.Network, *hcloud.Response, error) {
+	defer c.invalidate(kindNetwork, network.ID)
+	return c.HcloudClient.UpdateNetwork(ctx, network, opts)
+}
+
+func (c *cachedHcloudClient) DeleteNetwork(ctx context.Context, network *hcloud.Network) (*hcloud.Response, error) {
+	defer c.invalidate(kindNetwork, network.ID)
+	return c.HcloudClient.DeleteNetwork(ctx, network)
+}
+
+// ownerEvents returns the events for the object owning a resource according
+// to its labels. Resources without the namespace of their cluster in the
+// labels have no known owner.
+func ownerEvents(kind resourceKind, resourceLabels map[string]string) []event.GenericEvent {
+	namespace, ok := resourceLabels[infrav1.ClusterNamespaceTagKey]
+	if !ok {
+		return nil
+	}
+
+	var owner interface {
+		metav1.Object
+		runtime.Object
+	}
+	meta := metav1.ObjectMeta{Namespace: namespace}
+	switch kind {
+	case kindServer:
+		meta.Name = resourceLabels[infrav1.MachineNameTagKey]
+		owner = &infrav1.HcloudMachine{ObjectMeta: meta}
+	case kindVolume:
+		meta.Name = resourceLabels[infrav1.VolumeNameTagKey]
+		owner = &infrav1.HcloudVolume{ObjectMeta: meta}
+	default:
+		for key, value := range resourceLabels {
+			if strings.HasPrefix(key, infrav1.NameHcloudProviderOwned) && infrav1.ResourceLifecycle(value) == infrav1.ResourceLifecycleOwned {
+				meta.Name = strings.TrimPrefix(key, infrav1.NameHcloudProviderOwned)
+			}
+		}
+		owner = &infrav1.HcloudCluster{ObjectMeta: meta}
+	}
+	if meta.Name == "" {
+		return nil
+	}
+	return []event.GenericEvent{{Meta: owner, Object: owner}}
+}
+
+func serverFingerprint(s *hcloud.Server) string {
+	var privateIPs []string
+	for _, n := range s.PrivateNet {
+		privateIPs = append(privateIPs, n.IP.String())
+	}
+	return fmt.Sprintf("%s|%s|%v|%s|%v", s.Name, s.Status, s.Labels, s.PublicNet.IPv4.IP, privateIPs)
+}
+
+func loadBalancerFingerprint(lb *hcloud.LoadBalancer) string {
+	var targets []int
+	for _, t := range lb.Targets {
+		if t.Server != nil && t.Server.Server != nil {
+			targets = append(targets, t.Server.Server.ID)
+		}
+	}
+	sort.Ints(targets)
+	var privateIPs []string
+	for _, n := range lb.PrivateNet {
+		privateIPs = append(privateIPs, n.IP.String())
+	}
+	return fmt.Sprintf("%s|%v|%v|%d|%v", lb.Name, lb.Labels, targets, len(lb.Services), privateIPs)
+}
+
+func networkFingerprint(n *hcloud.Network) string {
+	var subnets []string
+	for _, s := range n.Subnets {
+		subnets = append(subnets, s.IPRange.String())
+	}
+	return fmt.Sprintf("%s|%v|%v|%d", n.Name, n.Labels, subnets, len(n.Servers))
+}
+
+func volumeFingerprint(v *hcloud.Volume) string {
+	var serverID int
+	if v.Server != nil {
+		serverID = v.Server.ID
+	}
+	return fmt.Sprintf("%s|%s|%v|%d|%d", v.Name, v.Status, v.Labels, v.Size, serverID)
+}
@@ -0,0 +1,307 @@
+package scope
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+)
+
+// fakeHcloudClient serves the servers and volumes of a project and counts
+// the requests
+type fakeHcloudClient struct {
+	HcloudClient
+	servers     []*hcloud.Server
+	volumes     []*hcloud.Volume
+	lists       int
+	gets        int
+	volumeLists int
+}
+
+func (f *fakeHcloudClient) GetServerByID(ctx context.Context, id int) (*hcloud.Server, *hcloud.Response, error) {
+	f.gets++
+	for _, s := range f.servers {
+		if s.ID == id {
+			return s, nil, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (f *fakeHcloudClient) CreateServer(ctx context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, *hcloud.Response, error) {
+	server := &hcloud.Server{ID: len(f.servers) + 1, Name: opts.Name, Status: hcloud.ServerStatusInitializing, Labels: opts.Labels}
+	f.servers = append(f.servers, server)
+	return hcloud.ServerCreateResult{Server: server}, nil, nil
+}
+
+func (f *fakeHcloudClient) ListServers(ctx context.Context, opts hcloud.ServerListOpts) ([]*hcloud.Server, error) {
+	f.lists++
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	var servers []*hcloud.Server
+	for _, s := range f.servers {
+		if selector.Matches(labels.Set(s.Labels)) {
+			servers = append(servers, s)
+		}
+	}
+	return servers, nil
+}
+
+func (f *fakeHcloudClient) ListLoadBalancers(ctx context.Context, opts hcloud.LoadBalancerListOpts) ([]*hcloud.LoadBalancer, error) {
+	return nil, nil
+}
+
+func (f *fakeHcloudClient) ListNetworks(ctx context.Context, opts hcloud.NetworkListOpts) ([]*hcloud.Network, error) {
+	return nil, nil
+}
+
+func (f *fakeHcloudClient) ListVolumes(ctx context.Context, opts hcloud.VolumeListOpts) ([]*hcloud.Volume, error) {
+	f.volumeLists++
+	return f.volumes, nil
+}
+
+func (f *fakeHcloudClient) UpdateVolume(ctx context.Context, volume *hcloud.Volume, opts hcloud.VolumeUpdateOpts) (*hcloud.Volume, *hcloud.Response, error) {
+	volume.Labels = opts.Labels
+	return volume, nil, nil
+}
+
+func (f *fakeHcloudClient) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error) {
+	server.Labels = opts.Labels
+	return server, nil, nil
+}
+
+func newFakeServer(id int, cluster, machine string) *hcloud.Server {
+	return &hcloud.Server{
+		ID:     id,
+		Status: hcloud.ServerStatusRunning,
+		Labels: map[string]string{
+			infrav1.ClusterTagKey(cluster): string(infrav1.ResourceLifecycleOwned),
+			infrav1.ClusterNamespaceTagKey: "default",
+			infrav1.MachineNameTagKey:      machine,
+		},
+	}
+}
+
+func newFakeVolume(id int, cluster, volume string) *hcloud.Volume {
+	return &hcloud.Volume{
+		ID:     id,
+		Status: hcloud.VolumeStatusAvailable,
+		Labels: map[string]string{
+			infrav1.ClusterTagKey(cluster): string(infrav1.ResourceLifecycleOwned),
+			infrav1.ClusterNamespaceTagKey: "default",
+			infrav1.VolumeNameTagKey:       volume,
+		},
+	}
+}
+
+func TestCachedHcloudClient_ListVolumes(t *testing.T) {
+	fake := &fakeHcloudClient{volumes: []*hcloud.Volume{
+		newFakeVolume(1, "a", "a-1"),
+		newFakeVolume(2, "b", "b-1"),
+	}}
+	c := newCachedHcloudClient(fake, klogr.New(), NewHcloudClientCache(HcloudClientCacheOptions{}))
+	ctx := context.TODO()
+
+	opts := hcloud.VolumeListOpts{}
+	opts.LabelSelector = infrav1.ClusterTagKey("a") + "==owned"
+	for i := 0; i < 2; i++ {
+		volumes, err := c.ListVolumes(ctx, opts)
+		if err != nil {
+			t.Fatalf("ListVolumes() error = %v", err)
+		}
+		if len(volumes) != 1 || volumes[0].ID != 1 {
+			t.Errorf("ListVolumes() = %v, want volume 1", volumes)
+		}
+	}
+	if fake.volumeLists != 1 {
+		t.Errorf("ListVolumes() listed the volumes %d times, want 1", fake.volumeLists)
+	}
+
+	// a mutation invalidates the list
+	if _, _, err := c.UpdateVolume(ctx, fake.volumes[1], hcloud.VolumeUpdateOpts{Labels: map[string]string{}}); err != nil {
+		t.Fatalf("UpdateVolume() error = %v", err)
+	}
+	if _, err := c.ListVolumes(ctx, opts); err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if fake.volumeLists != 2 {
+		t.Errorf("ListVolumes() after a mutation listed the volumes %d times, want 2", fake.volumeLists)
+	}
+
+	// lists filtered by more than labels are not cached
+	opts.Name = "a-1"
+	if _, err := c.ListVolumes(ctx, opts); err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if fake.volumeLists != 3 {
+		t.Errorf("ListVolumes() by name listed the volumes %d times, want 3", fake.volumeLists)
+	}
+}
+
+func TestCachedHcloudClient_Refresh(t *testing.T) {
+	fake := &fakeHcloudClient{servers: []*hcloud.Server{
+		newFakeServer(1, "a", "a-1"),
+		newFakeServer(2, "a", "a-2"),
+	}}
+	cache := NewHcloudClientCache(HcloudClientCacheOptions{})
+	c := newCachedHcloudClient(fake, klogr.New(), cache)
+	ctx := context.TODO()
+
+	events := make(chan event.GenericEvent, 10)
+	go func() {
+		for evt := range cache.MachineEvents() {
+			events <- evt
+		}
+	}()
+
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	// server 1 is changed by the controller, server 2 externally
+	if _, _, err := c.UpdateServer(ctx, fake.servers[0], hcloud.ServerUpdateOpts{Labels: newFakeServer(1, "a", "a-1").Labels}); err != nil {
+		t.Fatalf("UpdateServer() error = %v", err)
+	}
+	fake.servers[0].Status = hcloud.ServerStatusOff
+	fake.servers[1].Status = hcloud.ServerStatusOff
+
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Meta.GetNamespace() != "default" || evt.Meta.GetName() != "a-2" {
+			t.Errorf("refresh() enqueued %s/%s, want default/a-2", evt.Meta.GetNamespace(), evt.Meta.GetName())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("refresh() enqueued no HcloudMachine")
+	}
+	select {
+	case evt := <-events:
+		t.Errorf("refresh() enqueued unexpected %s/%s", evt.Meta.GetNamespace(), evt.Meta.GetName())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCachedHcloudClient_Servers(t *testing.T) {
+	fake := &fakeHcloudClient{servers: []*hcloud.Server{
+		newFakeServer(1, "a", "a-1"),
+	}}
+	c := newCachedHcloudClient(fake, klogr.New(), NewHcloudClientCache(HcloudClientCacheOptions{}))
+	ctx := context.TODO()
+
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	// the polled servers are not answered from memory
+	opts := hcloud.ServerListOpts{}
+	opts.LabelSelector = infrav1.ClusterTagKey("a") + "==owned"
+	if _, err := c.ListServers(ctx, opts); err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if fake.lists != 2 {
+		t.Errorf("ListServers() listed the servers %d times, want 2", fake.lists)
+	}
+	if _, _, err := c.GetServerByID(ctx, 1); err != nil {
+		t.Fatalf("GetServerByID() error = %v", err)
+	}
+	if fake.gets != 1 {
+		t.Errorf("GetServerByID() got the server %d times, want 1", fake.gets)
+	}
+}
+
+func TestCachedHcloudClient_ExternalDeletion(t *testing.T) {
+	fake := &fakeHcloudClient{servers: []*hcloud.Server{
+		newFakeServer(1, "a", "a-1"),
+		newFakeServer(2, "a", "a-2"),
+	}}
+	cache := NewHcloudClientCache(HcloudClientCacheOptions{})
+	c := newCachedHcloudClient(fake, klogr.New(), cache)
+	ctx := context.TODO()
+
+	events := make(chan event.GenericEvent, 10)
+	go func() {
+		for evt := range cache.MachineEvents() {
+			events <- evt
+		}
+	}()
+
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	// server 2 is deleted outside of the controller before the next poll
+	fake.servers = fake.servers[:1]
+
+	// the machine finds neither its server by ID nor by labels, so it is
+	// marked as failed
+	if server, _, err := c.GetServerByID(ctx, 2); err != nil || server != nil {
+		t.Errorf("GetServerByID() = %v, %v, want no server", server, err)
+	}
+	opts := hcloud.ServerListOpts{}
+	opts.LabelSelector = infrav1.MachineNameTagKey + "==a-2"
+	if servers, err := c.ListServers(ctx, opts); err != nil || len(servers) != 0 {
+		t.Errorf("ListServers() = %v, %v, want no server", servers, err)
+	}
+
+	// the next poll reconciles the machine
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	select {
+	case evt := <-events:
+		if evt.Meta.GetNamespace() != "default" || evt.Meta.GetName() != "a-2" {
+			t.Errorf("refresh() enqueued %s/%s, want default/a-2", evt.Meta.GetNamespace(), evt.Meta.GetName())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("refresh() enqueued no HcloudMachine")
+	}
+}
+
+func TestCachedHcloudClient_RefreshCreatedServer(t *testing.T) {
+	fake := &fakeHcloudClient{servers: []*hcloud.Server{
+		newFakeServer(1, "a", "a-1"),
+	}}
+	cache := NewHcloudClientCache(HcloudClientCacheOptions{})
+	c := newCachedHcloudClient(fake, klogr.New(), cache)
+	ctx := context.TODO()
+
+	events := make(chan event.GenericEvent, 10)
+	go func() {
+		for evt := range cache.MachineEvents() {
+			events <- evt
+		}
+	}()
+
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	// the state of a server created by the controller changes until it is
+	// running, so its machine is reconciled
+	if _, _, err := c.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "a-2", Labels: newFakeServer(2, "a", "a-2").Labels}); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := c.refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Meta.GetNamespace() != "default" || evt.Meta.GetName() != "a-2" {
+			t.Errorf("refresh() enqueued %s/%s, want default/a-2", evt.Meta.GetNamespace(), evt.Meta.GetName())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("refresh() enqueued no HcloudMachine")
+	}
+}
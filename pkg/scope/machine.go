@@ -2,15 +2,21 @@ package scope
 
 import (
 	"context"
+	"fmt"
 	"hash/crc32"
 	"sort"
+	"strconv"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
 	packerapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer/api"
@@ -66,7 +72,52 @@ func (s *MachineScope) Close() error {
 }
 
 func (s *MachineScope) EnsureImage(ctx context.Context, parameters *packerapi.PackerParameters) (*infrav1.HcloudImageID, error) {
-	return s.packer.EnsureImage(ctx, s, s.hcloudClient, parameters)
+	imageID, err := s.packer.EnsureImage(ctx, s, s.hcloudClient, parameters)
+
+	// persist the output of the build, so it survives the next reconcile
+	if buildLog := s.packer.BuildLog(parameters.Hash()); buildLog != nil {
+		if err := s.reconcileImageBuildLog(ctx, buildLog); err != nil {
+			s.Error(err, "failed to persist packer build log", "hash", buildLog.Hash)
+		}
+	}
+
+	return imageID, err
+}
+
+// ImageBuildLogConfigMapName returns the name of the ConfigMap storing the
+// output of the packer build for a template hash
+func ImageBuildLogConfigMapName(hash string) string {
+	return fmt.Sprintf("packer-build-%s", hash)
+}
+
+func (s *MachineScope) reconcileImageBuildLog(ctx context.Context, buildLog *packerapi.BuildLog) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.Namespace(),
+			Name:      ImageBuildLogConfigMapName(buildLog.Hash),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, s.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = make(map[string]string)
+		}
+		cm.Labels[infrav1.NameHcloudProviderPrefix+"template-hash"] = buildLog.Hash
+		// the log is garbage collected once all clusters using the image are deleted
+		cm.OwnerReferences = util.EnsureOwnerRef(cm.OwnerReferences, metav1.OwnerReference{
+			APIVersion: infrav1.GroupVersion.String(),
+			Kind:       "HcloudCluster",
+			Name:       s.HcloudCluster.Name,
+			UID:        s.HcloudCluster.UID,
+		})
+		// the output is already bounded by packerapi.MaxBuildLogSize
+		cm.Data = map[string]string{
+			"phase":     string(buildLog.Phase),
+			"attempts":  strconv.Itoa(buildLog.Attempts),
+			"build.log": string(buildLog.Output),
+		}
+		return nil
+	})
+	return err
 }
 
 // IsControlPlane returns true if the machine is a control plane.
@@ -89,6 +140,14 @@ func (m *MachineScope) PatchObject(ctx context.Context) error {
 	return m.patchHelper.Patch(ctx, m.HcloudMachine)
 }
 
+func (m *MachineScope) SetFailureReason(reason capierrors.MachineStatusError) {
+	m.HcloudMachine.Status.FailureReason = &reason
+}
+
+func (m *MachineScope) SetFailureMessage(err error) {
+	m.HcloudMachine.Status.FailureMessage = pointer.StringPtr(err.Error())
+}
+
 func (m *MachineScope) IsBootstrapDataReady(ctx context.Context) bool {
 	return m.Machine.Spec.Bootstrap.DataSecretName != nil
 }
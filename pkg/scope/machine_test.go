@@ -1,11 +1,19 @@
 package scope
 
 import (
+	"bytes"
+	"context"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
 	infrav1 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
+	packerapi "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer/api"
 )
 
 func newFakeMachineScope() *MachineScope {
@@ -84,3 +92,30 @@ func TestMachineScope_GetFailureDomain(t *testing.T) {
 		t.Errorf("unexpected location: %s (expected %s)", act, exp)
 	}
 }
+
+func TestMachineScope_ReconcileImageBuildLog(t *testing.T) {
+	s := newFakeMachineScope()
+	s.Client = fake.NewFakeClientWithScheme(scheme.Scheme)
+	s.HcloudMachine.Namespace = "default"
+	s.HcloudCluster.Name = "cluster"
+	s.HcloudCluster.UID = "uid"
+
+	// the packer build hands over its output already truncated
+	output := append([]byte("[...truncated...]\n"), bytes.Repeat([]byte("x"), packerapi.MaxBuildLogSize)...)
+	buildLog := &packerapi.BuildLog{Hash: "abc", Phase: packerapi.BuildPhaseFailed, Attempts: 1, Output: output}
+	if err := s.reconcileImageBuildLog(context.TODO(), buildLog); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var cm corev1.ConfigMap
+	key := types.NamespacedName{Namespace: "default", Name: ImageBuildLogConfigMapName("abc")}
+	if err := s.Client.Get(context.TODO(), key, &cm); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if log := cm.Data["build.log"]; log != string(output) {
+		t.Errorf("unexpected build log of %d bytes (expected %d bytes)", len(log), len(output))
+	}
+	if refs := cm.OwnerReferences; len(refs) != 1 || refs[0].Kind != "HcloudCluster" || refs[0].UID != "uid" {
+		t.Errorf("unexpected owner references: %v", refs)
+	}
+}
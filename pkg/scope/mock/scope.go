@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope (interfaces: HcloudClient,Manifests,Packer)
+// Source: github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/scope (interfaces: HcloudClient,HrobotClient,Manifests,Packer)
 
 // Package mock_scope is a generated GoMock package.
 package mock_scope
@@ -7,10 +7,14 @@ package mock_scope
 import (
 	context "context"
 	v1alpha3 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/api/v1alpha3"
-	api "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer/api"
+	api "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/api"
+	parameters "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/manifests/parameters"
+	api0 "github.com/cluster-api-provider-hcloud/cluster-api-provider-hcloud/pkg/packer/api"
 	logr "github.com/go-logr/logr"
 	gomock "github.com/golang/mock/gomock"
 	hcloud "github.com/hetznercloud/hcloud-go/hcloud"
+	models "github.com/nl2go/hrobot-go/models"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 	reflect "reflect"
 )
@@ -38,6 +42,70 @@ func (m *MockHcloudClient) EXPECT() *MockHcloudClientMockRecorder {
 	return m.recorder
 }
 
+// AddServiceToLoadBalancer mocks base method
+func (m *MockHcloudClient) AddServiceToLoadBalancer(arg0 context.Context, arg1 *hcloud.LoadBalancer, arg2 hcloud.LoadBalancerAddServiceOpts) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddServiceToLoadBalancer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddServiceToLoadBalancer indicates an expected call of AddServiceToLoadBalancer
+func (mr *MockHcloudClientMockRecorder) AddServiceToLoadBalancer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddServiceToLoadBalancer", reflect.TypeOf((*MockHcloudClient)(nil).AddServiceToLoadBalancer), arg0, arg1, arg2)
+}
+
+// AddTargetServerToLoadBalancer mocks base method
+func (m *MockHcloudClient) AddTargetServerToLoadBalancer(arg0 context.Context, arg1 hcloud.LoadBalancerAddServerTargetOpts, arg2 *hcloud.LoadBalancer) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTargetServerToLoadBalancer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddTargetServerToLoadBalancer indicates an expected call of AddTargetServerToLoadBalancer
+func (mr *MockHcloudClientMockRecorder) AddTargetServerToLoadBalancer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTargetServerToLoadBalancer", reflect.TypeOf((*MockHcloudClient)(nil).AddTargetServerToLoadBalancer), arg0, arg1, arg2)
+}
+
+// AttachLoadBalancerToNetwork mocks base method
+func (m *MockHcloudClient) AttachLoadBalancerToNetwork(arg0 context.Context, arg1 *hcloud.LoadBalancer, arg2 hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachLoadBalancerToNetwork", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AttachLoadBalancerToNetwork indicates an expected call of AttachLoadBalancerToNetwork
+func (mr *MockHcloudClientMockRecorder) AttachLoadBalancerToNetwork(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachLoadBalancerToNetwork", reflect.TypeOf((*MockHcloudClient)(nil).AttachLoadBalancerToNetwork), arg0, arg1, arg2)
+}
+
+// AttachServerToNetwork mocks base method
+func (m *MockHcloudClient) AttachServerToNetwork(arg0 context.Context, arg1 *hcloud.Server, arg2 hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachServerToNetwork", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AttachServerToNetwork indicates an expected call of AttachServerToNetwork
+func (mr *MockHcloudClientMockRecorder) AttachServerToNetwork(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachServerToNetwork", reflect.TypeOf((*MockHcloudClient)(nil).AttachServerToNetwork), arg0, arg1, arg2)
+}
+
 // CreateLoadBalancer mocks base method
 func (m *MockHcloudClient) CreateLoadBalancer(arg0 context.Context, arg1 hcloud.LoadBalancerCreateOpts) (hcloud.LoadBalancerCreateResult, *hcloud.Response, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +215,22 @@ func (mr *MockHcloudClientMockRecorder) DeleteServer(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServer", reflect.TypeOf((*MockHcloudClient)(nil).DeleteServer), arg0, arg1)
 }
 
+// DeleteTargetServerOfLoadBalancer mocks base method
+func (m *MockHcloudClient) DeleteTargetServerOfLoadBalancer(arg0 context.Context, arg1 *hcloud.LoadBalancer, arg2 *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTargetServerOfLoadBalancer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DeleteTargetServerOfLoadBalancer indicates an expected call of DeleteTargetServerOfLoadBalancer
+func (mr *MockHcloudClientMockRecorder) DeleteTargetServerOfLoadBalancer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTargetServerOfLoadBalancer", reflect.TypeOf((*MockHcloudClient)(nil).DeleteTargetServerOfLoadBalancer), arg0, arg1, arg2)
+}
+
 // DeleteVolume mocks base method
 func (m *MockHcloudClient) DeleteVolume(arg0 context.Context, arg1 *hcloud.Volume) (*hcloud.Response, error) {
 	m.ctrl.T.Helper()
@@ -162,6 +246,38 @@ func (mr *MockHcloudClientMockRecorder) DeleteVolume(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVolume", reflect.TypeOf((*MockHcloudClient)(nil).DeleteVolume), arg0, arg1)
 }
 
+// GetLoadBalancerTypeByName mocks base method
+func (m *MockHcloudClient) GetLoadBalancerTypeByName(arg0 context.Context, arg1 string) (*hcloud.LoadBalancerType, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoadBalancerTypeByName", arg0, arg1)
+	ret0, _ := ret[0].(*hcloud.LoadBalancerType)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLoadBalancerTypeByName indicates an expected call of GetLoadBalancerTypeByName
+func (mr *MockHcloudClientMockRecorder) GetLoadBalancerTypeByName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoadBalancerTypeByName", reflect.TypeOf((*MockHcloudClient)(nil).GetLoadBalancerTypeByName), arg0, arg1)
+}
+
+// GetServerByID mocks base method
+func (m *MockHcloudClient) GetServerByID(arg0 context.Context, arg1 int) (*hcloud.Server, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServerByID", arg0, arg1)
+	ret0, _ := ret[0].(*hcloud.Server)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetServerByID indicates an expected call of GetServerByID
+func (mr *MockHcloudClientMockRecorder) GetServerByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServerByID", reflect.TypeOf((*MockHcloudClient)(nil).GetServerByID), arg0, arg1)
+}
+
 // ListImages mocks base method
 func (m *MockHcloudClient) ListImages(arg0 context.Context, arg1 hcloud.ImageListOpts) ([]*hcloud.Image, error) {
 	m.ctrl.T.Helper()
@@ -268,6 +384,38 @@ func (mr *MockHcloudClientMockRecorder) ListVolumes(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVolumes", reflect.TypeOf((*MockHcloudClient)(nil).ListVolumes), arg0, arg1)
 }
 
+// RebootServer mocks base method
+func (m *MockHcloudClient) RebootServer(arg0 context.Context, arg1 *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebootServer", arg0, arg1)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RebootServer indicates an expected call of RebootServer
+func (mr *MockHcloudClientMockRecorder) RebootServer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebootServer", reflect.TypeOf((*MockHcloudClient)(nil).RebootServer), arg0, arg1)
+}
+
+// ResetServer mocks base method
+func (m *MockHcloudClient) ResetServer(arg0 context.Context, arg1 *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetServer", arg0, arg1)
+	ret0, _ := ret[0].(*hcloud.Action)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ResetServer indicates an expected call of ResetServer
+func (mr *MockHcloudClientMockRecorder) ResetServer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetServer", reflect.TypeOf((*MockHcloudClient)(nil).ResetServer), arg0, arg1)
+}
+
 // ShutdownServer mocks base method
 func (m *MockHcloudClient) ShutdownServer(arg0 context.Context, arg1 *hcloud.Server) (*hcloud.Action, *hcloud.Response, error) {
 	m.ctrl.T.Helper()
@@ -298,6 +446,211 @@ func (mr *MockHcloudClientMockRecorder) Token() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockHcloudClient)(nil).Token))
 }
 
+// UpdateLoadBalancer mocks base method
+func (m *MockHcloudClient) UpdateLoadBalancer(arg0 context.Context, arg1 *hcloud.LoadBalancer, arg2 hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLoadBalancer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.LoadBalancer)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateLoadBalancer indicates an expected call of UpdateLoadBalancer
+func (mr *MockHcloudClientMockRecorder) UpdateLoadBalancer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoadBalancer", reflect.TypeOf((*MockHcloudClient)(nil).UpdateLoadBalancer), arg0, arg1, arg2)
+}
+
+// UpdateNetwork mocks base method
+func (m *MockHcloudClient) UpdateNetwork(arg0 context.Context, arg1 *hcloud.Network, arg2 hcloud.NetworkUpdateOpts) (*hcloud.Network, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNetwork", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Network)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateNetwork indicates an expected call of UpdateNetwork
+func (mr *MockHcloudClientMockRecorder) UpdateNetwork(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNetwork", reflect.TypeOf((*MockHcloudClient)(nil).UpdateNetwork), arg0, arg1, arg2)
+}
+
+// UpdateServer mocks base method
+func (m *MockHcloudClient) UpdateServer(arg0 context.Context, arg1 *hcloud.Server, arg2 hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateServer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Server)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateServer indicates an expected call of UpdateServer
+func (mr *MockHcloudClientMockRecorder) UpdateServer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServer", reflect.TypeOf((*MockHcloudClient)(nil).UpdateServer), arg0, arg1, arg2)
+}
+
+// UpdateVolume mocks base method
+func (m *MockHcloudClient) UpdateVolume(arg0 context.Context, arg1 *hcloud.Volume, arg2 hcloud.VolumeUpdateOpts) (*hcloud.Volume, *hcloud.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVolume", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*hcloud.Volume)
+	ret1, _ := ret[1].(*hcloud.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateVolume indicates an expected call of UpdateVolume
+func (mr *MockHcloudClientMockRecorder) UpdateVolume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVolume", reflect.TypeOf((*MockHcloudClient)(nil).UpdateVolume), arg0, arg1, arg2)
+}
+
+// MockHrobotClient is a mock of HrobotClient interface
+type MockHrobotClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockHrobotClientMockRecorder
+}
+
+// MockHrobotClientMockRecorder is the mock recorder for MockHrobotClient
+type MockHrobotClientMockRecorder struct {
+	mock *MockHrobotClient
+}
+
+// NewMockHrobotClient creates a new mock instance
+func NewMockHrobotClient(ctrl *gomock.Controller) *MockHrobotClient {
+	mock := &MockHrobotClient{ctrl: ctrl}
+	mock.recorder = &MockHrobotClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockHrobotClient) EXPECT() *MockHrobotClientMockRecorder {
+	return m.recorder
+}
+
+// ActivateRescue mocks base method
+func (m *MockHrobotClient) ActivateRescue(arg0, arg1 string) (*models.Rescue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateRescue", arg0, arg1)
+	ret0, _ := ret[0].(*models.Rescue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateRescue indicates an expected call of ActivateRescue
+func (mr *MockHrobotClientMockRecorder) ActivateRescue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateRescue", reflect.TypeOf((*MockHrobotClient)(nil).ActivateRescue), arg0, arg1)
+}
+
+// GetBMServer mocks base method
+func (m *MockHrobotClient) GetBMServer(arg0 string) (*models.Server, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBMServer", arg0)
+	ret0, _ := ret[0].(*models.Server)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBMServer indicates an expected call of GetBMServer
+func (mr *MockHrobotClientMockRecorder) GetBMServer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBMServer", reflect.TypeOf((*MockHrobotClient)(nil).GetBMServer), arg0)
+}
+
+// ListBMKeys mocks base method
+func (m *MockHrobotClient) ListBMKeys() ([]models.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBMKeys")
+	ret0, _ := ret[0].([]models.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBMKeys indicates an expected call of ListBMKeys
+func (mr *MockHrobotClientMockRecorder) ListBMKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBMKeys", reflect.TypeOf((*MockHrobotClient)(nil).ListBMKeys))
+}
+
+// ListBMServers mocks base method
+func (m *MockHrobotClient) ListBMServers() ([]models.Server, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBMServers")
+	ret0, _ := ret[0].([]models.Server)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBMServers indicates an expected call of ListBMServers
+func (mr *MockHrobotClientMockRecorder) ListBMServers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBMServers", reflect.TypeOf((*MockHrobotClient)(nil).ListBMServers))
+}
+
+// Password mocks base method
+func (m *MockHrobotClient) Password() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Password")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Password indicates an expected call of Password
+func (mr *MockHrobotClientMockRecorder) Password() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Password", reflect.TypeOf((*MockHrobotClient)(nil).Password))
+}
+
+// ResetBMServer mocks base method
+func (m *MockHrobotClient) ResetBMServer(arg0, arg1 string) (*models.ResetPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetBMServer", arg0, arg1)
+	ret0, _ := ret[0].(*models.ResetPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetBMServer indicates an expected call of ResetBMServer
+func (mr *MockHrobotClientMockRecorder) ResetBMServer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetBMServer", reflect.TypeOf((*MockHrobotClient)(nil).ResetBMServer), arg0, arg1)
+}
+
+// SetBMServerName mocks base method
+func (m *MockHrobotClient) SetBMServerName(arg0, arg1 string) (*models.Server, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBMServerName", arg0, arg1)
+	ret0, _ := ret[0].(*models.Server)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBMServerName indicates an expected call of SetBMServerName
+func (mr *MockHrobotClientMockRecorder) SetBMServerName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBMServerName", reflect.TypeOf((*MockHrobotClient)(nil).SetBMServerName), arg0, arg1)
+}
+
+// UserName mocks base method
+func (m *MockHrobotClient) UserName() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserName")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// UserName indicates an expected call of UserName
+func (mr *MockHrobotClientMockRecorder) UserName() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserName", reflect.TypeOf((*MockHrobotClient)(nil).UserName))
+}
+
 // MockManifests is a mock of Manifests interface
 type MockManifests struct {
 	ctrl     *gomock.Controller
@@ -321,12 +674,28 @@ func (m *MockManifests) EXPECT() *MockManifestsMockRecorder {
 	return m.recorder
 }
 
+// AddonHash mocks base method
+func (m *MockManifests) AddonHash(arg0 api.Addon, arg1 *parameters.ManifestParameters) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddonHash", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddonHash indicates an expected call of AddonHash
+func (mr *MockManifestsMockRecorder) AddonHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddonHash", reflect.TypeOf((*MockManifests)(nil).AddonHash), arg0, arg1)
+}
+
 // Apply mocks base method
-func (m *MockManifests) Apply(arg0 context.Context, arg1 clientcmd.ClientConfig, arg2 map[string]string) error {
+func (m *MockManifests) Apply(arg0 context.Context, arg1 clientcmd.ClientConfig, arg2 *parameters.ManifestParameters) ([]api.ApplyResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Apply", arg0, arg1, arg2)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]api.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Apply indicates an expected call of Apply
@@ -335,8 +704,37 @@ func (mr *MockManifestsMockRecorder) Apply(arg0, arg1, arg2 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockManifests)(nil).Apply), arg0, arg1, arg2)
 }
 
+// ApplyAddon mocks base method
+func (m *MockManifests) ApplyAddon(arg0 context.Context, arg1 clientcmd.ClientConfig, arg2 api.Addon, arg3 *parameters.ManifestParameters) ([]api.ApplyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAddon", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyAddon indicates an expected call of ApplyAddon
+func (mr *MockManifestsMockRecorder) ApplyAddon(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAddon", reflect.TypeOf((*MockManifests)(nil).ApplyAddon), arg0, arg1, arg2, arg3)
+}
+
+// Enabled mocks base method
+func (m *MockManifests) Enabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Enabled indicates an expected call of Enabled
+func (mr *MockManifestsMockRecorder) Enabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enabled", reflect.TypeOf((*MockManifests)(nil).Enabled))
+}
+
 // Hash mocks base method
-func (m *MockManifests) Hash(arg0 map[string]string) (string, error) {
+func (m *MockManifests) Hash(arg0 *parameters.ManifestParameters) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Hash", arg0)
 	ret0, _ := ret[0].(string)
@@ -350,6 +748,51 @@ func (mr *MockManifestsMockRecorder) Hash(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockManifests)(nil).Hash), arg0)
 }
 
+// Prune mocks base method
+func (m *MockManifests) Prune(arg0 context.Context, arg1 clientcmd.ClientConfig, arg2 []api.ObjectReference) ([]api.PruneResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prune", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]api.PruneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prune indicates an expected call of Prune
+func (mr *MockManifestsMockRecorder) Prune(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockManifests)(nil).Prune), arg0, arg1, arg2)
+}
+
+// Render mocks base method
+func (m *MockManifests) Render(arg0 *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", arg0)
+	ret0, _ := ret[0].([]*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render
+func (mr *MockManifestsMockRecorder) Render(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockManifests)(nil).Render), arg0)
+}
+
+// RenderAddon mocks base method
+func (m *MockManifests) RenderAddon(arg0 api.Addon, arg1 *parameters.ManifestParameters) ([]*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderAddon", arg0, arg1)
+	ret0, _ := ret[0].([]*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderAddon indicates an expected call of RenderAddon
+func (mr *MockManifestsMockRecorder) RenderAddon(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderAddon", reflect.TypeOf((*MockManifests)(nil).RenderAddon), arg0, arg1)
+}
+
 // MockPacker is a mock of Packer interface
 type MockPacker struct {
 	ctrl     *gomock.Controller
@@ -373,8 +816,22 @@ func (m *MockPacker) EXPECT() *MockPackerMockRecorder {
 	return m.recorder
 }
 
+// BuildLog mocks base method
+func (m *MockPacker) BuildLog(arg0 string) *api0.BuildLog {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildLog", arg0)
+	ret0, _ := ret[0].(*api0.BuildLog)
+	return ret0
+}
+
+// BuildLog indicates an expected call of BuildLog
+func (mr *MockPackerMockRecorder) BuildLog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildLog", reflect.TypeOf((*MockPacker)(nil).BuildLog), arg0)
+}
+
 // EnsureImage mocks base method
-func (m *MockPacker) EnsureImage(arg0 context.Context, arg1 logr.Logger, arg2 api.HcloudClient, arg3 *api.PackerParameters) (*v1alpha3.HcloudImageID, error) {
+func (m *MockPacker) EnsureImage(arg0 context.Context, arg1 logr.Logger, arg2 api0.HcloudClient, arg3 *api0.PackerParameters) (*v1alpha3.HcloudImageID, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "EnsureImage", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(*v1alpha3.HcloudImageID)
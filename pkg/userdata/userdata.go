@@ -305,6 +305,58 @@ func (u *UserData) SetOrUpdateFile(file bootstrapv1.File) error {
 	return fmt.Errorf("existing file did not have %v keys", strings.Join(keys, ", "))
 }
 
+const jinjaTemplateHeader = "## template: jinja"
+const cloudConfigHeader = "#cloud-config"
+
+// SetJinjaTemplate marks the cloud-init as a Jinja template, so cloud-init
+// renders the instance data referenced by it, e.g. {{ v1.instance_id }}
+func (u *UserData) SetJinjaTemplate() error {
+	if len(u.document.Content) == 0 || u.document.Content[0].Kind != yaml.MappingNode {
+		return errors.New("cloud-init is not a mapping")
+	}
+	m := u.document.Content[0]
+
+	// without an empty line, the header is parsed as comment of the first key
+	head := u.document.HeadComment
+	if head == "" && len(m.Content) > 0 && strings.HasPrefix(m.Content[0].HeadComment, cloudConfigHeader) {
+		head = m.Content[0].HeadComment
+		m.Content[0].HeadComment = ""
+	}
+
+	lines := []string{jinjaTemplateHeader, cloudConfigHeader}
+	for _, line := range strings.Split(head, "\n") {
+		if line == "" || line == jinjaTemplateHeader || line == cloudConfigHeader {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	u.document.HeadComment = strings.Join(lines, "\n")
+	return nil
+}
+
+// SetHostname sets the hostname cloud-init configures on the server
+func (u *UserData) SetHostname(hostname string) error {
+	if len(u.document.Content) == 0 || u.document.Content[0].Kind != yaml.MappingNode {
+		return errors.New("cloud-init is not a mapping")
+	}
+	m := u.document.Content[0]
+
+	for pos, l1 := range m.Content {
+		if next := nextNode(m.Content, pos); pos%2 == 0 && l1.Value == "hostname" && next != nil {
+			next.Kind = yaml.ScalarNode
+			next.Tag = "!!str"
+			next.Value = hostname
+			return nil
+		}
+	}
+
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "hostname"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: hostname},
+	)
+	return nil
+}
+
 func (u *UserData) SkipKubeProxy() error {
 	var n *yaml.Node
 	for _, l1 := range u.document.Content {